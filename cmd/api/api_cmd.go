@@ -26,10 +26,17 @@ func NewHTTPServerCmd() *cobra.Command {
 			container.httpServer.Default()
 			container.graphqlServer.Default()
 			container.marketsRoutes.Register(container.httpServer)
+			container.healthRoutes.Register(container.httpServer)
+			container.adminRoutes.Register(container.httpServer)
 			container.graphqlRoutes.Register(container.httpServer, container.graphqlServer)
 			container.httpServer.Setup()
+			container.scheduler.Start()
 
-			if err := container.httpServer.Run(); err != nil {
+			err = container.httpServer.Run()
+			container.scheduler.Stop()
+			container.db.Close()
+			container.logger.Sync()
+			if err != nil {
 				log.Fatal(err)
 			}
 		},
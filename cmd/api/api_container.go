@@ -1,14 +1,20 @@
 package api
 
 import (
+	"database/sql"
+	"os"
+
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/ralvescosta/base/pkg/app/interfaces"
 	"github.com/ralvescosta/base/pkg/app/usecases"
+	"github.com/ralvescosta/base/pkg/infra/cache"
 	"github.com/ralvescosta/base/pkg/infra/database"
+	"github.com/ralvescosta/base/pkg/infra/environments"
 	graphqlserver "github.com/ralvescosta/base/pkg/infra/graphql_server"
 	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
 	"github.com/ralvescosta/base/pkg/infra/logger"
 	"github.com/ralvescosta/base/pkg/infra/repositories"
+	"github.com/ralvescosta/base/pkg/infra/scheduler"
 	"github.com/ralvescosta/base/pkg/infra/validator"
 	"github.com/ralvescosta/base/pkg/interfaces/graphql/graph/generated"
 	gqlPresenters "github.com/ralvescosta/base/pkg/interfaces/graphql/presenters"
@@ -21,10 +27,14 @@ import (
 
 type HTTPServerContainer struct {
 	logger        interfaces.ILogger
+	db            *sql.DB
 	httpServer    httpServer.IHTTPServer
 	graphqlServer graphqlserver.IGraphqlServer
+	scheduler     interfaces.IScheduler
 
 	marketsRoutes i.IRoutes
+	healthRoutes  i.IRoutes
+	adminRoutes   i.IRoutes
 	graphqlRoutes gqlPresenters.GraphqlRoutes
 }
 
@@ -42,17 +52,38 @@ func NewHTTPContainer(env interfaces.IEnvironments) (HTTPServerContainer, error)
 	}
 
 	httpServer := httpServer.NewHTTPServer(env, logger, shotdown)
+	scheduler := scheduler.NewScheduler(logger)
+	if interval := database.PoolStatsIntervalFromEnv(logger); interval > 0 {
+		if err := scheduler.Register("pool-stats", interval, database.PoolStatsJob(logger, db)); err != nil {
+			return HTTPServerContainer{}, err
+		}
+	}
 
 	vAlidator := validator.NewValidator()
 	httpResFactory := factories.NewHttpResponseFactory()
-	marketRepository := repositories.NewMarketRepository(logger, db)
+	marketsCache, err := newCache(logger)
+	if err != nil {
+		return HTTPServerContainer{}, err
+	}
+	marketRepository := repositories.NewCachedMarketRepository(logger, repositories.NewMarketRepository(logger, db), marketsCache)
+	if os.Getenv("READ_ONLY") == "true" {
+		marketRepository = repositories.NewReadOnlyMarketRepository(logger, marketRepository)
+	}
 
 	createMarketUseCase := usecases.NewCreateMarketUseCase(marketRepository)
 	getByQueryUseCase := usecases.NewGetMarketByQueryUseCase(marketRepository)
 	updateMarketUseCase := usecases.NewUpdateMarketUseCase(marketRepository)
 	deleteMarketUseCase := usecases.NewDeleteMarketUseCase(marketRepository)
-	marketHandlers := handlers.NewMarketHandlers(logger, vAlidator, httpResFactory, createMarketUseCase, getByQueryUseCase, updateMarketUseCase, deleteMarketUseCase)
+	timelineUseCase := usecases.NewGetMarketsTimelineUseCase(marketRepository)
+	summariesUseCase := usecases.NewGetMarketSummariesUseCase(marketRepository)
+	exportUseCase := usecases.NewExportMarketsUseCase(marketRepository)
+	pageUseCase := usecases.NewGetMarketsPageUseCase(marketRepository)
+	marketHandlers := handlers.NewMarketHandlers(logger, vAlidator, httpResFactory, createMarketUseCase, getByQueryUseCase, updateMarketUseCase, deleteMarketUseCase,
+		timelineUseCase, summariesUseCase, exportUseCase, pageUseCase, repositories.MaxPageSizeFromEnv(logger))
 	marketsRoutes := presenters.NewMarketRoutes(logger, marketHandlers)
+	healthRoutes := presenters.NewHealthRoutes(logger, handlers.NewHealthHandlers(httpResFactory, database.NewReadinessChecker(logger, db)))
+	reloader := environments.NewConfigReloader(logger, env, hotReloadables(logger)...)
+	adminRoutes := presenters.NewAdminRoutes(logger, handlers.NewAdminHandlers(httpResFactory, reloader))
 
 	graphqlResolvers := resolvers.NewResolver(createMarketUseCase, getByQueryUseCase, updateMarketUseCase, deleteMarketUseCase)
 
@@ -63,10 +94,30 @@ func NewHTTPContainer(env interfaces.IEnvironments) (HTTPServerContainer, error)
 
 	return HTTPServerContainer{
 		logger,
+		db,
 		httpServer,
 		graphqlServer,
+		scheduler,
 
 		marketsRoutes,
+		healthRoutes,
+		adminRoutes,
 		graphqlRoutes,
 	}, nil
 }
+
+func newCache(logger interfaces.ILogger) (interfaces.ICache, error) {
+	if os.Getenv("CACHE_DRIVER") == "redis" {
+		return cache.NewRedisCache(logger)
+	}
+
+	return cache.NewMemoryCache(), nil
+}
+
+// hotReloadables are the values POST /admin/reload applies without a restart.
+func hotReloadables(log interfaces.ILogger) []environments.Reloadable {
+	return []environments.Reloadable{
+		{Key: "LOG_LEVEL", Validate: logger.ValidateLevel, Apply: logger.SetLevel},
+		{Key: "LATENCY_BUDGETS", Apply: func(string) { httpServer.ReloadLatencyBudgets(log) }},
+	}
+}
@@ -0,0 +1,209 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/lib/pq"
+)
+
+const defaultCopyThreshold = 5000
+
+var now = time.Now
+
+var copyColumns = []string{
+	"long", "lat", "setcens", "areap", "coddist", "distrito", "codsubpref", "subpref", "regiao5", "regiao8",
+	"nome_feira", "registro", "logradouro", "numero", "bairro", "referencia",
+}
+
+type CopyResult struct {
+	Inserted int64
+	Updated  int64
+	Rejected int64
+}
+
+const createStagingTable = `CREATE TEMP TABLE feiras_staging (
+	long INT, lat INT, setcens VARCHAR, areap VARCHAR, coddist INT, distrito VARCHAR, codsubpref INT, subpref VARCHAR,
+	regiao5 VARCHAR, regiao8 VARCHAR, nome_feira VARCHAR, registro VARCHAR, logradouro VARCHAR, numero VARCHAR,
+	bairro VARCHAR, referencia VARCHAR
+) ON COMMIT DROP`
+
+// insertFromStaging keeps the first staged row of each registro and skips the ones already in feiras,
+// matching what the row by row import does through Create.
+var insertFromStaging = fmt.Sprintf(`INSERT INTO feiras (%s, criado_em, atualizado_em)
+	SELECT DISTINCT ON (s.registro) %s, $1, $1
+	FROM feiras_staging s
+	WHERE NOT EXISTS (SELECT 1 FROM feiras f WHERE f.registro = s.registro AND f.deletado_em IS NULL)
+	ORDER BY s.registro`, strings.Join(copyColumns, ", "), prefixed("s.", copyColumns))
+
+// upsertFromStaging relies on the feiras_registro_key partial index. Rows equal to the stored ones are left
+// untouched, so re-importing the same file changes nothing, and xmax = 0 tells the inserted rows apart.
+var upsertFromStaging = fmt.Sprintf(`INSERT INTO feiras (%s, criado_em, atualizado_em)
+	SELECT DISTINCT ON (s.registro) %s, $1, $1
+	FROM feiras_staging s
+	ORDER BY s.registro
+	ON CONFLICT (registro) WHERE deletado_em IS NULL DO UPDATE SET %s, atualizado_em = EXCLUDED.atualizado_em
+	WHERE (%s) IS DISTINCT FROM (%s)
+	RETURNING (xmax = 0) AS inserted`,
+	strings.Join(copyColumns, ", "), prefixed("s.", copyColumns), excludedAssignments(), prefixed("feiras.", updatableColumns()),
+	prefixed("EXCLUDED.", updatableColumns()))
+
+func prefixed(prefix string, columns []string) string {
+	return prefix + strings.Join(columns, ", "+prefix)
+}
+
+func updatableColumns() []string {
+	columns := []string{}
+	for _, column := range copyColumns {
+		if column != "registro" {
+			columns = append(columns, column)
+		}
+	}
+
+	return columns
+}
+
+func excludedAssignments() string {
+	assignments := []string{}
+	for _, column := range updatableColumns() {
+		assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+
+	return strings.Join(assignments, ", ")
+}
+
+// useCopy tells whether a batch goes through CopyImportMarkets: upserts always do, plain imports once they reach
+// the threshold. COPY FROM STDIN goes through lib/pq, so the pgx driver never uses it.
+func useCopy(options ImportOptions, records int) bool {
+	if os.Getenv("DB_DRIVER") == "pgx" {
+		return false
+	}
+
+	return options.Upsert || (options.CopyThreshold > 0 && records >= options.CopyThreshold)
+}
+
+// CopyImportMarkets streams records into a temporary staging table with COPY and merges them into feiras
+// with a single INSERT ... SELECT, all in one transaction. With upsert the markets already stored are
+// updated from the staged rows, otherwise they are skipped. The records Create would reject are left out.
+func CopyImportMarkets(ctx context.Context, logger interfaces.ILogger, db *sql.DB, records []valueObjects.MarketValueObjects,
+	upsert bool) (CopyResult, error) {
+
+	records, rejected := acceptedRecords(logger, records)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	result, err := copyAndMerge(ctx, tx, records, upsert)
+	if err != nil {
+		tx.Rollback()
+		logger.Error(fmt.Sprintf("[Seeder::Copy] - bulk load failure: %s", err.Error()))
+		return CopyResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error(fmt.Sprintf("[Seeder::Copy] - commit failure: %s", err.Error()))
+		return CopyResult{}, err
+	}
+
+	result.Rejected = rejected
+	logger.Info(fmt.Sprintf("[Seeder::Copy] - %d records staged, %d inserted, %d updated, %d rejected", len(records), result.Inserted,
+		result.Updated, result.Rejected))
+
+	return result, nil
+}
+
+// acceptedRecords leaves out and logs the records failing Validate, as the row by row import does through Create.
+// A blank registro is among them, so DISTINCT ON (s.registro) never collapses unrelated rows into one.
+func acceptedRecords(logger interfaces.ILogger, records []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, int64) {
+	valid := make([]valueObjects.MarketValueObjects, 0, len(records))
+	var rejected int64
+	for _, r := range records {
+		if err := r.Validate(); err != nil {
+			logger.Error(fmt.Sprintf("[Seeder::Copy] - registro %s not imported - %s", r.Registro, err.Error()))
+			rejected++
+			continue
+		}
+
+		valid = append(valid, r)
+	}
+
+	return valid, rejected
+}
+
+func copyAndMerge(ctx context.Context, tx *sql.Tx, records []valueObjects.MarketValueObjects, upsert bool) (CopyResult, error) {
+	if err := loadStaging(ctx, tx, records); err != nil {
+		return CopyResult{}, err
+	}
+
+	if upsert {
+		return upsertStaging(ctx, tx)
+	}
+
+	result, err := tx.ExecContext(ctx, insertFromStaging, now())
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return CopyResult{}, err
+	}
+
+	return CopyResult{Inserted: inserted}, nil
+}
+
+func loadStaging(ctx context.Context, tx *sql.Tx, records []valueObjects.MarketValueObjects) error {
+	if _, err := tx.ExecContext(ctx, createStagingTable); err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("feiras_staging", copyColumns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.ExecContext(ctx, r.Long, r.Lat, r.Setcens, r.Areap, r.Coddist, r.Distrito, r.Codsubpref, r.Subpref,
+			r.Regiao5, r.Regiao8, r.NomeFeira, r.Registro, r.Logradouro, r.Numero, r.Bairro, r.Referencia); err != nil {
+			return err
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+
+	return err
+}
+
+func upsertStaging(ctx context.Context, tx *sql.Tx) (CopyResult, error) {
+	rows, err := tx.QueryContext(ctx, upsertFromStaging, now())
+	if err != nil {
+		return CopyResult{}, err
+	}
+	defer rows.Close()
+
+	result := CopyResult{}
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return CopyResult{}, err
+		}
+
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+
+	return result, rows.Err()
+}
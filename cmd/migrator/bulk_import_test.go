@@ -0,0 +1,219 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type BulkImportTestSuite struct {
+	suite.Suite
+
+	db      *sql.DB
+	sqlMock sqlmock.Sqlmock
+	logger  *logger.LoggerSpy
+	records []valueObjects.MarketValueObjects
+}
+
+func TestBulkImportTestSuite(t *testing.T) {
+	suite.Run(t, new(BulkImportTestSuite))
+}
+
+func (s *BulkImportTestSuite) SetupTest() {
+	now = func() time.Time { return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	s.db, s.sqlMock, _ = sqlmock.New()
+	s.logger = logger.NewLoggerSpy()
+	s.records = []valueObjects.MarketValueObjects{
+		{Long: -46550164, Lat: -23558733, Coddist: 87, Distrito: "VILA FORMOSA", Codsubpref: 26, NomeFeira: "VILA FORMOSA", Registro: "4041-0"},
+		{Long: -46574716, Lat: -23584852, Coddist: 95, Distrito: "VILA PRUDENTE", Codsubpref: 29, NomeFeira: "PRACA SANTA HELENA", Registro: "4045-2"},
+	}
+}
+
+func (s *BulkImportTestSuite) TearDownTest() {
+	now = time.Now
+}
+
+func (s *BulkImportTestSuite) TestUseCopyOnlyForLargeBatches() {
+	s.True(useCopy(ImportOptions{CopyThreshold: 3}, 3))
+	s.True(useCopy(ImportOptions{CopyThreshold: 3}, 50000))
+	s.False(useCopy(ImportOptions{CopyThreshold: 3}, 2))
+	s.False(useCopy(ImportOptions{CopyThreshold: 0}, 50000))
+}
+
+func (s *BulkImportTestSuite) TestUseCopyForUpsertsOfAnySize() {
+	s.True(useCopy(ImportOptions{CopyThreshold: 0, Upsert: true}, 1))
+	s.True(useCopy(ImportOptions{CopyThreshold: 3, Upsert: true}, 2))
+}
+
+func (s *BulkImportTestSuite) TestUseCopyFallsBackWithPgx() {
+	os.Setenv("DB_DRIVER", "pgx")
+	defer os.Unsetenv("DB_DRIVER")
+
+	s.False(useCopy(ImportOptions{CopyThreshold: 3}, 50000))
+	s.False(useCopy(ImportOptions{Upsert: true}, 50000))
+}
+
+func (s *BulkImportTestSuite) TestCopyStagesTheRecordsAndMerges() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE TEMP TABLE feiras_staging (.+) ON COMMIT DROP").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare := s.sqlMock.ExpectPrepare(`COPY "feiras_staging" \("long", "lat", (.+), "registro", "logradouro", "numero", "bairro", "referencia"\) FROM STDIN`)
+	for _, r := range s.records {
+		prepare.ExpectExec().WithArgs(r.Long, r.Lat, r.Setcens, r.Areap, r.Coddist, r.Distrito, r.Codsubpref, r.Subpref, r.Regiao5, r.Regiao8,
+			r.NomeFeira, r.Registro, r.Logradouro, r.Numero, r.Bairro, r.Referencia).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectExec(
+		`INSERT INTO feiras \(long, lat, (.+), referencia, criado_em, atualizado_em\) ` +
+			`SELECT DISTINCT ON \(s.registro\) s.long, s.lat, (.+), s.referencia, \$1, \$1 FROM feiras_staging s ` +
+			`WHERE NOT EXISTS \(SELECT 1 FROM feiras f WHERE f.registro = s.registro AND f.deletado_em IS NULL\) ORDER BY s.registro`,
+	).WithArgs(now()).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+	s.logger.On("Info", "[Seeder::Copy] - 2 records staged, 1 inserted, 0 updated, 0 rejected", mock.Anything)
+
+	result, err := CopyImportMarkets(context.Background(), s.logger, s.db, s.records, false)
+
+	s.NoError(err)
+	s.Equal(CopyResult{Inserted: 1}, result)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+	s.logger.AssertExpectations(s.T())
+}
+
+func (s *BulkImportTestSuite) TestCopyLeavesOutTheRecordsCreateWouldReject() {
+	invalid := []valueObjects.MarketValueObjects{
+		{Long: -46550164, Lat: -95000000, Distrito: "VILA FORMOSA", NomeFeira: "FORA DO MAPA", Registro: "5001-0"},
+		{Long: -46550164, Lat: -23558733, Distrito: "VILA FORMOSA", NomeFeira: "SEM REGISTRO"},
+		{Long: -46550164, Lat: -23558733, Distrito: "VILA FORMOSA", NomeFeira: "OUTRA SEM REGISTRO", Registro: " "},
+	}
+
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE TEMP TABLE feiras_staging").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare := s.sqlMock.ExpectPrepare(`COPY "feiras_staging"`)
+	for _, r := range s.records {
+		prepare.ExpectExec().WithArgs(r.Long, r.Lat, r.Setcens, r.Areap, r.Coddist, r.Distrito, r.Codsubpref, r.Subpref, r.Regiao5, r.Regiao8,
+			r.NomeFeira, r.Registro, r.Logradouro, r.Numero, r.Bairro, r.Referencia).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectExec("INSERT INTO feiras").WithArgs(now()).WillReturnResult(sqlmock.NewResult(0, 2))
+	s.sqlMock.ExpectCommit()
+	s.logger.On("Error", "[Seeder::Copy] - registro 5001-0 not imported - Lat must be between -90000000 and 90000000", mock.Anything)
+	s.logger.On("Error", "[Seeder::Copy] - registro  not imported - Registro is required", mock.Anything)
+	s.logger.On("Error", "[Seeder::Copy] - registro   not imported - Registro is required", mock.Anything)
+	s.logger.On("Info", "[Seeder::Copy] - 2 records staged, 2 inserted, 0 updated, 3 rejected", mock.Anything)
+
+	result, err := CopyImportMarkets(context.Background(), s.logger, s.db, append(invalid, s.records...), false)
+
+	s.NoError(err)
+	s.Equal(CopyResult{Inserted: 2, Rejected: 3}, result)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+	s.logger.AssertExpectations(s.T())
+}
+
+func (s *BulkImportTestSuite) TestCopyRollsBackWhenARowFails() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE TEMP TABLE feiras_staging").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare := s.sqlMock.ExpectPrepare(`COPY "feiras_staging"`)
+	prepare.ExpectExec().WillReturnError(fmt.Errorf("invalid input syntax for type integer"))
+	s.sqlMock.ExpectRollback()
+	s.logger.On("Error", "[Seeder::Copy] - bulk load failure: invalid input syntax for type integer", mock.Anything)
+
+	result, err := CopyImportMarkets(context.Background(), s.logger, s.db, s.records, false)
+
+	s.Error(err)
+	s.Equal(CopyResult{}, result)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *BulkImportTestSuite) TestCopyRollsBackWhenTheMergeFails() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE TEMP TABLE feiras_staging").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare := s.sqlMock.ExpectPrepare(`COPY "feiras_staging"`)
+	for range s.records {
+		prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectExec("INSERT INTO feiras").WillReturnError(fmt.Errorf("deadlock detected"))
+	s.sqlMock.ExpectRollback()
+	s.logger.On("Error", "[Seeder::Copy] - bulk load failure: deadlock detected", mock.Anything)
+
+	_, err := CopyImportMarkets(context.Background(), s.logger, s.db, s.records, false)
+
+	s.Error(err)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *BulkImportTestSuite) TestUpsertMergeSQL() {
+	s.Contains(upsertFromStaging, "SELECT DISTINCT ON (s.registro) s.long, s.lat, s.setcens")
+	s.Contains(upsertFromStaging, "ON CONFLICT (registro) WHERE deletado_em IS NULL DO UPDATE SET long = EXCLUDED.long, lat = EXCLUDED.lat")
+	s.Contains(upsertFromStaging, "referencia = EXCLUDED.referencia, atualizado_em = EXCLUDED.atualizado_em")
+	s.Contains(upsertFromStaging, "WHERE (feiras.long, feiras.lat, feiras.setcens")
+	s.Contains(upsertFromStaging, "IS DISTINCT FROM (EXCLUDED.long, EXCLUDED.lat, EXCLUDED.setcens")
+	s.Contains(upsertFromStaging, "RETURNING (xmax = 0) AS inserted")
+	s.NotContains(upsertFromStaging, "registro = EXCLUDED.registro")
+	s.NotContains(upsertFromStaging, "criado_em = EXCLUDED.criado_em")
+}
+
+func (s *BulkImportTestSuite) TestUpsertCountsInsertedAndUpdatedRecords() {
+	s.expectStaging()
+	s.sqlMock.ExpectQuery(
+		`INSERT INTO feiras \(long, lat, (.+), referencia, criado_em, atualizado_em\) ` +
+			`SELECT DISTINCT ON \(s.registro\) s.long, s.lat, (.+), s.referencia, \$1, \$1 FROM feiras_staging s ORDER BY s.registro ` +
+			`ON CONFLICT \(registro\) WHERE deletado_em IS NULL DO UPDATE SET (.+) RETURNING \(xmax = 0\) AS inserted`,
+	).WithArgs(now()).WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true).AddRow(false).AddRow(false))
+	s.sqlMock.ExpectCommit()
+	s.logger.On("Info", "[Seeder::Copy] - 2 records staged, 1 inserted, 2 updated, 0 rejected", mock.Anything)
+
+	result, err := CopyImportMarkets(context.Background(), s.logger, s.db, s.records, true)
+
+	s.NoError(err)
+	s.Equal(CopyResult{Inserted: 1, Updated: 2}, result)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+	s.logger.AssertExpectations(s.T())
+}
+
+func (s *BulkImportTestSuite) TestUpsertOfUnchangedRecordsCountsNothing() {
+	s.expectStaging()
+	s.sqlMock.ExpectQuery("INSERT INTO feiras (.+) ON CONFLICT").WithArgs(now()).WillReturnRows(sqlmock.NewRows([]string{"inserted"}))
+	s.sqlMock.ExpectCommit()
+	s.logger.On("Info", "[Seeder::Copy] - 2 records staged, 0 inserted, 0 updated, 0 rejected", mock.Anything)
+
+	result, err := CopyImportMarkets(context.Background(), s.logger, s.db, s.records, true)
+
+	s.NoError(err)
+	s.Equal(CopyResult{}, result)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *BulkImportTestSuite) TestUpsertRollsBackWhenReadingTheResultsFails() {
+	s.expectStaging()
+	s.sqlMock.ExpectQuery("INSERT INTO feiras (.+) ON CONFLICT").
+		WillReturnRows(sqlmock.NewRows([]string{"inserted"}).AddRow(true).RowError(0, fmt.Errorf("connection reset")))
+	s.sqlMock.ExpectRollback()
+	s.logger.On("Error", "[Seeder::Copy] - bulk load failure: connection reset", mock.Anything)
+
+	result, err := CopyImportMarkets(context.Background(), s.logger, s.db, s.records, true)
+
+	s.Error(err)
+	s.Equal(CopyResult{}, result)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *BulkImportTestSuite) expectStaging() {
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE TEMP TABLE feiras_staging").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare := s.sqlMock.ExpectPrepare(`COPY "feiras_staging"`)
+	for range s.records {
+		prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+}
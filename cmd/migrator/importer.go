@@ -0,0 +1,256 @@
+package migrator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type ColumnMapping map[string]string
+
+type ImportOptions struct {
+	SkipHeader    bool
+	ProgressEvery int
+	// DecimalSeparator is the separator of coordinates written in decimal degrees, "." when empty.
+	DecimalSeparator string
+	// CopyThreshold is the batch size from which the seeder loads through COPY, zero disables it.
+	CopyThreshold int
+	// Upsert updates the markets whose registro is already stored, through COPY when available or row by row otherwise.
+	Upsert bool
+	// CommitEvery groups the row by row import in transactions of N records, zero commits each record.
+	CommitEvery int
+	// Strict rejects the rows missing any of administrativeFields instead of importing them with blanks. The
+	// rows missing requiredFields are rejected either way.
+	Strict bool
+}
+
+var administrativeFields = []string{"Coddist", "Distrito", "Codsubpref", "Subpref", "Regiao5", "Regiao8"}
+
+// requiredFields are rejected in the lenient mode too, before batching: Create refuses a market without a
+// distrito, so with --commit-every such a row would roll back its whole batch.
+var requiredFields = []string{"Distrito"}
+
+var marketFields = []string{
+	"ID", "Long", "Lat", "Setcens", "Areap", "Coddist", "Distrito", "Codsubpref", "Subpref",
+	"Regiao5", "Regiao8", "NomeFeira", "Registro", "Logradouro", "Numero", "Bairro", "Referencia",
+}
+
+var DefaultColumnMapping = ColumnMapping{
+	"ID": "ID", "Long": "LONG", "Lat": "LAT", "Setcens": "SETCENS", "Areap": "AREAP", "Coddist": "CODDIST",
+	"Distrito": "DISTRITO", "Codsubpref": "CODSUBPREF", "Subpref": "SUBPREFE", "Regiao5": "REGIAO5", "Regiao8": "REGIAO8",
+	"NomeFeira": "NOME_FEIRA", "Registro": "REGISTRO", "Logradouro": "LOGRADOURO", "Numero": "NUMERO", "Bairro": "BAIRRO",
+	"Referencia": "REFERENCIA",
+}
+
+func LoadColumnMapping(filePath string) (ColumnMapping, error) {
+	mapping := ColumnMapping{}
+	for field, header := range DefaultColumnMapping {
+		mapping[field] = header
+	}
+
+	if filePath == "" {
+		return mapping, nil
+	}
+
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	custom := ColumnMapping{}
+	if err := json.Unmarshal(raw, &custom); err != nil {
+		return nil, fmt.Errorf("column mapping: invalid file %s - %s", filePath, err.Error())
+	}
+
+	for field, header := range custom {
+		if _, ok := DefaultColumnMapping[field]; !ok {
+			return nil, fmt.Errorf("column mapping: unknown field %s", field)
+		}
+		mapping[field] = header
+	}
+
+	return mapping, nil
+}
+
+func (pst ColumnMapping) indexes(header []string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, h := range header {
+		positions[strings.ToUpper(strings.TrimSpace(h))] = i
+	}
+
+	indexes := make(map[string]int, len(marketFields))
+	for _, field := range marketFields {
+		position, ok := positions[strings.ToUpper(strings.TrimSpace(pst[field]))]
+		if !ok {
+			return nil, fmt.Errorf("column mapping: header %s for field %s not found", pst[field], field)
+		}
+		indexes[field] = position
+	}
+
+	return indexes, nil
+}
+
+func (pst ColumnMapping) isHeader(row []string) bool {
+	headers := make(map[string]bool, len(pst))
+	for _, header := range pst {
+		headers[strings.ToUpper(strings.TrimSpace(header))] = true
+	}
+
+	for _, cell := range row {
+		if headers[strings.ToUpper(strings.TrimSpace(cell))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func positionalIndexes() map[string]int {
+	indexes := make(map[string]int, len(marketFields))
+	for i, field := range marketFields {
+		indexes[field] = i
+	}
+
+	return indexes
+}
+
+func ReadMarkets(logger interfaces.ILogger, reader io.Reader, mapping ColumnMapping, options ImportOptions) ([]valueObjects.MarketValueObjects, error) {
+	csvReader := csv.NewReader(reader)
+
+	first, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv first line unreadable - %s", err.Error())
+	}
+
+	if !options.SkipHeader && mapping.isHeader(first) {
+		indexes, err := mapping.indexes(first)
+		if err != nil {
+			return nil, err
+		}
+
+		return readRecords(logger, csvReader, indexes, options, nil), nil
+	}
+
+	if len(first) < len(marketFields) {
+		return nil, fmt.Errorf("csv read by position must have %d columns, got %d", len(marketFields), len(first))
+	}
+
+	if options.SkipHeader {
+		first = nil
+	}
+
+	return readRecords(logger, csvReader, positionalIndexes(), options, first), nil
+}
+
+// readRecords reads the remaining lines of csvReader, after first when it is a record read by position.
+func readRecords(logger interfaces.ILogger, csvReader *csv.Reader, indexes map[string]int, options ImportOptions,
+	first []string) []valueObjects.MarketValueObjects {
+	var records []valueObjects.MarketValueObjects
+	rejected := 0
+
+	fields, mode := requiredFields, "lenient"
+	if options.Strict {
+		fields, mode = administrativeFields, "strict"
+	}
+
+	rec, line := first, 1
+	for {
+		if rec != nil {
+			if missing := missingFields(rec, indexes, fields); len(missing) > 0 {
+				logger.Warn(fmt.Sprintf("[Seeder] - line %d rejected, missing %s", line, strings.Join(missing, ", ")))
+				rejected++
+			} else {
+				records = append(records, toMarket(rec, indexes, options))
+			}
+		}
+
+		var err error
+		rec, err = csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("csv line unformatted - %s", err.Error()))
+			rec = nil
+			continue
+		}
+		line, _ = csvReader.FieldPos(0)
+	}
+
+	if rejected > 0 {
+		logger.Warn(fmt.Sprintf("[Seeder] - %d rows rejected by the %s mode", rejected, mode))
+	}
+
+	return records
+}
+
+func missingFields(rec []string, indexes map[string]int, fields []string) []string {
+	var missing []string
+	for _, field := range fields {
+		if strings.TrimSpace(rec[indexes[field]]) == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	return missing
+}
+
+// parseCoordinate reads a coordinate either in the integer micro-degrees of the source dataset
+// (-23558733) or in decimal degrees written with separator (-23,558733), returning micro-degrees.
+func parseCoordinate(raw, separator string) int {
+	raw = strings.TrimSpace(raw)
+	if separator == "" {
+		separator = "."
+	}
+
+	if !strings.Contains(raw, separator) {
+		value, _ := strconv.Atoi(raw)
+		return value
+	}
+
+	degrees, err := strconv.ParseFloat(strings.Replace(raw, separator, ".", 1), 64)
+	if err != nil {
+		return 0
+	}
+
+	return int(math.Round(degrees * 1e6))
+}
+
+func toMarket(rec []string, indexes map[string]int, options ImportOptions) valueObjects.MarketValueObjects {
+	id, _ := strconv.Atoi(rec[indexes["ID"]])
+	long := parseCoordinate(rec[indexes["Long"]], options.DecimalSeparator)
+	lat := parseCoordinate(rec[indexes["Lat"]], options.DecimalSeparator)
+	coddist, _ := strconv.Atoi(rec[indexes["Coddist"]])
+	codsubpref, _ := strconv.Atoi(rec[indexes["Codsubpref"]])
+
+	return valueObjects.MarketValueObjects{
+		ID:         id,
+		Long:       long,
+		Lat:        lat,
+		Setcens:    rec[indexes["Setcens"]],
+		Areap:      rec[indexes["Areap"]],
+		Coddist:    coddist,
+		Distrito:   rec[indexes["Distrito"]],
+		Codsubpref: codsubpref,
+		Subpref:    rec[indexes["Subpref"]],
+		Regiao5:    rec[indexes["Regiao5"]],
+		Regiao8:    rec[indexes["Regiao8"]],
+		NomeFeira:  rec[indexes["NomeFeira"]],
+		Registro:   rec[indexes["Registro"]],
+		Logradouro: rec[indexes["Logradouro"]],
+		Numero:     rec[indexes["Numero"]],
+		Bairro:     rec[indexes["Bairro"]],
+		Referencia: rec[indexes["Referencia"]],
+	}
+}
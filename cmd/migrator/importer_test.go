@@ -0,0 +1,259 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ImporterTestSuite struct {
+	suite.Suite
+}
+
+func TestImporterTestSuite(t *testing.T) {
+	suite.Run(t, new(ImporterTestSuite))
+}
+
+const reorderedCsv = `nome,registro,distrito,id,lon,lat,setor,area,cod_distrito,cod_subprefeitura,subprefeitura,regiao_5,regiao_8,rua,numero,bairro,referencia
+VILA FORMOSA,4041-0,VILA FORMOSA,1,-46550164,-23558733,355030885000091,3550308005040,87,26,ARICANDUVA,Leste,Leste 1,RUA MARAGOJIPE,S/N,VL FORMOSA,TV RUA PRETORIA
+`
+
+const csvHeader = "ID,LONG,LAT,SETCENS,AREAP,CODDIST,DISTRITO,CODSUBPREF,SUBPREFE,REGIAO5,REGIAO8,NOME_FEIRA,REGISTRO,LOGRADOURO,NUMERO,BAIRRO,REFERENCIA\n"
+
+const csvLine = "1,-46550164,-23558733,355030885000091,3550308005040,87,VILA FORMOSA,26,ARICANDUVA,Leste,Leste 1,VILA FORMOSA,4041-0,RUA MARAGOJIPE,S/N,VL FORMOSA,TV RUA PRETORIA\n"
+
+const reorderedMapping = `{
+	"ID": "id", "Long": "lon", "Lat": "lat", "Setcens": "setor", "Areap": "area", "Coddist": "cod_distrito",
+	"Distrito": "distrito", "Codsubpref": "cod_subprefeitura", "Subpref": "subprefeitura", "Regiao5": "regiao_5",
+	"Regiao8": "regiao_8", "NomeFeira": "nome", "Registro": "registro", "Logradouro": "rua", "Numero": "numero",
+	"Bairro": "bairro", "Referencia": "referencia"
+}`
+
+func (s *ImporterTestSuite) TestReadMarketsWithDefaultMapping() {
+	file, err := os.Open("DEINFO_AB_FEIRASLIVRES_2014.csv")
+	s.NoError(err)
+	defer file.Close()
+
+	mapping, err := LoadColumnMapping("")
+	s.NoError(err)
+	logger := logger.NewLoggerSpy()
+	logger.On("Error", "csv line unformatted - record on line 881: wrong number of fields", mock.Anything)
+
+	markets, err := ReadMarkets(logger, file, mapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Equal(879, len(markets))
+	s.Equal("4041-0", markets[0].Registro)
+	s.Equal(-46550164, markets[0].Long)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsWithReorderedHeaders() {
+	mapping, err := LoadColumnMapping(s.writeFile("mapping.json", reorderedMapping))
+	s.NoError(err)
+
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(reorderedCsv), mapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Equal([]valueObjects.MarketValueObjects{{
+		ID:         1,
+		Long:       -46550164,
+		Lat:        -23558733,
+		Setcens:    "355030885000091",
+		Areap:      "3550308005040",
+		Coddist:    87,
+		Distrito:   "VILA FORMOSA",
+		Codsubpref: 26,
+		Subpref:    "ARICANDUVA",
+		Regiao5:    "Leste",
+		Regiao8:    "Leste 1",
+		NomeFeira:  "VILA FORMOSA",
+		Registro:   "4041-0",
+		Logradouro: "RUA MARAGOJIPE",
+		Numero:     "S/N",
+		Bairro:     "VL FORMOSA",
+		Referencia: "TV RUA PRETORIA",
+	}}, markets)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsMissingMappedHeader() {
+	mapping, err := LoadColumnMapping(s.writeFile("mapping.json", `{"Referencia": "observacao"}`))
+	s.NoError(err)
+
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader("ID,LONG\n1,2\n"), mapping, ImportOptions{})
+
+	s.EqualError(err, "column mapping: header LAT for field Lat not found")
+	s.Nil(markets)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsAutodetectHeaderPresent() {
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(csvHeader+csvLine), DefaultColumnMapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal("4041-0", markets[0].Registro)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsAutodetectHeaderAbsent() {
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(csvLine+csvLine), DefaultColumnMapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Len(markets, 2)
+	s.Equal(1, markets[0].ID)
+	s.Equal("4041-0", markets[0].Registro)
+	s.Equal("TV RUA PRETORIA", markets[1].Referencia)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsSkipHeader() {
+	header := "id,longitude,latitude,a,b,c,d,e,f,g,h,i,j,k,l,m,n\n"
+
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(header+csvLine), DefaultColumnMapping, ImportOptions{SkipHeader: true})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal(-46550164, markets[0].Long)
+	s.Equal("4041-0", markets[0].Registro)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsSkipHeaderWithoutEnoughColumns() {
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader("a,b\n1,2\n"), DefaultColumnMapping, ImportOptions{SkipHeader: true})
+
+	s.EqualError(err, "csv read by position must have 17 columns, got 2")
+	s.Nil(markets)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsCommaDecimalCoordinates() {
+	line := "1,\"-46,550164\",\"-23,55\",355030885000091,3550308005040,87,VILA FORMOSA,26,ARICANDUVA,Leste,Leste 1,VILA FORMOSA,4041-0,RUA MARAGOJIPE,S/N,VL FORMOSA,TV RUA PRETORIA\n"
+
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(csvHeader+line), DefaultColumnMapping, ImportOptions{DecimalSeparator: ","})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal(-46550164, markets[0].Long)
+	s.Equal(-23550000, markets[0].Lat)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsDotDecimalCoordinates() {
+	line := "1,-46.550164,-23.55,355030885000091,3550308005040,87,VILA FORMOSA,26,ARICANDUVA,Leste,Leste 1,VILA FORMOSA,4041-0,RUA MARAGOJIPE,S/N,VL FORMOSA,TV RUA PRETORIA\n"
+
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(csvHeader+line), DefaultColumnMapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal(-46550164, markets[0].Long)
+	s.Equal(-23550000, markets[0].Lat)
+}
+
+const partialCsvLine = "2,-46574716,-23584852,355030893000035,3550308005042,95,,29,VILA PRUDENTE,Leste,,PRACA SANTA HELENA,4045-2,RUA JOSE DOS REIS,909,VL ZELINA,RUA OLIVEIRA GOUVEIA\n"
+
+const noRegionCsvLine = "3,-46574716,-23584852,355030893000035,3550308005042,95,VILA PRUDENTE,29,VILA PRUDENTE,,,PRACA SANTA HELENA,4046-0,RUA JOSE DOS REIS,909,VL ZELINA,RUA OLIVEIRA GOUVEIA\n"
+
+func (s *ImporterTestSuite) TestReadMarketsLenientKeepsPartialRows() {
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(csvHeader+csvLine+noRegionCsvLine), DefaultColumnMapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Len(markets, 2)
+	s.Equal("4046-0", markets[1].Registro)
+	s.Equal("VILA PRUDENTE", markets[1].Distrito)
+	s.Equal("", markets[1].Regiao5)
+	s.Equal("", markets[1].Regiao8)
+}
+
+func (s *ImporterTestSuite) TestReadMarketsLenientRejectsRowsWithoutDistrito() {
+	logger := logger.NewLoggerSpy()
+	logger.On("Warn", "[Seeder] - line 3 rejected, missing Distrito", mock.Anything)
+	logger.On("Warn", "[Seeder] - 1 rows rejected by the lenient mode", mock.Anything)
+
+	markets, err := ReadMarkets(logger, strings.NewReader(csvHeader+csvLine+partialCsvLine), DefaultColumnMapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal("4041-0", markets[0].Registro)
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ImporterTestSuite) TestReadMarketsStrictRejectsPartialRows() {
+	logger := logger.NewLoggerSpy()
+	logger.On("Warn", "[Seeder] - line 3 rejected, missing Distrito, Regiao8", mock.Anything)
+	logger.On("Warn", "[Seeder] - 1 rows rejected by the strict mode", mock.Anything)
+
+	markets, err := ReadMarkets(logger, strings.NewReader(csvHeader+csvLine+partialCsvLine), DefaultColumnMapping, ImportOptions{Strict: true})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal("4041-0", markets[0].Registro)
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ImporterTestSuite) TestReadMarketsStrictChecksTheFirstRowReadByPosition() {
+	logger := logger.NewLoggerSpy()
+	logger.On("Warn", "[Seeder] - line 1 rejected, missing Distrito, Regiao8", mock.Anything)
+	logger.On("Warn", "[Seeder] - 1 rows rejected by the strict mode", mock.Anything)
+
+	markets, err := ReadMarkets(logger, strings.NewReader(partialCsvLine+csvLine), DefaultColumnMapping, ImportOptions{Strict: true})
+
+	s.NoError(err)
+	s.Len(markets, 1)
+	s.Equal("4041-0", markets[0].Registro)
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ImporterTestSuite) TestReadMarketsStrictKeepsCompleteRows() {
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(csvHeader+csvLine+csvLine), DefaultColumnMapping, ImportOptions{Strict: true})
+
+	s.NoError(err)
+	s.Len(markets, 2)
+}
+
+func (s *ImporterTestSuite) TestParseCoordinate() {
+	s.Equal(-23558733, parseCoordinate("-23558733", ","))
+	s.Equal(-23558733, parseCoordinate(" -23558733 ", ""))
+	s.Equal(-23550000, parseCoordinate("-23,55", ","))
+	s.Equal(-23550000, parseCoordinate("-23.55", "."))
+	s.Equal(-23550000, parseCoordinate("-23.55", ""))
+	s.Equal(0, parseCoordinate("-23,5,5", ","))
+}
+
+func (s *ImporterTestSuite) TestReadMarketsEmptyFile() {
+	markets, err := ReadMarkets(logger.NewLoggerSpy(), strings.NewReader(""), DefaultColumnMapping, ImportOptions{})
+
+	s.NoError(err)
+	s.Empty(markets)
+}
+
+func (s *ImporterTestSuite) TestLoadColumnMappingOverridesDefaults() {
+	mapping, err := LoadColumnMapping(s.writeFile("mapping.json", `{"NomeFeira": "nome"}`))
+
+	s.NoError(err)
+	s.Equal("nome", mapping["NomeFeira"])
+	s.Equal("REGISTRO", mapping["Registro"])
+	s.Equal("NOME_FEIRA", DefaultColumnMapping["NomeFeira"])
+}
+
+func (s *ImporterTestSuite) TestLoadColumnMappingUnknownField() {
+	mapping, err := LoadColumnMapping(s.writeFile("mapping.json", `{"Cep": "cep"}`))
+
+	s.EqualError(err, "column mapping: unknown field Cep")
+	s.Nil(mapping)
+}
+
+func (s *ImporterTestSuite) TestLoadColumnMappingInvalidFile() {
+	_, err := LoadColumnMapping(s.writeFile("mapping.json", `[]`))
+	s.Error(err)
+
+	_, err = LoadColumnMapping(filepath.Join(s.T().TempDir(), "missing.json"))
+	s.Error(err)
+}
+
+func (s *ImporterTestSuite) writeFile(name, content string) string {
+	path := filepath.Join(s.T().TempDir(), name)
+	s.NoError(os.WriteFile(path, []byte(content), 0644))
+
+	return path
+}
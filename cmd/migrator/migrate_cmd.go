@@ -0,0 +1,83 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/database"
+	"github.com/ralvescosta/base/pkg/infra/environments"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/spf13/cobra"
+)
+
+func NewMigrateCmd() *cobra.Command {
+	var dir string
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "GoLang Base Application Schema Migrations Command",
+	}
+	cmd.PersistentFlags().StringVar(&dir, "dir", database.MigrationsDir(), "directory with the *_up.sql and *_down.sql migrations")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "List the applied and pending migrations",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, db := connect()
+
+			states, err := MigrationStatus(context.Background(), db, dir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			PrintMigrationStatus(cmd.OutOrStdout(), states)
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply every pending migration",
+		Run: func(cmd *cobra.Command, args []string) {
+			logger, db := connect()
+
+			if _, err := MigrateUp(context.Background(), logger, db, dir); err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "down",
+		Short: "Revert the last applied migration",
+		Run: func(cmd *cobra.Command, args []string) {
+			logger, db := connect()
+
+			if _, err := MigrateDown(context.Background(), logger, db, dir); err != nil {
+				log.Fatal(err)
+			}
+		},
+	})
+
+	return cmd
+}
+
+func connect() (interfaces.ILogger, *sql.DB) {
+	if err := environments.NewEnvironment().Configure(); err != nil {
+		log.Fatal(err)
+	}
+
+	logger, err := logger.NewLogger()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.Connect(logger, make(chan bool))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return logger, db
+}
@@ -0,0 +1,144 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/database"
+)
+
+type MigrationState struct {
+	Name    string
+	Applied bool
+}
+
+// MigrationStatus returns every *_up.sql file of dir, in the order they are applied, flagged with
+// whether the migrations table records it.
+func MigrationStatus(ctx context.Context, db *sql.DB, dir string) ([]MigrationState, error) {
+	files, err := upMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := database.AppliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(files))
+	for _, name := range files {
+		states = append(states, MigrationState{Name: name, Applied: applied[name]})
+	}
+
+	return states, nil
+}
+
+func PrintMigrationStatus(w io.Writer, states []MigrationState) {
+	for _, state := range states {
+		status := "pending"
+		if state.Applied {
+			status = "applied"
+		}
+		fmt.Fprintf(w, "%-8s %s\n", status, state.Name)
+	}
+}
+
+// MigrateUp applies the pending migrations of dir in order, each one in its own transaction.
+func MigrateUp(ctx context.Context, logger interfaces.ILogger, db *sql.DB, dir string) ([]string, error) {
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS migrations (name varchar not null, created_at TIMESTAMPTZ)"); err != nil {
+		return nil, err
+	}
+
+	pending, err := database.PendingMigrations(ctx, db, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []string{}
+	for _, name := range pending {
+		err := runMigration(ctx, db, filepath.Join(dir, name), "INSERT INTO migrations (name, created_at) VALUES ($1, $2)", name, time.Now())
+		if err != nil {
+			logger.Error(fmt.Sprintf("[Migrator::Up] - %s failure: %s", name, err.Error()))
+			return applied, err
+		}
+
+		logger.Info(fmt.Sprintf("[Migrator::Up] - %s applied", name))
+		applied = append(applied, name)
+	}
+
+	return applied, nil
+}
+
+// MigrateDown reverts the last applied migration running its *_down.sql pair.
+func MigrateDown(ctx context.Context, logger interfaces.ILogger, db *sql.DB, dir string) (string, error) {
+	var name string
+	err := db.QueryRowContext(ctx, "SELECT name FROM migrations ORDER BY created_at DESC LIMIT 1").Scan(&name)
+	if err == sql.ErrNoRows {
+		logger.Info("[Migrator::Down] - no migration to revert")
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	down := strings.TrimSuffix(name, "_up.sql") + "_down.sql"
+	if err := runMigration(ctx, db, filepath.Join(dir, down), "DELETE FROM migrations WHERE name = $1", name); err != nil {
+		logger.Error(fmt.Sprintf("[Migrator::Down] - %s failure: %s", down, err.Error()))
+		return "", err
+	}
+
+	logger.Info(fmt.Sprintf("[Migrator::Down] - %s reverted", name))
+
+	return name, nil
+}
+
+func runMigration(ctx context.Context, db *sql.DB, file, record string, args ...interface{}) error {
+	script, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func upMigrations(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), "_up.sql") {
+			names = append(names, f.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,123 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationsTestSuite struct {
+	suite.Suite
+
+	dir     string
+	db      *sql.DB
+	sqlMock sqlmock.Sqlmock
+	logger  *logger.LoggerSpy
+}
+
+func TestMigrationsTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrationsTestSuite))
+}
+
+func (s *MigrationsTestSuite) SetupTest() {
+	s.dir = s.T().TempDir()
+	files := map[string]string{
+		"feiras_up.sql":            "CREATE TABLE feiras (id serial)",
+		"feiras_down.sql":          "DROP TABLE feiras",
+		"registro_unique_up.sql":   "CREATE UNIQUE INDEX feiras_registro_key ON feiras (registro)",
+		"registro_unique_down.sql": "DROP INDEX feiras_registro_key",
+	}
+	for name, content := range files {
+		ioutil.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644)
+	}
+
+	s.db, s.sqlMock, _ = sqlmock.New()
+	s.logger = logger.NewLoggerSpy()
+}
+
+func (s *MigrationsTestSuite) TestStatusListsAppliedAndPending() {
+	s.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(s.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql"))
+
+	states, err := MigrationStatus(context.Background(), s.db, s.dir)
+
+	s.NoError(err)
+	s.Equal([]MigrationState{{Name: "feiras_up.sql", Applied: true}, {Name: "registro_unique_up.sql", Applied: false}}, states)
+
+	out := &bytes.Buffer{}
+	PrintMigrationStatus(out, states)
+	s.Equal("applied  feiras_up.sql\npending  registro_unique_up.sql\n", out.String())
+}
+
+func (s *MigrationsTestSuite) TestStatusFailsWhenTheTableCanNotBeRead() {
+	s.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnError(fmt.Errorf("relation \"migrations\" does not exist"))
+
+	_, err := MigrationStatus(context.Background(), s.db, s.dir)
+
+	s.Error(err)
+}
+
+func (s *MigrationsTestSuite) TestUpAppliesThePendingMigrations() {
+	s.sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(s.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql"))
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE UNIQUE INDEX feiras_registro_key").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectExec("INSERT INTO migrations").WithArgs("registro_unique_up.sql", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+	s.logger.On("Info", "[Migrator::Up] - registro_unique_up.sql applied", mock.Anything)
+
+	applied, err := MigrateUp(context.Background(), s.logger, s.db, s.dir)
+
+	s.NoError(err)
+	s.Equal([]string{"registro_unique_up.sql"}, applied)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *MigrationsTestSuite) TestUpRollsBackAFailedMigration() {
+	s.sqlMock.ExpectExec("CREATE TABLE IF NOT EXISTS migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(s.sqlMock.NewRows([]string{"name"}))
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("CREATE TABLE feiras").WillReturnError(fmt.Errorf("syntax error"))
+	s.sqlMock.ExpectRollback()
+	s.logger.On("Error", "[Migrator::Up] - feiras_up.sql failure: syntax error", mock.Anything)
+
+	applied, err := MigrateUp(context.Background(), s.logger, s.db, s.dir)
+
+	s.Error(err)
+	s.Empty(applied)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *MigrationsTestSuite) TestDownRevertsTheLastMigration() {
+	s.sqlMock.ExpectQuery("SELECT name FROM migrations ORDER BY created_at DESC LIMIT 1").WillReturnRows(s.sqlMock.NewRows([]string{"name"}).AddRow("registro_unique_up.sql"))
+	s.sqlMock.ExpectBegin()
+	s.sqlMock.ExpectExec("DROP INDEX feiras_registro_key").WillReturnResult(sqlmock.NewResult(0, 0))
+	s.sqlMock.ExpectExec("DELETE FROM migrations WHERE name = \\$1").WithArgs("registro_unique_up.sql").WillReturnResult(sqlmock.NewResult(0, 1))
+	s.sqlMock.ExpectCommit()
+	s.logger.On("Info", "[Migrator::Down] - registro_unique_up.sql reverted", mock.Anything)
+
+	reverted, err := MigrateDown(context.Background(), s.logger, s.db, s.dir)
+
+	s.NoError(err)
+	s.Equal("registro_unique_up.sql", reverted)
+	s.NoError(s.sqlMock.ExpectationsWereMet())
+}
+
+func (s *MigrationsTestSuite) TestDownWithoutAppliedMigrations() {
+	s.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(s.sqlMock.NewRows([]string{"name"}))
+	s.logger.On("Info", "[Migrator::Down] - no migration to revert", mock.Anything)
+
+	reverted, err := MigrateDown(context.Background(), s.logger, s.db, s.dir)
+
+	s.NoError(err)
+	s.Empty(reverted)
+}
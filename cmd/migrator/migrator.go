@@ -3,13 +3,10 @@ package migrator
 import (
 	"context"
 	"database/sql"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -39,6 +36,11 @@ func ListTables(ctx context.Context, logger interfaces.ILogger, db *sql.DB) ([]s
 		tables = append(tables, table)
 	}
 
+	if err := rawTables.Err(); err != nil {
+		logger.Error(fmt.Sprintf("[Migrator::ListTables] - reading the tables failure: %s", err.Error()))
+		return []string{}, err
+	}
+
 	return tables, nil
 }
 
@@ -65,7 +67,9 @@ func ListMigrations() ([]string, error) {
 
 	migrates := []string{}
 	for _, f := range files {
-		migrates = append(migrates, f.Name())
+		if !f.IsDir() {
+			migrates = append(migrates, f.Name())
+		}
 	}
 
 	return migrates, nil
@@ -145,7 +149,7 @@ func Migrate() {
 	}
 }
 
-func Seeder() {
+func Seeder(mappingFile string, options ImportOptions) {
 	if err := environments.NewEnvironment().Configure(); err != nil {
 		log.Fatal(err)
 	}
@@ -162,8 +166,13 @@ func Seeder() {
 	}
 	fileDir := currentDir + "/cmd/seeders/DEINFO_AB_FEIRASLIVRES_2014.csv"
 
+	mapping, err := LoadColumnMapping(mappingFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	logger.Info("[Seeder] - Reading the CSV file...")
-	records := readCsvFile(logger, fileDir)
+	records := readCsvFile(logger, fileDir, mapping, options)
 	logger.Info("[Seeder] - CSV File read")
 
 	logger.Info("[Seeder] - Connection to the database...")
@@ -177,68 +186,35 @@ func Seeder() {
 	row := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM feiras")
 	var amount int
 	row.Scan(&amount)
-	if amount >= len(records) {
+	if !options.Upsert && amount >= len(records) {
 		logger.Info("[Seeder] - Seeder has already been run")
 		return
 	}
 
-	logger.Info("[Seeder] - Register records in database...")
-	for _, r := range records {
-		marketRepository.Create(context.Background(), r)
+	if useCopy(options, len(records)) {
+		logger.Info("[Seeder] - Register records in database through COPY...")
+		if _, err := CopyImportMarkets(context.Background(), logger, db, records, options.Upsert); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-	logger.Info("[Seeder] finished successfully")
+
+	logger.Info("[Seeder] - Register records in database...")
+	errors := ImportMarketsInBatches(context.Background(), logger, marketRepository, records, NewStderrProgressReporter(), options.ProgressEvery,
+		options.CommitEvery, options.Upsert)
+	logger.Info(fmt.Sprintf("[Seeder] finished with %d errors", errors))
 }
 
-func readCsvFile(logger interfaces.ILogger, filePath string) []valueObjects.MarketValueObjects {
+func readCsvFile(logger interfaces.ILogger, filePath string, mapping ColumnMapping, options ImportOptions) []valueObjects.MarketValueObjects {
 	f, err := os.Open(filePath)
 	if err != nil {
 		log.Fatal("Unable to read input file "+filePath, err)
 	}
 	defer f.Close()
 
-	csvReader := csv.NewReader(f)
-	var records []valueObjects.MarketValueObjects
-	bypassFirstLine := 0
-	for {
-		rec, err := csvReader.Read()
-		if bypassFirstLine == 0 {
-			bypassFirstLine++
-			continue
-		}
-		if err != nil && err != io.EOF {
-			logger.Error(fmt.Sprintf("csv line unformatted - %s", err.Error()))
-			continue
-		}
-		if err != nil && err == io.EOF {
-			break
-		}
-
-		id, _ := strconv.Atoi(rec[0])
-		long, _ := strconv.Atoi(rec[1])
-		lat, _ := strconv.Atoi(rec[2])
-		coddist, _ := strconv.Atoi(rec[5])
-		codsubpref, _ := strconv.Atoi(rec[7])
-
-		vo := valueObjects.MarketValueObjects{
-			ID:         id,
-			Long:       long,
-			Lat:        lat,
-			Setcens:    rec[3],
-			Areap:      rec[4],
-			Coddist:    coddist,
-			Distrito:   rec[6],
-			Codsubpref: codsubpref,
-			Subpref:    rec[8],
-			Regiao5:    rec[9],
-			Regiao8:    rec[10],
-			NomeFeira:  rec[11],
-			Registro:   rec[12],
-			Logradouro: rec[13],
-			Numero:     rec[14],
-			Bairro:     rec[15],
-			Referencia: rec[16],
-		}
-		records = append(records, vo)
+	records, err := ReadMarkets(logger, f, mapping, options)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	return records
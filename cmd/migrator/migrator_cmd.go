@@ -3,11 +3,25 @@ package migrator
 import "github.com/spf13/cobra"
 
 func NewMigratorCmd() *cobra.Command {
-	return &cobra.Command{
+	var mappingFile string
+	var options ImportOptions
+
+	cmd := &cobra.Command{
 		Use:   "seeders",
 		Short: "GoLang Base Application Migration Command",
 		Run: func(cmd *cobra.Command, args []string) {
-			//
+			Seeder(mappingFile, options)
 		},
 	}
+
+	cmd.Flags().StringVar(&mappingFile, "mapping", "", "JSON file mapping market fields to CSV headers")
+	cmd.Flags().BoolVar(&options.SkipHeader, "skip-header", false, "skip the first CSV line and read the columns by position")
+	cmd.Flags().IntVar(&options.ProgressEvery, "progress-every", defaultProgressEvery, "report the import progress every N records")
+	cmd.Flags().IntVar(&options.CopyThreshold, "copy-threshold", defaultCopyThreshold, "load batches of at least N records through COPY, 0 disables it")
+	cmd.Flags().IntVar(&options.CommitEvery, "commit-every", 0, "import row by row in transactions of N records, 0 commits each record")
+	cmd.Flags().BoolVar(&options.Upsert, "upsert", false, "update the markets already stored instead of skipping them")
+	cmd.Flags().BoolVar(&options.Strict, "strict", false, "reject the rows missing subprefeitura or region fields instead of importing them with blanks, distrito is always required")
+	cmd.Flags().StringVar(&options.DecimalSeparator, "decimal-separator", ".", "decimal separator of coordinates written in degrees, e.g. , for -23,55")
+
+	return cmd
 }
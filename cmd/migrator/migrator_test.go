@@ -1,8 +1,14 @@
 package migrator
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -20,3 +26,17 @@ func (s *MigratorTestSuite) TestListMigrations() {
 	s.NoError(err)
 	s.NotNil(migrations)
 }
+
+func (s *MigratorTestSuite) TestListTablesRowsErr() {
+	db, sqlMock, _ := sqlmock.New()
+	logger := logger.NewLoggerSpy()
+	rows := sqlMock.NewRows([]string{"table"}).AddRow("feiras").AddRow("migrations").RowError(1, fmt.Errorf("connection reset"))
+	sqlMock.ExpectQuery("SHOW tables").WillReturnRows(rows)
+	logger.On("Error", "[Migrator::ListTables] - reading the tables failure: connection reset", mock.Anything)
+
+	tables, err := ListTables(context.Background(), logger, db)
+
+	s.Error(err)
+	s.Empty(tables)
+	logger.AssertExpectations(s.T())
+}
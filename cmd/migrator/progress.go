@@ -0,0 +1,132 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+const defaultProgressEvery = 100
+
+type ProgressReporter interface {
+	Report(processed, total, errors int)
+}
+
+type writerProgressReporter struct {
+	writer io.Writer
+}
+
+func (pst writerProgressReporter) Report(processed, total, errors int) {
+	fmt.Fprintf(pst.writer, "\r[Seeder] - %d/%d processed, %d errors", processed, total, errors)
+	if processed == total {
+		fmt.Fprintln(pst.writer)
+	}
+}
+
+func NewStderrProgressReporter() ProgressReporter {
+	return writerProgressReporter{os.Stderr}
+}
+
+// importMarket creates the record, or upserts it when upsert is set, counting it in stats.
+func importMarket(ctx context.Context, repo interfaces.IMarketRepository, r valueObjects.MarketValueObjects, upsert bool,
+	stats *CopyResult) error {
+
+	if !upsert {
+		_, err := repo.Create(ctx, r)
+		if err == nil {
+			stats.Inserted++
+		}
+		return err
+	}
+
+	_, inserted, err := repo.Upsert(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if inserted {
+		stats.Inserted++
+	} else {
+		stats.Updated++
+	}
+
+	return nil
+}
+
+func ImportMarkets(ctx context.Context, logger interfaces.ILogger, repo interfaces.IMarketRepository, records []valueObjects.MarketValueObjects,
+	reporter ProgressReporter, every int, upsert bool) int {
+
+	if every < 1 {
+		every = defaultProgressEvery
+	}
+
+	errors := 0
+	stats := CopyResult{}
+	for i, r := range records {
+		if err := importMarket(ctx, repo, r, upsert, &stats); err != nil {
+			logger.Error(fmt.Sprintf("[Seeder] - registro %s not imported - %s", r.Registro, err.Error()))
+			errors++
+		}
+
+		processed := i + 1
+		if processed%every == 0 || processed == len(records) {
+			reporter.Report(processed, len(records), errors)
+		}
+	}
+
+	logger.Info(fmt.Sprintf("[Seeder] - %d inserted, %d updated", stats.Inserted, stats.Updated))
+
+	return errors
+}
+
+// ImportMarketsInBatches creates the records in transactions of commitEvery records. A failing record rolls
+// back only its own batch, counted as errors in full, while the batches already committed are kept.
+func ImportMarketsInBatches(ctx context.Context, logger interfaces.ILogger, repo interfaces.IMarketRepository,
+	records []valueObjects.MarketValueObjects, reporter ProgressReporter, every, commitEvery int, upsert bool) int {
+
+	if every < 1 {
+		every = defaultProgressEvery
+	}
+	if commitEvery < 1 {
+		return ImportMarkets(ctx, logger, repo, records, reporter, every, upsert)
+	}
+
+	errors := 0
+	stats := CopyResult{}
+	for start := 0; start < len(records); start += commitEvery {
+		end := start + commitEvery
+		if end > len(records) {
+			end = len(records)
+		}
+
+		batch := records[start:end]
+		batchStats := CopyResult{}
+		err := repo.WithTx(ctx, func(tx interfaces.IMarketRepository) error {
+			for _, r := range batch {
+				if err := importMarket(ctx, tx, r, upsert, &batchStats); err != nil {
+					return fmt.Errorf("registro %s - %s", r.Registro, err.Error())
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[Seeder] - records %d to %d rolled back - %s", start+1, end, err.Error()))
+			errors += len(batch)
+		} else {
+			stats.Inserted += batchStats.Inserted
+			stats.Updated += batchStats.Updated
+		}
+
+		if end/every > start/every || end == len(records) {
+			reporter.Report(end, len(records), errors)
+		}
+	}
+
+	logger.Info(fmt.Sprintf("[Seeder] - %d inserted, %d updated", stats.Inserted, stats.Updated))
+
+	return errors
+}
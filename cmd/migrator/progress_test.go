@@ -0,0 +1,239 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProgressTestSuite struct {
+	suite.Suite
+}
+
+func TestProgressTestSuite(t *testing.T) {
+	suite.Run(t, new(ProgressTestSuite))
+}
+
+type progressCall struct {
+	processed, total, errors int
+}
+
+type progressRecorder struct {
+	calls []progressCall
+}
+
+func (pst *progressRecorder) Report(processed, total, errors int) {
+	pst.calls = append(pst.calls, progressCall{processed, total, errors})
+}
+
+func (s *ProgressTestSuite) TestImportMarketsReportsIncreasingCounts() {
+	ctx := context.Background()
+	logger := logger.NewLoggerSpy()
+	repo := repositories.NewMarketRepositorySpy()
+	records := []valueObjects.MarketValueObjects{
+		{Registro: "1"}, {Registro: "2"}, {Registro: "3"}, {Registro: "4"}, {Registro: "5"},
+	}
+	repo.On("Create", ctx, records[2]).Return(valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error"))
+	repo.On("Create", ctx, mock.Anything).Return(valueObjects.MarketValueObjects{}, nil)
+	logger.On("Error", "[Seeder] - registro 3 not imported - query execution error", mock.Anything)
+	logger.On("Info", "[Seeder] - 4 inserted, 0 updated", mock.Anything)
+	reporter := &progressRecorder{}
+
+	failures := ImportMarkets(ctx, logger, repo, records, reporter, 2, false)
+
+	s.Equal(1, failures)
+	s.Equal([]progressCall{{2, 5, 0}, {4, 5, 1}, {5, 5, 1}}, reporter.calls)
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ProgressTestSuite) TestImportMarketsDefaultInterval() {
+	ctx := context.Background()
+	repo := repositories.NewMarketRepositorySpy()
+	records := make([]valueObjects.MarketValueObjects, defaultProgressEvery+1)
+	repo.On("Create", ctx, mock.Anything).Return(valueObjects.MarketValueObjects{}, nil)
+	logger := logger.NewLoggerSpy()
+	logger.On("Info", mock.Anything, mock.Anything)
+	reporter := &progressRecorder{}
+
+	failures := ImportMarkets(ctx, logger, repo, records, reporter, 0, false)
+
+	s.Equal(0, failures)
+	s.Equal([]progressCall{{defaultProgressEvery, defaultProgressEvery + 1, 0}, {defaultProgressEvery + 1, defaultProgressEvery + 1, 0}}, reporter.calls)
+}
+
+func (s *ProgressTestSuite) TestImportMarketsInBatchesCommitsEveryBatch() {
+	db, sqlMock, _ := sqlmock.New()
+	logger := logger.NewLoggerSpy()
+	logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+	records := validRecords(5)
+	for _, size := range []int{2, 2, 1} {
+		sqlMock.ExpectBegin()
+		for i := 0; i < size; i++ {
+			sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnRows(marketRow(sqlMock))
+		}
+		sqlMock.ExpectCommit()
+	}
+	logger.On("Info", "[Seeder] - 5 inserted, 0 updated", mock.Anything)
+	reporter := &progressRecorder{}
+
+	failures := ImportMarketsInBatches(context.Background(), logger, repositories.NewMarketRepository(logger, db), records, reporter, 2, 2, false)
+
+	s.Equal(0, failures)
+	s.Equal([]progressCall{{2, 5, 0}, {4, 5, 0}, {5, 5, 0}}, reporter.calls)
+	s.NoError(sqlMock.ExpectationsWereMet())
+}
+
+func (s *ProgressTestSuite) TestImportMarketsInBatchesKeepsTheCommittedBatchesOnFailure() {
+	db, sqlMock, _ := sqlmock.New()
+	logger := logger.NewLoggerSpy()
+	logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+	logger.On("Error", "[MarketRepository::Create] query execution error", mock.Anything)
+	logger.On("Error", "[Seeder] - records 4 to 6 rolled back - registro 5 - query execution error", mock.Anything)
+	records := validRecords(7)
+
+	sqlMock.ExpectBegin()
+	for i := 0; i < 3; i++ {
+		sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnRows(marketRow(sqlMock))
+	}
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnRows(marketRow(sqlMock))
+	sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnError(fmt.Errorf("connection reset"))
+	sqlMock.ExpectRollback()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnRows(marketRow(sqlMock))
+	sqlMock.ExpectCommit()
+	logger.On("Info", "[Seeder] - 4 inserted, 0 updated", mock.Anything)
+	reporter := &progressRecorder{}
+
+	failures := ImportMarketsInBatches(context.Background(), logger, repositories.NewMarketRepository(logger, db), records, reporter, 3, 3, false)
+
+	s.Equal(3, failures)
+	s.Equal([]progressCall{{3, 7, 0}, {6, 7, 3}, {7, 7, 3}}, reporter.calls)
+	s.NoError(sqlMock.ExpectationsWereMet())
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ProgressTestSuite) TestImportMarketsInBatchesCommitsTheLenientRows() {
+	db, sqlMock, _ := sqlmock.New()
+	logger := logger.NewLoggerSpy()
+	logger.On("Warn", "[Seeder] - line 4 rejected, missing Distrito", mock.Anything)
+	logger.On("Warn", "[Seeder] - 1 rows rejected by the lenient mode", mock.Anything)
+	logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+	logger.On("Info", "[Seeder] - 2 inserted, 0 updated", mock.Anything)
+	records, err := ReadMarkets(logger, strings.NewReader(csvHeader+csvLine+noRegionCsvLine+partialCsvLine), DefaultColumnMapping, ImportOptions{})
+	s.NoError(err)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WithArgs(-46550164, -23558733, "355030885000091", "3550308005040", 87,
+		"VILA FORMOSA", 26, "ARICANDUVA", "Leste", "Leste 1", "VILA FORMOSA", "4041-0", "RUA MARAGOJIPE", "S/N", "VL FORMOSA", "TV RUA PRETORIA",
+		sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(marketRow(sqlMock))
+	sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WithArgs(-46574716, -23584852, "355030893000035", "3550308005042", 95,
+		"VILA PRUDENTE", 29, "VILA PRUDENTE", "", "", "PRACA SANTA HELENA", "4046-0", "RUA JOSE DOS REIS", "909", "VL ZELINA",
+		"RUA OLIVEIRA GOUVEIA", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(marketRow(sqlMock))
+	sqlMock.ExpectCommit()
+	reporter := &progressRecorder{}
+
+	failures := ImportMarketsInBatches(context.Background(), logger, repositories.NewMarketRepository(logger, db), records, reporter, 3, 3, false)
+
+	s.Equal(0, failures)
+	s.Equal([]progressCall{{2, 2, 0}}, reporter.calls)
+	s.NoError(sqlMock.ExpectationsWereMet())
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ProgressTestSuite) TestImportMarketsInBatchesWithoutBatches() {
+	ctx := context.Background()
+	repo := repositories.NewMarketRepositorySpy()
+	records := []valueObjects.MarketValueObjects{{Registro: "1"}, {Registro: "2"}}
+	repo.On("Create", ctx, mock.Anything).Return(valueObjects.MarketValueObjects{}, nil)
+	logger := logger.NewLoggerSpy()
+	logger.On("Info", "[Seeder] - 2 inserted, 0 updated", mock.Anything)
+	reporter := &progressRecorder{}
+
+	failures := ImportMarketsInBatches(ctx, logger, repo, records, reporter, 1, 0, false)
+
+	s.Equal(0, failures)
+	s.Equal([]progressCall{{1, 2, 0}, {2, 2, 0}}, reporter.calls)
+	repo.AssertNotCalled(s.T(), "WithTx", ctx)
+}
+
+func (s *ProgressTestSuite) TestImportMarketsUpsertReportsInsertedAndUpdated() {
+	ctx := context.Background()
+	logger := logger.NewLoggerSpy()
+	repo := repositories.NewMarketRepositorySpy()
+	records := []valueObjects.MarketValueObjects{{Registro: "1"}, {Registro: "2"}, {Registro: "3"}}
+	repo.On("Upsert", ctx, records[0]).Return(valueObjects.MarketValueObjects{}, true, nil)
+	repo.On("Upsert", ctx, records[1]).Return(valueObjects.MarketValueObjects{}, false, nil)
+	repo.On("Upsert", ctx, records[2]).Return(valueObjects.MarketValueObjects{}, false, nil)
+	logger.On("Info", "[Seeder] - 1 inserted, 2 updated", mock.Anything)
+
+	failures := ImportMarkets(ctx, logger, repo, records, &progressRecorder{}, 1, true)
+
+	s.Equal(0, failures)
+	repo.AssertNotCalled(s.T(), "Create", ctx, mock.Anything)
+	logger.AssertExpectations(s.T())
+}
+
+func (s *ProgressTestSuite) TestImportMarketsInBatchesUpsert() {
+	db, sqlMock, _ := sqlmock.New()
+	logger := logger.NewLoggerSpy()
+	logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+	logger.On("Info", "[MarketRepository::Update] - success", mock.Anything)
+	logger.On("Info", "[Seeder] - 1 inserted, 1 updated", mock.Anything)
+	records := validRecords(2)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectPrepare("ON CONFLICT").ExpectQuery().WillReturnRows(upsertedRow(sqlMock, true))
+	sqlMock.ExpectPrepare("ON CONFLICT").ExpectQuery().WillReturnRows(upsertedRow(sqlMock, false))
+	sqlMock.ExpectCommit()
+
+	failures := ImportMarketsInBatches(context.Background(), logger, repositories.NewMarketRepository(logger, db), records, &progressRecorder{}, 2,
+		2, true)
+
+	s.Equal(0, failures)
+	s.NoError(sqlMock.ExpectationsWereMet())
+	logger.AssertExpectations(s.T())
+}
+
+func validRecords(amount int) []valueObjects.MarketValueObjects {
+	records := make([]valueObjects.MarketValueObjects, 0, amount)
+	for i := 1; i <= amount; i++ {
+		records = append(records, valueObjects.MarketValueObjects{Distrito: "VILA FORMOSA", NomeFeira: "VILA FORMOSA", Registro: fmt.Sprint(i)})
+	}
+
+	return records
+}
+
+func upsertedRow(sqlMock sqlmock.Sqlmock, inserted bool) *sqlmock.Rows {
+	return sqlMock.NewRows([]string{"id", "long", "lat", "setcens", "areap", "coddist", "distrito", "codsubpref", "subpref", "regiao5", "regiao8",
+		"nome_feira", "registro", "logradouro", "numero", "bairro", "referencia", "criado_em", "atualizado_em", "deletado_em", "inserted"}).
+		AddRow(1, 0, 0, "", "", 0, "", 0, "", "", "", "", "1", "", "", "", "", time.Now(), time.Now(), nil, inserted)
+}
+
+func marketRow(sqlMock sqlmock.Sqlmock) *sqlmock.Rows {
+	return sqlMock.NewRows([]string{"id", "long", "lat", "setcens", "areap", "coddist", "distrito", "codsubpref", "subpref", "regiao5", "regiao8",
+		"nome_feira", "registro", "logradouro", "numero", "bairro", "referencia", "criado_em", "atualizado_em", "deletado_em"}).
+		AddRow(1, 0, 0, "", "", 0, "", 0, "", "", "", "", "1", "", "", "", "", time.Now(), time.Now(), nil)
+}
+
+func (s *ProgressTestSuite) TestWriterProgressReporter() {
+	buffer := &bytes.Buffer{}
+	reporter := writerProgressReporter{buffer}
+
+	reporter.Report(1, 2, 0)
+	reporter.Report(2, 2, 1)
+
+	s.Equal("\r[Seeder] - 1/2 processed, 0 errors\r[Seeder] - 2/2 processed, 1 errors\n", buffer.String())
+}
@@ -9,6 +9,7 @@ import (
 func main() {
 	cmd.Execute(
 		migrator.NewMigratorCmd(),
+		migrator.NewMigrateCmd(),
 		api.NewHTTPServerCmd(),
 	)
 }
@@ -0,0 +1 @@
+DROP INDEX feiras_registro_key;
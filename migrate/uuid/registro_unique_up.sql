@@ -0,0 +1 @@
+CREATE UNIQUE INDEX feiras_registro_key ON feiras (registro) WHERE deletado_em IS NULL;
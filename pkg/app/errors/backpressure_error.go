@@ -0,0 +1,23 @@
+package errors
+
+import "time"
+
+// BackpressureError tells the caller to retry after RetryAfter. Unavailable marks the whole service as
+// saturated, otherwise only the caller's request was refused, e.g. because a queue is full.
+type BackpressureError struct {
+	Message     string
+	RetryAfter  time.Duration
+	Unavailable bool
+}
+
+func (pst BackpressureError) Error() string {
+	return pst.Message
+}
+
+func NewBackpressureError(message string, retryAfter time.Duration) BackpressureError {
+	return BackpressureError{Message: message, RetryAfter: retryAfter}
+}
+
+func NewUnavailableError(message string, retryAfter time.Duration) BackpressureError {
+	return BackpressureError{Message: message, RetryAfter: retryAfter, Unavailable: true}
+}
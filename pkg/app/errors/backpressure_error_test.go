@@ -0,0 +1,37 @@
+package errors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BackpressureErrTestSuite struct {
+	suite.Suite
+}
+
+func TestBackpressureErrTestSuite(t *testing.T) {
+	suite.Run(t, new(BackpressureErrTestSuite))
+}
+
+func (s *BackpressureErrTestSuite) TestNewBackpressureError() {
+	err := NewBackpressureError("some error", time.Second)
+
+	s.Error(err)
+	s.IsType(BackpressureError{}, err)
+	s.Equal(time.Second, err.RetryAfter)
+	s.False(err.Unavailable)
+}
+
+func (s *BackpressureErrTestSuite) TestNewUnavailableError() {
+	err := NewUnavailableError("some error", time.Minute)
+
+	s.Equal(time.Minute, err.RetryAfter)
+	s.True(err.Unavailable)
+}
+
+func (s *BackpressureErrTestSuite) TestNewBackpressureErrorError() {
+	err := NewBackpressureError("some error", time.Second)
+	s.Equal("some error", err.Error())
+}
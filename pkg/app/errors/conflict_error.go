@@ -1,5 +1,7 @@
 package errors
 
+var ErrMarketAlreadyExists = NewConflictError("market already exists")
+
 type ConflictError struct {
 	Message string
 }
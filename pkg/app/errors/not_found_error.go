@@ -1,5 +1,7 @@
 package errors
 
+var ErrMarketNotFound = NewNotFoundError("market not found")
+
 type NotFoundError struct {
 	Message string
 }
@@ -21,6 +21,11 @@ func (s *NotFoundErrorTestSuite) TestCONFLICTNotFoundError() {
 	s.IsType(NotFoundError{}, err)
 }
 
+func (s *NotFoundErrorTestSuite) TestErrMarketNotFound() {
+	s.IsType(NotFoundError{}, ErrMarketNotFound)
+	s.Equal("market not found", ErrMarketNotFound.Error())
+}
+
 func (s *NotFoundErrorTestSuite) TestNotCONFLICTFoundErrorError() {
 	//CONFLICT
 	err := NewNotFoundError("some error")
@@ -0,0 +1,15 @@
+package errors
+
+var ErrReadOnly = NewReadOnlyError("the service is running in read-only mode")
+
+type ReadOnlyError struct {
+	Message string
+}
+
+func (pst ReadOnlyError) Error() string {
+	return pst.Message
+}
+
+func NewReadOnlyError(message string) ReadOnlyError {
+	return ReadOnlyError{Message: message}
+}
@@ -0,0 +1,28 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReadOnlyErrTestSuite struct {
+	suite.Suite
+}
+
+func TestReadOnlyErrTestSuite(t *testing.T) {
+	suite.Run(t, new(ReadOnlyErrTestSuite))
+}
+
+func (s *ReadOnlyErrTestSuite) TestNewReadOnlyError() {
+	err := NewReadOnlyError("some error")
+
+	s.Error(err)
+	s.IsType(ReadOnlyError{}, err)
+
+}
+
+func (s *ReadOnlyErrTestSuite) TestNewReadOnlyErrorError() {
+	err := NewReadOnlyError("some error")
+	s.Equal("some error", err.Error())
+}
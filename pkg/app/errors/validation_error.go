@@ -0,0 +1,13 @@
+package errors
+
+type ValidationError struct {
+	Message string
+}
+
+func (pst ValidationError) Error() string {
+	return pst.Message
+}
+
+func NewValidationError(message string) ValidationError {
+	return ValidationError{Message: message}
+}
@@ -0,0 +1,28 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidationErrTestSuite struct {
+	suite.Suite
+}
+
+func TestValidationErrTestSuite(t *testing.T) {
+	suite.Run(t, new(ValidationErrTestSuite))
+}
+
+func (s *ValidationErrTestSuite) TestNewValidationError() {
+	err := NewValidationError("some error")
+
+	s.Error(err)
+	s.IsType(ValidationError{}, err)
+
+}
+
+func (s *ValidationErrTestSuite) TestNewValidationErrorError() {
+	err := NewValidationError("some error")
+	s.Equal("some error", err.Error())
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+type ICache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Clear(ctx context.Context)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+// ReloadResult lists the values a reload applied and the changed ones it ignored, which need a restart.
+type ReloadResult struct {
+	Applied map[string]string
+	Ignored []string
+}
+
+type IConfigReloader interface {
+	Reload() (ReloadResult, error)
+}
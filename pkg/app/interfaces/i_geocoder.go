@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+// IGeocoder resolves an address to a point in the micro-degrees the markets are stored in. An address
+// without match is reported as an errors.NotFoundError.
+type IGeocoder interface {
+	Geocode(ctx context.Context, address string) (valueObjects.Coordinate, error)
+}
@@ -1,6 +1,10 @@
 package interfaces
 
-import "go.uber.org/zap"
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
 
 type LogField struct {
 	Key   string
@@ -12,4 +16,8 @@ type ILogger interface {
 	Info(msg string, fields ...zap.Field)
 	Warn(msg string, fields ...zap.Field)
 	Error(msg string, fields ...zap.Field)
+	// WithContext returns a logger adding the correlation ID of the request in ctx to every line.
+	WithContext(ctx context.Context) ILogger
+	// Sync flushes the buffered lines, called once on shutdown.
+	Sync() error
 }
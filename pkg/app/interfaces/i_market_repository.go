@@ -2,13 +2,47 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
 )
 
 type IMarketRepository interface {
 	Create(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error)
+	Upsert(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, bool, error)
+	CreateMany(ctx context.Context, markets []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error)
 	Find(ctx context.Context, market valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error)
+	FindByID(ctx context.Context, id int) (valueObjects.MarketValueObjects, error)
+	FindByUUID(ctx context.Context, uuid string) (valueObjects.MarketValueObjects, error)
+	FindByRegistro(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error)
+	FindPage(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error)
+	Count(ctx context.Context, market valueObjects.MarketValueObjects) (int64, error)
+	FindForPublic(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error)
+	FindSummaries(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketSummary, error)
 	Delete(ctx context.Context, registerCode string) error
+	Restore(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error)
 	Update(ctx context.Context, registerCode string, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error)
+	UpdateByID(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error)
+	DedupeByProximity(ctx context.Context, thresholdMeters float64) (int64, error)
+	Touch(ctx context.Context, id int) error
+	FindAfterID(ctx context.Context, lastID, limit int) ([]valueObjects.MarketValueObjects, error)
+	FindAfterUUID(ctx context.Context, lastUUID string, limit int) ([]valueObjects.MarketValueObjects, error)
+	FindMissingRegistro(ctx context.Context, limit int) ([]valueObjects.MarketValueObjects, error)
+	FindByDistritoPaged(ctx context.Context, distrito string, page, pageSize int) ([]valueObjects.MarketValueObjects, error)
+	FindByCoordinates(ctx context.Context, long, lat float64) ([]valueObjects.MarketValueObjects, error)
+	FindWithNeighbors(ctx context.Context, id int, radiusMeters float64, limit int) (valueObjects.MarketNeighborsValueObjects, error)
+	FindNearby(ctx context.Context, lat, long float64, radiusKm float64, limit int) ([]valueObjects.MarketDistance, error)
+	FindSiblingsBySubpref(ctx context.Context, id int, limit int) ([]valueObjects.MarketValueObjects, error)
+	FindNearestToPoints(ctx context.Context, points []valueObjects.Coordinate, limit int) ([]valueObjects.MarketValueObjects, error)
+	FindCoordinateCollisions(ctx context.Context) ([]valueObjects.CoordinateGroup, error)
+	CountByDistrito(ctx context.Context, distrito string) (int64, error)
+	FindByBairro(ctx context.Context, bairro string, fuzzy bool) ([]valueObjects.MarketValueObjects, error)
+	FindByRegiao8(ctx context.Context, regiao8 string) ([]valueObjects.MarketValueObjects, error)
+	FindByRegiao8Paged(ctx context.Context, regiao8 string, page, pageSize int) (valueObjects.MarketPage, error)
+	CountByRegiao8(ctx context.Context) (map[string]int64, error)
+	CountByRegiao5InDistrito(ctx context.Context, distrito string) (map[string]int64, error)
+	CountDistinctRegistros(ctx context.Context) (int64, error)
+	FindCreatedBetween(ctx context.Context, from, to time.Time, limit, offset int) ([]valueObjects.MarketValueObjects, error)
+	CountCreatedByPeriod(ctx context.Context, granularity string) ([]valueObjects.MarketTimelineValueObjects, error)
+	WithTx(ctx context.Context, fn func(repo IMarketRepository) error) error
 }
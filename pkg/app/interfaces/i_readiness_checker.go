@@ -0,0 +1,7 @@
+package interfaces
+
+import "context"
+
+type IReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
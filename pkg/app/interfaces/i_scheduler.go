@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+type IScheduler interface {
+	Register(name string, interval time.Duration, job func(ctx context.Context)) error
+	Start()
+	Stop()
+}
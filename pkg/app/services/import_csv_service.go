@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+// RowError is a CSV row left out of the import. Line is the line of the file where the row starts.
+type RowError struct {
+	Line    int
+	Message string
+}
+
+type ImportReport struct {
+	Imported int
+	Skipped  int
+	Errors   []RowError
+}
+
+type IImportService interface {
+	ImportCSV(ctx context.Context, r io.Reader) (ImportReport, error)
+}
+
+type importService struct {
+	logger  interfaces.ILogger
+	repo    interfaces.IMarketRepository
+	workers int
+}
+
+// importRow is a row on its way to the workers and back with the result of Create. Invalid rows, failing
+// to parse or validate, skip the workers.
+type importRow struct {
+	line    int
+	market  valueObjects.MarketValueObjects
+	err     error
+	invalid bool
+}
+
+var csvHeaders = []string{
+	"long", "lat", "setcens", "areap", "coddist", "distrito", "codsubpref", "subpref", "regiao5", "regiao8",
+	"nome_feira", "registro", "logradouro", "numero", "bairro", "referencia",
+}
+
+// CSVHeaders returns the columns ImportCSV expects, in the order of the import template.
+func CSVHeaders() []string {
+	return append([]string{}, csvHeaders...)
+}
+
+// csvHeaderAliases are the other names a column has in the published dataset.
+var csvHeaderAliases = map[string]string{"subprefe": "subpref"}
+
+// ImportCSV reads the dataset one row at a time, so the file is never held in memory, and hands the rows to
+// IMPORT_WORKERS goroutines creating the markets. Rows that can not be parsed, fail validation or are rejected
+// by the repository are reported, sorted by line, and skipped; any other repository error cancels the
+// remaining rows and is returned along with the report so far. A read error other than a malformed row stops
+// the reading, since the csv.Reader returns it again on every Read, and is returned once the rows already
+// read are imported.
+func (pst importService) ImportCSV(ctx context.Context, r io.Reader) (ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return ImportReport{Errors: []RowError{}}, nil
+	}
+	if err != nil {
+		return ImportReport{Errors: []RowError{}}, fmt.Errorf("csv header unreadable - %s", err.Error())
+	}
+
+	indexes, err := headerIndexes(header)
+	if err != nil {
+		return ImportReport{Errors: []RowError{}}, err
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan importRow, pst.workers*2)
+	results := make(chan importRow, pst.workers*2)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < pst.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pst.work(workCtx, jobs, results)
+		}()
+	}
+
+	collected := make(chan ImportReport)
+	var fatal error
+	go func() {
+		report, err := pst.collect(results, cancel)
+		fatal = err
+		collected <- report
+	}()
+
+	readErr := feed(workCtx, reader, indexes, jobs, results)
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	report := <-collected
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	if fatal != nil {
+		return report, fatal
+	}
+	if readErr != nil {
+		pst.logger.Error(fmt.Sprintf("[ImportService::ImportCSV] - import stopped, %s", readErr.Error()))
+		return report, readErr
+	}
+
+	pst.logger.Info(fmt.Sprintf("[ImportService::ImportCSV] - %d imported, %d skipped", report.Imported, report.Skipped))
+
+	return report, nil
+}
+
+// feed parses the rows into jobs until the file ends, ctx is canceled or the reader fails. The rows failing
+// to parse or validate go straight to results.
+func feed(ctx context.Context, reader *csv.Reader, indexes map[string]int, jobs, results chan<- importRow) error {
+	for ctx.Err() == nil {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			results <- importRow{line: parseErr.StartLine, err: err, invalid: true}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("csv unreadable - %s", err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		market, err := toMarket(row, indexes)
+		if err == nil {
+			err = market.Validate()
+		}
+		if err != nil {
+			results <- importRow{line: line, err: err, invalid: true}
+			continue
+		}
+
+		select {
+		case jobs <- importRow{line: line, market: market}:
+		case <-ctx.Done():
+		}
+	}
+
+	return nil
+}
+
+func (pst importService) work(ctx context.Context, jobs <-chan importRow, results chan<- importRow) {
+	for job := range jobs {
+		if ctx.Err() != nil {
+			continue
+		}
+
+		_, job.err = pst.repo.Create(ctx, job.market)
+		results <- job
+	}
+}
+
+func (pst importService) collect(results <-chan importRow, cancel context.CancelFunc) (ImportReport, error) {
+	report := ImportReport{Errors: []RowError{}}
+	var fatal error
+	for result := range results {
+		switch {
+		case result.err == nil:
+			report.Imported++
+		case result.invalid || isRowError(result.err):
+			report.skip(result.line, result.err.Error())
+		case fatal == nil:
+			pst.logger.Error(fmt.Sprintf("[ImportService::ImportCSV] - import stopped at line %d: %s", result.line, result.err.Error()))
+			fatal = result.err
+			cancel()
+		}
+	}
+
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+
+	return report, fatal
+}
+
+func (pst *ImportReport) skip(line int, message string) {
+	pst.Skipped++
+	pst.Errors = append(pst.Errors, RowError{Line: line, Message: message})
+}
+
+func isRowError(err error) bool {
+	switch err.(type) {
+	case errors.ConflictError, errors.ValidationError, valueObjects.MarketValidationError:
+		return true
+	default:
+		return false
+	}
+}
+
+// headerIndexes maps each expected column to its position in header, in any order and case, failing with
+// every missing column before a data row is read.
+func headerIndexes(header []string) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if alias, ok := csvHeaderAliases[h]; ok {
+			h = alias
+		}
+		positions[h] = i
+	}
+
+	indexes := make(map[string]int, len(csvHeaders))
+	missing := []string{}
+	for _, h := range csvHeaders {
+		position, ok := positions[h]
+		if !ok {
+			missing = append(missing, h)
+			continue
+		}
+		indexes[h] = position
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("csv header missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	return indexes, nil
+}
+
+func toMarket(row []string, indexes map[string]int) (valueObjects.MarketValueObjects, error) {
+	field := func(h string) string {
+		if indexes[h] >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[indexes[h]])
+	}
+
+	long, err := parseCoordinate(field("long"))
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, fmt.Errorf("long is invalid: %s", field("long"))
+	}
+	lat, err := parseCoordinate(field("lat"))
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, fmt.Errorf("lat is invalid: %s", field("lat"))
+	}
+	coddist, err := parseInt(field("coddist"))
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, fmt.Errorf("coddist is invalid: %s", field("coddist"))
+	}
+	codsubpref, err := parseInt(field("codsubpref"))
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, fmt.Errorf("codsubpref is invalid: %s", field("codsubpref"))
+	}
+
+	return valueObjects.MarketValueObjects{
+		Long:       long,
+		Lat:        lat,
+		Setcens:    field("setcens"),
+		Areap:      field("areap"),
+		Coddist:    coddist,
+		Distrito:   field("distrito"),
+		Codsubpref: codsubpref,
+		Subpref:    field("subpref"),
+		Regiao5:    field("regiao5"),
+		Regiao8:    field("regiao8"),
+		NomeFeira:  field("nome_feira"),
+		Registro:   field("registro"),
+		Logradouro: field("logradouro"),
+		Numero:     field("numero"),
+		Bairro:     field("bairro"),
+		Referencia: field("referencia"),
+	}, nil
+}
+
+func parseInt(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(raw)
+}
+
+// parseCoordinate accepts the integer micro-degrees of the dataset (-46550164) or decimal degrees (-46.550164).
+func parseCoordinate(raw string) (int, error) {
+	if !strings.Contains(raw, ".") {
+		return parseInt(raw)
+	}
+
+	degrees, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(math.Round(degrees * 1e6)), nil
+}
+
+func NewImportService(logger interfaces.ILogger, repo interfaces.IMarketRepository) IImportService {
+	return importService{logger, repo, workersFromEnv(logger)}
+}
+
+// workersFromEnv reads IMPORT_WORKERS, the goroutines creating markets in parallel, GOMAXPROCS by default.
+func workersFromEnv(logger interfaces.ILogger) int {
+	raw := os.Getenv("IMPORT_WORKERS")
+	if raw == "" {
+		return runtime.GOMAXPROCS(0)
+	}
+
+	workers, err := strconv.Atoi(raw)
+	if err != nil || workers < 1 {
+		logger.Warn(fmt.Sprintf("[ImportService] - invalid IMPORT_WORKERS: %s", raw))
+		return runtime.GOMAXPROCS(0)
+	}
+
+	return workers
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const csvHeader = "ID,LONG,LAT,SETCENS,AREAP,CODDIST,DISTRITO,CODSUBPREF,SUBPREFE,REGIAO5,REGIAO8,NOME_FEIRA,REGISTRO,LOGRADOURO,NUMERO,BAIRRO,REFERENCIA\n"
+
+const vilaFormosa = "1,-46550164,-23558733,355030885000091,3550308005040,87,VILA FORMOSA,26,ARICANDUVA-FORMOSA-CARRAO,Leste,Leste 1,VILA FORMOSA,4041-0,RUA MARAGOJIPE,S/N,VL FORMOSA,TV RUA PRETORIA\n"
+
+var vilaFormosaMarket = valueObjects.MarketValueObjects{
+	Long: -46550164, Lat: -23558733, Setcens: "355030885000091", Areap: "3550308005040", Coddist: 87, Distrito: "VILA FORMOSA",
+	Codsubpref: 26, Subpref: "ARICANDUVA-FORMOSA-CARRAO", Regiao5: "Leste", Regiao8: "Leste 1", NomeFeira: "VILA FORMOSA",
+	Registro: "4041-0", Logradouro: "RUA MARAGOJIPE", Numero: "S/N", Bairro: "VL FORMOSA", Referencia: "TV RUA PRETORIA",
+}
+
+func Test_ImportCSV(t *testing.T) {
+	t.Run("should import every valid row", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx := context.Background()
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(vilaFormosaMarket, nil)
+		sut.logger.On("Info", "[ImportService::ImportCSV] - 1 imported, 0 skipped", mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(csvHeader+vilaFormosa))
+
+		assert.NoError(t, err)
+		assert.Equal(t, ImportReport{Imported: 1, Errors: []RowError{}}, report)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should read coordinates in decimal degrees", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx := context.Background()
+		row := strings.Replace(vilaFormosa, "-46550164,-23558733", "-46.550164,-23.558733", 1)
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(vilaFormosaMarket, nil)
+		sut.logger.On("Info", mock.Anything, mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(csvHeader+row))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+	})
+
+	t.Run("should skip the bad rows reporting their line", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx := context.Background()
+		noName := strings.Replace(vilaFormosa, ",VILA FORMOSA,4041-0", ",,4041-0", 1)
+		badLong := strings.Replace(vilaFormosa, "-46550164", "wrong", 1)
+		badQuote := "2,\"-46550164,x\"y\n"
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(vilaFormosaMarket, nil)
+		sut.logger.On("Info", "[ImportService::ImportCSV] - 1 imported, 3 skipped", mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(csvHeader+noName+badLong+badQuote+vilaFormosa))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		assert.Equal(t, 3, report.Skipped)
+		assert.Equal(t, RowError{Line: 2, Message: "NomeFeira is required"}, report.Errors[0])
+		assert.Equal(t, RowError{Line: 3, Message: "long is invalid: wrong"}, report.Errors[1])
+		assert.Equal(t, 4, report.Errors[2].Line)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should skip the rows the repository rejects", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx := context.Background()
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(valueObjects.MarketValueObjects{}, errors.ErrMarketAlreadyExists)
+		sut.logger.On("Info", "[ImportService::ImportCSV] - 0 imported, 1 skipped", mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(csvHeader+vilaFormosa))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []RowError{{Line: 2, Message: "market already exists"}}, report.Errors)
+	})
+
+	t.Run("should stop on any other repository error", func(t *testing.T) {
+		sut := makeImportServiceSut(1)
+
+		ctx := context.Background()
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(valueObjects.MarketValueObjects{}, fmt.Errorf("connection refused"))
+		sut.logger.On("Error", "[ImportService::ImportCSV] - import stopped at line 2: connection refused", mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(csvHeader+vilaFormosa+vilaFormosa))
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, report.Imported)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should stop and return the error when the stream breaks", func(t *testing.T) {
+		sut := makeImportServiceSut(1)
+
+		ctx := context.Background()
+		stream := io.MultiReader(strings.NewReader(csvHeader+vilaFormosa), iotest.ErrReader(fmt.Errorf("connection reset")))
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(vilaFormosaMarket, nil)
+		sut.logger.On("Error", "[ImportService::ImportCSV] - import stopped, csv unreadable - connection reset", mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, stream)
+
+		assert.EqualError(t, err, "csv unreadable - connection reset")
+		assert.Equal(t, 1, report.Imported)
+		assert.Empty(t, report.Errors)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should map the columns by header in any order and case", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx := context.Background()
+		header := "referencia,bairro,numero,logradouro,registro,nome_feira,regiao8,regiao5,subpref,codsubpref,distrito,coddist,areap,setcens,lat,Long\n"
+		row := "TV RUA PRETORIA,VL FORMOSA,S/N,RUA MARAGOJIPE,4041-0,VILA FORMOSA,Leste 1,Leste,ARICANDUVA-FORMOSA-CARRAO,26,VILA FORMOSA,87," +
+			"3550308005040,355030885000091,-23558733,-46550164\n"
+		sut.repo.On("Create", mock.Anything, vilaFormosaMarket).Return(vilaFormosaMarket, nil)
+		sut.logger.On("Info", mock.Anything, mock.Anything)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(header+row))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should fail naming every missing column before reading the rows", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		header := strings.Replace(strings.Replace(csvHeader, ",SETCENS", "", 1), ",SUBPREFE", "", 1)
+
+		report, err := sut.service.ImportCSV(context.Background(), strings.NewReader(header+vilaFormosa))
+
+		assert.EqualError(t, err, "csv header missing columns: setcens, subpref")
+		assert.Equal(t, 0, report.Imported+report.Skipped)
+	})
+
+	t.Run("should stop when the context is canceled", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := sut.service.ImportCSV(ctx, strings.NewReader(csvHeader+vilaFormosa))
+
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
+func Test_ImportCSV_Workers(t *testing.T) {
+	t.Run("should import every row of a large file", func(t *testing.T) {
+		sut := makeImportServiceSut(8)
+
+		rows := strings.Builder{}
+		rows.WriteString(csvHeader)
+		for i := 0; i < 3000; i++ {
+			rows.WriteString(strings.Replace(vilaFormosa, "4041-0", fmt.Sprintf("%d-0", i), 1))
+		}
+		sut.repo.On("Create", mock.Anything, mock.Anything).Return(vilaFormosaMarket, nil)
+		sut.logger.On("Info", "[ImportService::ImportCSV] - 3000 imported, 0 skipped", mock.Anything)
+
+		report, err := sut.service.ImportCSV(context.Background(), strings.NewReader(rows.String()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3000, report.Imported)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should report the errors sorted by line", func(t *testing.T) {
+		sut := makeImportServiceSut(8)
+
+		rows := strings.Builder{}
+		rows.WriteString(csvHeader)
+		for i := 0; i < 200; i++ {
+			rows.WriteString(strings.Replace(vilaFormosa, "4041-0", fmt.Sprintf("%d-0", i), 1))
+		}
+		sut.repo.On("Create", mock.Anything, mock.Anything).Return(valueObjects.MarketValueObjects{}, errors.ErrMarketAlreadyExists)
+		sut.logger.On("Info", mock.Anything, mock.Anything)
+
+		report, err := sut.service.ImportCSV(context.Background(), strings.NewReader(rows.String()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 200, report.Skipped)
+		for i, rowErr := range report.Errors {
+			assert.Equal(t, i+2, rowErr.Line)
+		}
+	})
+
+	t.Run("should stop every worker when the context is canceled", func(t *testing.T) {
+		sut := makeImportServiceSut(4)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rows := csvHeader + strings.Repeat(vilaFormosa, 1000)
+		sut.repo.On("Create", mock.Anything, mock.Anything).Run(func(mock.Arguments) { cancel() }).Return(vilaFormosaMarket, nil)
+
+		report, err := sut.service.ImportCSV(ctx, strings.NewReader(rows))
+
+		assert.Equal(t, context.Canceled, err)
+		assert.Less(t, report.Imported, 1000)
+	})
+
+	t.Run("should read the workers from env", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("IMPORT_WORKERS", "3")
+		defer os.Unsetenv("IMPORT_WORKERS")
+
+		assert.Equal(t, 3, workersFromEnv(log))
+	})
+
+	t.Run("should fall back to GOMAXPROCS when the workers are invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("IMPORT_WORKERS", "0")
+		defer os.Unsetenv("IMPORT_WORKERS")
+		log.On("Warn", "[ImportService] - invalid IMPORT_WORKERS: 0", mock.Anything)
+
+		assert.Equal(t, runtime.GOMAXPROCS(0), workersFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
+
+type importServiceSutRtn struct {
+	logger  *logger.LoggerSpy
+	repo    *repositories.MarketRepositorySpy
+	service IImportService
+}
+
+func makeImportServiceSut(workers int) importServiceSutRtn {
+	logger := logger.NewLoggerSpy()
+	repo := repositories.NewMarketRepositorySpy()
+
+	service := importService{logger, repo, workers}
+
+	return importServiceSutRtn{logger, repo, service}
+}
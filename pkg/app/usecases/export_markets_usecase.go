@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+const (
+	defaultExportMaxRows = 10000
+	exportPageSize       = 500
+)
+
+type exportMarketsUseCase struct {
+	repo    interfaces.IMarketRepository
+	maxRows int
+}
+
+// Execute pages through the active markets by id and stops once EXPORT_MAX_ROWS is exceeded,
+// reading a single extra row to tell a dataset of exactly the cap apart from a truncated one. With
+// DB_ID_TYPE=uuid the pages after the first one follow the last uuid instead.
+func (pst exportMarketsUseCase) Execute(ctx context.Context) ([]valueObjects.MarketValueObjects, bool, error) {
+	markets := []valueObjects.MarketValueObjects{}
+	lastID, lastUUID := 0, ""
+	for {
+		limit := exportPageSize
+		if remaining := pst.maxRows + 1 - len(markets); remaining < limit {
+			limit = remaining
+		}
+
+		var page []valueObjects.MarketValueObjects
+		var err error
+		if lastUUID != "" {
+			page, err = pst.repo.FindAfterUUID(ctx, lastUUID, limit)
+		} else {
+			page, err = pst.repo.FindAfterID(ctx, lastID, limit)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		markets = append(markets, page...)
+		if len(page) < limit || len(markets) > pst.maxRows {
+			break
+		}
+		lastID, lastUUID = page[len(page)-1].ID, page[len(page)-1].UUID
+	}
+
+	if len(markets) > pst.maxRows {
+		return markets[:pst.maxRows], true, nil
+	}
+
+	return markets, false, nil
+}
+
+func NewExportMarketsUseCase(repo interfaces.IMarketRepository) usecases.IExportMarketsUseCase {
+	maxRows, err := strconv.Atoi(os.Getenv("EXPORT_MAX_ROWS"))
+	if err != nil || maxRows < 1 {
+		maxRows = defaultExportMaxRows
+	}
+
+	return exportMarketsUseCase{repo, maxRows}
+}
@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExportMarkets_Execute(t *testing.T) {
+	t.Run("should export every market when the cap is not reached", func(t *testing.T) {
+		sut := makeExportMarketsSut("3")
+
+		ctx := context.Background()
+		sut.repo.On("FindAfterID", ctx, 0, 4).Return(exportMarkets(1, 2), nil)
+
+		result, truncated, err := sut.useCase.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Equal(t, exportMarkets(1, 2), result)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should not truncate when the dataset has exactly the cap", func(t *testing.T) {
+		sut := makeExportMarketsSut("3")
+
+		ctx := context.Background()
+		sut.repo.On("FindAfterID", ctx, 0, 4).Return(exportMarkets(1, 2, 3), nil)
+
+		result, truncated, err := sut.useCase.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Len(t, result, 3)
+	})
+
+	t.Run("should truncate at the cap", func(t *testing.T) {
+		sut := makeExportMarketsSut("3")
+
+		ctx := context.Background()
+		sut.repo.On("FindAfterID", ctx, 0, 4).Return(exportMarkets(1, 2, 3, 4), nil)
+
+		result, truncated, err := sut.useCase.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Equal(t, exportMarkets(1, 2, 3), result)
+	})
+
+	t.Run("should page after the last id until the cap", func(t *testing.T) {
+		sut := makeExportMarketsSut("600")
+
+		ctx := context.Background()
+		first := make([]int, exportPageSize)
+		for i := range first {
+			first[i] = i + 1
+		}
+		sut.repo.On("FindAfterID", ctx, 0, exportPageSize).Return(exportMarkets(first...), nil)
+		sut.repo.On("FindAfterID", ctx, exportPageSize, 101).Return(exportMarkets(501, 502), nil)
+
+		result, truncated, err := sut.useCase.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Len(t, result, 502)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should page after the last uuid with the uuid id type", func(t *testing.T) {
+		sut := makeExportMarketsSut("600")
+
+		ctx := context.Background()
+		first := make([]valueObjects.MarketValueObjects, exportPageSize)
+		for i := range first {
+			first[i] = valueObjects.MarketValueObjects{UUID: fmt.Sprintf("%08x-0000-4000-8000-000000000000", i)}
+		}
+		last := first[exportPageSize-1].UUID
+		sut.repo.On("FindAfterID", ctx, 0, exportPageSize).Return(first, nil)
+		sut.repo.On("FindAfterUUID", ctx, last, 101).Return([]valueObjects.MarketValueObjects{{UUID: "ffffffff-0000-4000-8000-000000000000"}}, nil)
+
+		result, truncated, err := sut.useCase.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Len(t, result, 501)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should fall back to the default cap when EXPORT_MAX_ROWS is invalid", func(t *testing.T) {
+		sut := makeExportMarketsSut("wrong")
+
+		ctx := context.Background()
+		sut.repo.On("FindAfterID", ctx, 0, exportPageSize).Return(exportMarkets(1), nil)
+
+		_, truncated, err := sut.useCase.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, truncated)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should return err if repository failure", func(t *testing.T) {
+		sut := makeExportMarketsSut("3")
+
+		ctx := context.Background()
+		sut.repo.On("FindAfterID", ctx, 0, 4).Return([]valueObjects.MarketValueObjects(nil), errors.New("Error"))
+
+		_, _, err := sut.useCase.Execute(ctx)
+
+		assert.Error(t, err)
+	})
+}
+
+func exportMarkets(ids ...int) []valueObjects.MarketValueObjects {
+	markets := make([]valueObjects.MarketValueObjects, 0, len(ids))
+	for _, id := range ids {
+		markets = append(markets, valueObjects.MarketValueObjects{ID: id})
+	}
+
+	return markets
+}
+
+type exportMarketsSutRtn struct {
+	repo    *repositories.MarketRepositorySpy
+	useCase usecases.IExportMarketsUseCase
+}
+
+func makeExportMarketsSut(maxRows string) exportMarketsSutRtn {
+	os.Setenv("EXPORT_MAX_ROWS", maxRows)
+	defer os.Unsetenv("EXPORT_MAX_ROWS")
+
+	repo := repositories.NewMarketRepositorySpy()
+
+	useCase := NewExportMarketsUseCase(repo)
+
+	return exportMarketsSutRtn{repo, useCase}
+}
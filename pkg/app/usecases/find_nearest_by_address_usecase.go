@@ -0,0 +1,47 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type findNearestByAddressUseCase struct {
+	geocoder interfaces.IGeocoder
+	repo     interfaces.IMarketRepository
+}
+
+func (pst findNearestByAddressUseCase) FindNearestByAddress(ctx context.Context, address string) (valueObjects.MarketValueObjects, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return valueObjects.MarketValueObjects{}, errors.NewValidationError("address is required")
+	}
+
+	point, err := pst.geocoder.Geocode(ctx, address)
+	if _, notFound := err.(errors.NotFoundError); notFound {
+		return valueObjects.MarketValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Address: %s could not be geocoded", address))
+	}
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError(fmt.Sprintf("geocoding failure: %s", err.Error()))
+	}
+
+	result, err := pst.repo.FindNearestToPoints(ctx, []valueObjects.Coordinate{point}, 1)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	if len(result) == 0 {
+		return valueObjects.MarketValueObjects{}, errors.ErrMarketNotFound
+	}
+
+	return result[0], nil
+}
+
+func NewFindNearestByAddressUseCase(geocoder interfaces.IGeocoder, repo interfaces.IMarketRepository) usecases.IFindNearestByAddressUseCase {
+	return findNearestByAddressUseCase{geocoder, repo}
+}
@@ -0,0 +1,111 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/geocoder"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FindNearestByAddress(t *testing.T) {
+	t.Run("should return the market nearest to the geocoded address", func(t *testing.T) {
+		sut := makeFindNearestByAddressSut()
+
+		ctx := context.Background()
+		point := valueObjects.Coordinate{Long: -46550000, Lat: -23558000}
+
+		sut.geocoder.On("Geocode", ctx, "Rua Pedro Vicente, 625").Return(point, nil)
+		sut.repo.On("FindNearestToPoints", ctx, []valueObjects.Coordinate{point}, 1).
+			Return([]valueObjects.MarketValueObjects{{ID: 1, Registro: "4041-0", Long: -46550164, Lat: -23558733}}, nil)
+
+		result, err := sut.useCase.FindNearestByAddress(ctx, " Rua Pedro Vicente, 625 ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "4041-0", result.Registro)
+		sut.geocoder.AssertExpectations(t)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should return validationError when the address is blank", func(t *testing.T) {
+		sut := makeFindNearestByAddressSut()
+
+		_, err := sut.useCase.FindNearestByAddress(context.Background(), "  ")
+
+		assert.Equal(t, errors.NewValidationError("address is required"), err)
+		sut.geocoder.AssertNotCalled(t, "Geocode")
+	})
+
+	t.Run("should return notFoundError when the address has no match", func(t *testing.T) {
+		sut := makeFindNearestByAddressSut()
+
+		ctx := context.Background()
+
+		sut.geocoder.On("Geocode", ctx, "nowhere").Return(valueObjects.Coordinate{}, errors.NewNotFoundError("no results"))
+
+		_, err := sut.useCase.FindNearestByAddress(ctx, "nowhere")
+
+		assert.Equal(t, errors.NewNotFoundError("Address: nowhere could not be geocoded"), err)
+		sut.repo.AssertNotCalled(t, "FindNearestToPoints")
+	})
+
+	t.Run("should return internalError when the geocoder fails", func(t *testing.T) {
+		sut := makeFindNearestByAddressSut()
+
+		ctx := context.Background()
+
+		sut.geocoder.On("Geocode", ctx, "Praca da Se").Return(valueObjects.Coordinate{}, fmt.Errorf("dial tcp: i/o timeout"))
+
+		_, err := sut.useCase.FindNearestByAddress(ctx, "Praca da Se")
+
+		assert.Equal(t, errors.NewInternalError("geocoding failure: dial tcp: i/o timeout"), err)
+		sut.repo.AssertNotCalled(t, "FindNearestToPoints")
+	})
+
+	t.Run("should return ErrMarketNotFound when there is no market", func(t *testing.T) {
+		sut := makeFindNearestByAddressSut()
+
+		ctx := context.Background()
+
+		sut.geocoder.On("Geocode", ctx, "Praca da Se").Return(valueObjects.Coordinate{}, nil)
+		sut.repo.On("FindNearestToPoints", ctx, []valueObjects.Coordinate{{}}, 1).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		_, err := sut.useCase.FindNearestByAddress(ctx, "Praca da Se")
+
+		assert.Equal(t, errors.ErrMarketNotFound, err)
+	})
+
+	t.Run("should return the repository error", func(t *testing.T) {
+		sut := makeFindNearestByAddressSut()
+
+		ctx := context.Background()
+
+		sut.geocoder.On("Geocode", ctx, "Praca da Se").Return(valueObjects.Coordinate{}, nil)
+		sut.repo.On("FindNearestToPoints", ctx, []valueObjects.Coordinate{{}}, 1).Return([]valueObjects.MarketValueObjects(nil), errors.NewInternalError("query execution error"))
+
+		_, err := sut.useCase.FindNearestByAddress(ctx, "Praca da Se")
+
+		assert.Equal(t, errors.NewInternalError("query execution error"), err)
+	})
+}
+
+type findNearestByAddressSutRtn struct {
+	geocoder *geocoder.GeocoderSpy
+	repo     *repositories.MarketRepositorySpy
+	useCase  usecases.IFindNearestByAddressUseCase
+}
+
+func makeFindNearestByAddressSut() findNearestByAddressSutRtn {
+	geocoder := geocoder.NewGeocoderSpy()
+	repo := repositories.NewMarketRepositorySpy()
+
+	useCase := NewFindNearestByAddressUseCase(geocoder, repo)
+
+	return findNearestByAddressSutRtn{geocoder, repo, useCase}
+}
@@ -0,0 +1,21 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type getMarketSummariesUseCase struct {
+	repo interfaces.IMarketRepository
+}
+
+func (pst getMarketSummariesUseCase) Execute(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketSummary, error) {
+	return pst.repo.FindSummaries(ctx, filter)
+}
+
+func NewGetMarketSummariesUseCase(repo interfaces.IMarketRepository) usecases.IGetMarketSummariesUseCase {
+	return getMarketSummariesUseCase{repo}
+}
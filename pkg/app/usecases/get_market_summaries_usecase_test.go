@@ -0,0 +1,42 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetMarketSummaries_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeGetMarketSummariesSut()
+
+		ctx := context.Background()
+		filter := valueObjects.MarketValueObjects{Distrito: "VILA FORMOSA"}
+
+		sut.repo.On("FindSummaries", ctx, filter).Return([]valueObjects.MarketSummary{{ID: 1}}, nil)
+
+		result, err := sut.useCase.Execute(ctx, filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		sut.repo.AssertExpectations(t)
+	})
+}
+
+type getMarketSummariesSutRtn struct {
+	repo    *repositories.MarketRepositorySpy
+	useCase usecases.IGetMarketSummariesUseCase
+}
+
+func makeGetMarketSummariesSut() getMarketSummariesSutRtn {
+	repo := repositories.NewMarketRepositorySpy()
+
+	useCase := NewGetMarketSummariesUseCase(repo)
+
+	return getMarketSummariesSutRtn{repo, useCase}
+}
@@ -0,0 +1,21 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type getMarketsPageUseCase struct {
+	repo interfaces.IMarketRepository
+}
+
+func (pst getMarketsPageUseCase) Execute(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error) {
+	return pst.repo.FindPage(ctx, filter, pagination)
+}
+
+func NewGetMarketsPageUseCase(repo interfaces.IMarketRepository) usecases.IGetMarketsPageUseCase {
+	return getMarketsPageUseCase{repo}
+}
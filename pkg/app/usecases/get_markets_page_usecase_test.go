@@ -0,0 +1,43 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetMarketsPage_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeGetMarketsPageSut()
+
+		ctx := context.Background()
+		filter := valueObjects.MarketValueObjects{Distrito: "VILA FORMOSA"}
+		pagination := valueObjects.Pagination{Limit: 10, Offset: 20}
+
+		sut.repo.On("FindPage", ctx, filter, pagination).Return(valueObjects.MarketPage{Total: 21, Limit: 10, Offset: 20}, nil)
+
+		result, err := sut.useCase.Execute(ctx, filter, pagination)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(21), result.Total)
+		sut.repo.AssertExpectations(t)
+	})
+}
+
+type getMarketsPageSutRtn struct {
+	repo    *repositories.MarketRepositorySpy
+	useCase usecases.IGetMarketsPageUseCase
+}
+
+func makeGetMarketsPageSut() getMarketsPageSutRtn {
+	repo := repositories.NewMarketRepositorySpy()
+
+	useCase := NewGetMarketsPageUseCase(repo)
+
+	return getMarketsPageSutRtn{repo, useCase}
+}
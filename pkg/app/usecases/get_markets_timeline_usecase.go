@@ -0,0 +1,21 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type getMarketsTimelineUseCase struct {
+	repo interfaces.IMarketRepository
+}
+
+func (pst getMarketsTimelineUseCase) Execute(ctx context.Context, granularity string) ([]valueObjects.MarketTimelineValueObjects, error) {
+	return pst.repo.CountCreatedByPeriod(ctx, granularity)
+}
+
+func NewGetMarketsTimelineUseCase(repo interfaces.IMarketRepository) usecases.IGetMarketsTimelineUseCase {
+	return getMarketsTimelineUseCase{repo}
+}
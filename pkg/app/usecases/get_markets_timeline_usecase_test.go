@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetMarketsTimeline_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeGetMarketsTimelineSut()
+
+		ctx := context.Background()
+
+		sut.repo.On("CountCreatedByPeriod", ctx, "day").Return([]valueObjects.MarketTimelineValueObjects{{Count: 1}}, nil)
+
+		result, err := sut.useCase.Execute(ctx, "day")
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		sut.repo.AssertExpectations(t)
+	})
+}
+
+type getMarketsTimelineSutRtn struct {
+	repo    *repositories.MarketRepositorySpy
+	useCase usecases.IGetMarketsTimelineUseCase
+}
+
+func makeGetMarketsTimelineSut() getMarketsTimelineSutRtn {
+	repo := repositories.NewMarketRepositorySpy()
+
+	useCase := NewGetMarketsTimelineUseCase(repo)
+
+	return getMarketsTimelineSutRtn{repo, useCase}
+}
@@ -52,6 +52,66 @@ func NewGetMarketByQueryUseCaseSpy() *GetMarketByQueryUseCaseSpy {
 	return new(GetMarketByQueryUseCaseSpy)
 }
 
+//
+type ExportMarketsUseCaseSpy struct {
+	mock.Mock
+}
+
+func (pst ExportMarketsUseCaseSpy) Execute(ctx context.Context) ([]valueObjects.MarketValueObjects, bool, error) {
+	args := pst.Called(ctx)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Bool(1), args.Error(2)
+}
+
+func NewExportMarketsUseCaseSpy() *ExportMarketsUseCaseSpy {
+	return new(ExportMarketsUseCaseSpy)
+}
+
+//
+type GetMarketsPageUseCaseSpy struct {
+	mock.Mock
+}
+
+func (pst GetMarketsPageUseCaseSpy) Execute(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error) {
+	args := pst.Called(ctx, filter, pagination)
+
+	return args.Get(0).(valueObjects.MarketPage), args.Error(1)
+}
+
+func NewGetMarketsPageUseCaseSpy() *GetMarketsPageUseCaseSpy {
+	return new(GetMarketsPageUseCaseSpy)
+}
+
+//
+type GetMarketSummariesUseCaseSpy struct {
+	mock.Mock
+}
+
+func (pst GetMarketSummariesUseCaseSpy) Execute(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketSummary, error) {
+	args := pst.Called(ctx, filter)
+
+	return args.Get(0).([]valueObjects.MarketSummary), args.Error(1)
+}
+
+func NewGetMarketSummariesUseCaseSpy() *GetMarketSummariesUseCaseSpy {
+	return new(GetMarketSummariesUseCaseSpy)
+}
+
+//
+type GetMarketsTimelineUseCaseSpy struct {
+	mock.Mock
+}
+
+func (pst GetMarketsTimelineUseCaseSpy) Execute(ctx context.Context, granularity string) ([]valueObjects.MarketTimelineValueObjects, error) {
+	args := pst.Called(ctx, granularity)
+
+	return args.Get(0).([]valueObjects.MarketTimelineValueObjects), args.Error(1)
+}
+
+func NewGetMarketsTimelineUseCaseSpy() *GetMarketsTimelineUseCaseSpy {
+	return new(GetMarketsTimelineUseCaseSpy)
+}
+
 //
 type UpdateMarketUseCaseSpy struct {
 	mock.Mock
@@ -66,3 +126,18 @@ func (pst UpdateMarketUseCaseSpy) Execute(ctx context.Context, registerCode stri
 func NewUpdateMarketUseCaseSpy() *UpdateMarketUseCaseSpy {
 	return new(UpdateMarketUseCaseSpy)
 }
+
+//
+type FindNearestByAddressUseCaseSpy struct {
+	mock.Mock
+}
+
+func (pst FindNearestByAddressUseCaseSpy) FindNearestByAddress(ctx context.Context, address string) (valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, address)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func NewFindNearestByAddressUseCaseSpy() *FindNearestByAddressUseCaseSpy {
+	return new(FindNearestByAddressUseCaseSpy)
+}
@@ -59,6 +59,74 @@ func Test_GetMarketByQuerySpy_Execute(t *testing.T) {
 	})
 }
 
+func Test_ExportMarketsSpy_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewExportMarketsUseCaseSpy()
+
+		ctx := context.Background()
+
+		sut.On("Execute", ctx).Return([]valueObjects.MarketValueObjects{{}}, true, nil)
+
+		result, truncated, err := sut.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Len(t, result, 1)
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_GetMarketsPageSpy_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewGetMarketsPageUseCaseSpy()
+
+		ctx := context.Background()
+		filter := valueObjects.MarketValueObjects{}
+		pagination := valueObjects.Pagination{Limit: 10}
+
+		sut.On("Execute", ctx, filter, pagination).Return(valueObjects.MarketPage{Total: 1}, nil)
+
+		result, err := sut.Execute(ctx, filter, pagination)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), result.Total)
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_GetMarketSummariesSpy_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewGetMarketSummariesUseCaseSpy()
+
+		ctx := context.Background()
+		filter := valueObjects.MarketValueObjects{}
+
+		sut.On("Execute", ctx, filter).Return([]valueObjects.MarketSummary{{}}, nil)
+
+		result, err := sut.Execute(ctx, filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_GetMarketsTimelineSpy_Execute(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewGetMarketsTimelineUseCaseSpy()
+
+		ctx := context.Background()
+
+		sut.On("Execute", ctx, "day").Return([]valueObjects.MarketTimelineValueObjects{{}}, nil)
+
+		result, err := sut.Execute(ctx, "day")
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		sut.AssertExpectations(t)
+	})
+}
+
 func Test_UpdateMarketSpy_Execute(t *testing.T) {
 	t.Run("should execute correctly", func(t *testing.T) {
 		sut := NewUpdateMarketUseCaseSpy()
@@ -75,3 +143,19 @@ func Test_UpdateMarketSpy_Execute(t *testing.T) {
 		sut.AssertExpectations(t)
 	})
 }
+
+func Test_FindNearestByAddressSpy_FindNearestByAddress(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewFindNearestByAddressUseCaseSpy()
+
+		ctx := context.Background()
+
+		sut.On("FindNearestByAddress", ctx, "Praca da Se").Return(valueObjects.MarketValueObjects{Registro: "4041-0"}, nil)
+
+		result, err := sut.FindNearestByAddress(ctx, "Praca da Se")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "4041-0", result.Registro)
+		sut.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,11 @@
+package usecases
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type IExportMarketsUseCase interface {
+	Execute(ctx context.Context) (markets []valueObjects.MarketValueObjects, truncated bool, err error)
+}
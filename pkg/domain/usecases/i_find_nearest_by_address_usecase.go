@@ -0,0 +1,11 @@
+package usecases
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type IFindNearestByAddressUseCase interface {
+	FindNearestByAddress(ctx context.Context, address string) (valueObjects.MarketValueObjects, error)
+}
@@ -0,0 +1,11 @@
+package usecases
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type IGetMarketSummariesUseCase interface {
+	Execute(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketSummary, error)
+}
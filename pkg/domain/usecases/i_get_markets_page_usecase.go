@@ -0,0 +1,11 @@
+package usecases
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type IGetMarketsPageUseCase interface {
+	Execute(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error)
+}
@@ -0,0 +1,11 @@
+package usecases
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type IGetMarketsTimelineUseCase interface {
+	Execute(ctx context.Context, granularity string) ([]valueObjects.MarketTimelineValueObjects, error)
+}
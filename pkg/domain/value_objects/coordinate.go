@@ -0,0 +1,7 @@
+package valueObjects
+
+// Coordinate is a point in the same integer micro-degrees the markets are stored in.
+type Coordinate struct {
+	Long float64
+	Lat  float64
+}
@@ -0,0 +1,8 @@
+package valueObjects
+
+type CoordinateGroup struct {
+	Long      int
+	Lat       int
+	Count     int64
+	Registros []string
+}
@@ -1,7 +1,11 @@
 package valueObjects
 
+import "time"
+
 type MarketValueObjects struct {
+	// ID is set for the serial id type and UUID for the uuid one, see DB_ID_TYPE.
 	ID         int
+	UUID       string
 	Long       int
 	Lat        int
 	Setcens    string
@@ -18,4 +22,12 @@ type MarketValueObjects struct {
 	Numero     string
 	Bairro     string
 	Referencia string
+	DeletadoEm *time.Time
+
+	// LogradouroContains is a filter only: it matches logradouro by case-insensitive substring.
+	LogradouroContains string
+}
+
+func (pst MarketValueObjects) IsActive() bool {
+	return pst.DeletadoEm == nil
 }
@@ -0,0 +1,7 @@
+package valueObjects
+
+// MarketDistance is a market found around a point, with its distance to the point in kilometers.
+type MarketDistance struct {
+	Market     MarketValueObjects
+	DistanceKm float64
+}
@@ -0,0 +1,6 @@
+package valueObjects
+
+type MarketNeighborsValueObjects struct {
+	Market    MarketValueObjects
+	Neighbors []MarketValueObjects
+}
@@ -0,0 +1,20 @@
+package valueObjects
+
+type Pagination struct {
+	Limit  int
+	Offset int
+	// Snapshot reads the total and the page from the same snapshot, so they agree under concurrent writes.
+	Snapshot bool
+	// OrderBy is the column the page is sorted by, nome_feira when empty. Descending reverses it.
+	OrderBy    string
+	Descending bool
+}
+
+// MarketPage is one page of a filtered listing. Total counts every market matching the filter, so
+// callers can compute the number of pages.
+type MarketPage struct {
+	Markets []MarketValueObjects
+	Total   int64
+	Limit   int
+	Offset  int
+}
@@ -0,0 +1,9 @@
+package valueObjects
+
+type MarketSummary struct {
+	ID        int
+	UUID      string
+	NomeFeira string
+	Long      int
+	Lat       int
+}
@@ -0,0 +1,23 @@
+package valueObjects
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarketValueObjects_IsActive(t *testing.T) {
+	t.Run("should be active when DeletadoEm is nil", func(t *testing.T) {
+		market := MarketValueObjects{Registro: "4041-0"}
+
+		assert.True(t, market.IsActive())
+	})
+
+	t.Run("should not be active when DeletadoEm is set", func(t *testing.T) {
+		deletedAt := time.Now()
+		market := MarketValueObjects{Registro: "4041-0", DeletadoEm: &deletedAt}
+
+		assert.False(t, market.IsActive())
+	})
+}
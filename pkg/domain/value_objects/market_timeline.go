@@ -0,0 +1,8 @@
+package valueObjects
+
+import "time"
+
+type MarketTimelineValueObjects struct {
+	Period time.Time
+	Count  int64
+}
@@ -0,0 +1,64 @@
+package valueObjects
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Coordinates are stored in integer micro-degrees.
+const (
+	maxLong = 180 * 1000000
+	maxLat  = 90 * 1000000
+)
+
+// MarketValidationError lists every field of a market that failed validation.
+type MarketValidationError struct {
+	Fields []ValidateResult
+}
+
+func (pst MarketValidationError) Error() string {
+	messages := make([]string, 0, len(pst.Fields))
+	for _, field := range pst.Fields {
+		messages = append(messages, field.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks the coordinate ranges and the fields a new market requires.
+func (pst MarketValueObjects) Validate() error {
+	results := pst.coordinateResults()
+	required := []struct{ field, value string }{{"NomeFeira", pst.NomeFeira}, {"Registro", pst.Registro}, {"Distrito", pst.Distrito}}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			results = append(results, ValidateResult{Field: r.field, Message: fmt.Sprintf("%s is required", r.field)})
+		}
+	}
+
+	return validationError(results)
+}
+
+// ValidatePartial checks only the fields set, for partial updates where the empty ones are left untouched.
+func (pst MarketValueObjects) ValidatePartial() error {
+	return validationError(pst.coordinateResults())
+}
+
+func (pst MarketValueObjects) coordinateResults() []ValidateResult {
+	var results []ValidateResult
+	if pst.Long < -maxLong || pst.Long > maxLong {
+		results = append(results, ValidateResult{Field: "Long", Message: fmt.Sprintf("Long must be between %d and %d", -maxLong, maxLong)})
+	}
+	if pst.Lat < -maxLat || pst.Lat > maxLat {
+		results = append(results, ValidateResult{Field: "Lat", Message: fmt.Sprintf("Lat must be between %d and %d", -maxLat, maxLat)})
+	}
+
+	return results
+}
+
+func validationError(results []ValidateResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	return MarketValidationError{Fields: results}
+}
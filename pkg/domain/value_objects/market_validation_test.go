@@ -0,0 +1,49 @@
+package valueObjects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarketValueObjects_Validate(t *testing.T) {
+	valid := MarketValueObjects{Long: -46550164, Lat: -23558733, Distrito: "VILA FORMOSA", NomeFeira: "VILA FORMOSA", Registro: "4041-0"}
+
+	t.Run("should accept a valid market", func(t *testing.T) {
+		assert.NoError(t, valid.Validate())
+	})
+
+	t.Run("should accept the range limits", func(t *testing.T) {
+		market := valid
+		market.Long, market.Lat = -180000000, 90000000
+
+		assert.NoError(t, market.Validate())
+	})
+
+	t.Run("should list every invalid field", func(t *testing.T) {
+		market := MarketValueObjects{Long: 180000001, Lat: -90000001, Distrito: "VILA FORMOSA", NomeFeira: " "}
+
+		err := market.Validate()
+
+		assert.Equal(t, MarketValidationError{Fields: []ValidateResult{
+			{Field: "Long", Message: "Long must be between -180000000 and 180000000"},
+			{Field: "Lat", Message: "Lat must be between -90000000 and 90000000"},
+			{Field: "NomeFeira", Message: "NomeFeira is required"},
+			{Field: "Registro", Message: "Registro is required"},
+		}}, err)
+		assert.Equal(t, "Long must be between -180000000 and 180000000; Lat must be between -90000000 and 90000000; "+
+			"NomeFeira is required; Registro is required", err.Error())
+	})
+}
+
+func Test_MarketValueObjects_ValidatePartial(t *testing.T) {
+	t.Run("should accept the empty fields left untouched", func(t *testing.T) {
+		assert.NoError(t, MarketValueObjects{Bairro: "VL FORMOSA"}.ValidatePartial())
+	})
+
+	t.Run("should reject coordinates out of range", func(t *testing.T) {
+		err := MarketValueObjects{Lat: 90000001}.ValidatePartial()
+
+		assert.Equal(t, MarketValidationError{Fields: []ValidateResult{{Field: "Lat", Message: "Lat must be between -90000000 and 90000000"}}}, err)
+	})
+}
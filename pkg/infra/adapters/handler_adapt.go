@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"reflect"
 
 	"github.com/ralvescosta/base/pkg/app/interfaces"
 	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
@@ -13,12 +15,17 @@ import (
 
 var readAllBody = ioutil.ReadAll
 
+// HandlerAdapt writes the handler body as JSON, except []byte bodies written as is with the Content-Type the
+// handler set. Nil slices are written as [], so list endpoints never answer null; JSON_NULL_EMPTY_SLICES=true
+// keeps them as null for clients still relying on it.
 func HandlerAdapt(handler func(httpRequest httpServer.HttpRequest) httpServer.HttpResponse, logger interfaces.ILogger) gin.HandlerFunc {
+	nullEmptySlices := os.Getenv("JSON_NULL_EMPTY_SLICES") == "true"
+
 	return func(ctx *gin.Context) {
 		body, err := readAllBody(ctx.Request.Body)
 		if err != nil {
 			logger.Error("[HandlerAdapt] error while read request bytes")
-			ctx.JSON(http.StatusInternalServerError, gin.H{})
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "INTERNAL_ERROR", "message": "error while read request bytes"}})
 			return
 		}
 		ctx.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
@@ -28,16 +35,50 @@ func HandlerAdapt(handler func(httpRequest httpServer.HttpRequest) httpServer.Ht
 			params[param.Key] = param.Value
 		}
 
+		query := ctx.Request.URL.Query()
+		pretty := ctx.Request.Method == http.MethodGet && query.Get("pretty") == "true"
+		query.Del("pretty")
+
 		request := httpServer.HttpRequest{
 			Body:    body,
 			Headers: ctx.Request.Header,
 			Params:  params,
-			Query:   ctx.Request.URL.Query(),
+			Query:   query,
 			Ctx:     ctx.Request.Context(),
 		}
 
 		result := handler(request)
 
-		ctx.JSON(result.StatusCode, result.Body)
+		for key, values := range result.Headers {
+			for _, value := range values {
+				ctx.Writer.Header().Add(key, value)
+			}
+		}
+
+		if raw, ok := result.Body.([]byte); ok {
+			ctx.Data(result.StatusCode, result.Headers.Get("Content-Type"), raw)
+			return
+		}
+
+		response := result.Body
+		if !nullEmptySlices {
+			response = emptySliceIfNil(result.Body)
+		}
+
+		if pretty {
+			ctx.IndentedJSON(result.StatusCode, response)
+			return
+		}
+
+		ctx.JSON(result.StatusCode, response)
 	}
 }
+
+func emptySliceIfNil(body interface{}) interface{} {
+	value := reflect.ValueOf(body)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return reflect.MakeSlice(value.Type(), 0, 0).Interface()
+	}
+
+	return body
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"testing"
 
 	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
@@ -33,6 +34,7 @@ func Test_HandlerAdapter(t *testing.T) {
 		readAllBody = func(r io.Reader) ([]byte, error) {
 			return nil, errors.New("Error")
 		}
+		defer func() { readAllBody = ioutil.ReadAll }()
 		sut.logger.On("Error", "[HandlerAdapt] error while read request bytes", []zap.Field(nil))
 
 		sut.adapt(sut.ctx)
@@ -42,6 +44,106 @@ func Test_HandlerAdapter(t *testing.T) {
 	})
 }
 
+func Test_HandlerAdapter_Pretty(t *testing.T) {
+	body := map[string]interface{}{"nome_feira": "VILA FORMOSA"}
+
+	t.Run("should indent the response of read endpoints when pretty is true", func(t *testing.T) {
+		res, _ := serveAdapted(http.MethodGet, "/markets?pretty=true", body)
+
+		assert.Equal(t, "{\n    \"nome_feira\": \"VILA FORMOSA\"\n}", res.Body.String())
+	})
+
+	t.Run("should keep the response compact by default", func(t *testing.T) {
+		res, _ := serveAdapted(http.MethodGet, "/markets", body)
+
+		assert.Equal(t, `{"nome_feira":"VILA FORMOSA"}`, res.Body.String())
+	})
+
+	t.Run("should keep the response compact on write endpoints", func(t *testing.T) {
+		res, _ := serveAdapted(http.MethodPost, "/markets?pretty=true", body)
+
+		assert.Equal(t, `{"nome_feira":"VILA FORMOSA"}`, res.Body.String())
+	})
+
+	t.Run("should not forward pretty to the handler query", func(t *testing.T) {
+		_, req := serveAdapted(http.MethodGet, "/markets?pretty=true&bairro=VL", body)
+
+		assert.Equal(t, map[string][]string{"bairro": {"VL"}}, req.Query)
+	})
+}
+
+func Test_HandlerAdapter_Headers(t *testing.T) {
+	t.Run("should write the response headers returned by the handler", func(t *testing.T) {
+		handler := func(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+			return httpServer.HttpResponse{StatusCode: http.StatusOK, Headers: http.Header{"X-Export-Truncated": {"true"}}}
+		}
+
+		router := gin.New()
+		router.GET("/markets", HandlerAdapt(handler, logger.NewLoggerSpy()))
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/markets", nil))
+
+		assert.Equal(t, "true", res.Header().Get("X-Export-Truncated"))
+	})
+}
+
+func Test_HandlerAdapter_EmptySlices(t *testing.T) {
+	t.Run("should write a nil slice as an empty array", func(t *testing.T) {
+		res, _ := serveAdapted(http.MethodGet, "/markets", []string(nil))
+
+		assert.Equal(t, "[]", res.Body.String())
+	})
+
+	t.Run("should keep the nil slice as null when JSON_NULL_EMPTY_SLICES is true", func(t *testing.T) {
+		os.Setenv("JSON_NULL_EMPTY_SLICES", "true")
+		defer os.Unsetenv("JSON_NULL_EMPTY_SLICES")
+
+		res, _ := serveAdapted(http.MethodGet, "/markets", []string(nil))
+
+		assert.Equal(t, "null", res.Body.String())
+	})
+
+	t.Run("should leave the other bodies untouched", func(t *testing.T) {
+		res, _ := serveAdapted(http.MethodGet, "/markets", map[string]interface{}(nil))
+
+		assert.Equal(t, "null", res.Body.String())
+	})
+}
+
+func Test_HandlerAdapter_RawBody(t *testing.T) {
+	t.Run("should write a []byte body as is with the handler content type", func(t *testing.T) {
+		handler := func(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+			return httpServer.HttpResponse{StatusCode: http.StatusOK, Body: []byte("long,lat\n"), Headers: http.Header{"Content-Type": {"text/csv"}}}
+		}
+
+		router := gin.New()
+		router.GET("/markets", HandlerAdapt(handler, logger.NewLoggerSpy()))
+
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/markets", nil))
+
+		assert.Equal(t, "long,lat\n", res.Body.String())
+		assert.Equal(t, "text/csv", res.Header().Get("Content-Type"))
+	})
+}
+
+func serveAdapted(method, target string, body interface{}) (*httptest.ResponseRecorder, *httpServer.HttpRequest) {
+	received := &httpServer.HttpRequest{}
+	handler := func(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+		*received = httpRequest
+		return httpServer.HttpResponse{StatusCode: http.StatusOK, Body: body}
+	}
+
+	router := gin.New()
+	router.Handle(method, "/markets", HandlerAdapt(handler, logger.NewLoggerSpy()))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(method, target, nil))
+
+	return res, received
+}
+
 type sutReturn struct {
 	adapt              gin.HandlerFunc
 	logger             *logger.LoggerSpy
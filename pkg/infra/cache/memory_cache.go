@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mutex   *sync.RWMutex
+	entries map[string]entry
+}
+
+var now = time.Now
+
+func (pst memoryCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	pst.mutex.RLock()
+	defer pst.mutex.RUnlock()
+
+	e, ok := pst.entries[key]
+	if !ok || !now().Before(e.expiresAt) {
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+func (pst memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	pst.mutex.Lock()
+	defer pst.mutex.Unlock()
+
+	pst.entries[key] = entry{value, now().Add(ttl)}
+}
+
+func (pst memoryCache) Clear(ctx context.Context) {
+	pst.mutex.Lock()
+	defer pst.mutex.Unlock()
+
+	for k := range pst.entries {
+		delete(pst.entries, k)
+	}
+}
+
+func NewMemoryCache() interfaces.ICache {
+	return memoryCache{
+		mutex:   &sync.RWMutex{},
+		entries: make(map[string]entry),
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryCache_Get(t *testing.T) {
+	t.Run("should return the value stored before it expires", func(t *testing.T) {
+		sut := makeMemoryCacheSut()
+
+		sut.cache.Set(context.Background(), "key", []byte("value"), time.Minute)
+		value, ok := sut.cache.Get(context.Background(), "key")
+
+		assert.True(t, ok)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("should not return the value after the ttl", func(t *testing.T) {
+		sut := makeMemoryCacheSut()
+
+		sut.cache.Set(context.Background(), "key", []byte("value"), time.Minute)
+		sut.advance(time.Minute)
+		_, ok := sut.cache.Get(context.Background(), "key")
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should not store values with a zero ttl", func(t *testing.T) {
+		sut := makeMemoryCacheSut()
+
+		sut.cache.Set(context.Background(), "key", []byte("value"), 0)
+		_, ok := sut.cache.Get(context.Background(), "key")
+
+		assert.False(t, ok)
+	})
+}
+
+func Test_MemoryCache_Clear(t *testing.T) {
+	t.Run("should remove every entry", func(t *testing.T) {
+		sut := makeMemoryCacheSut()
+
+		sut.cache.Set(context.Background(), "key", []byte("value"), time.Minute)
+		sut.cache.Clear(context.Background())
+		_, ok := sut.cache.Get(context.Background(), "key")
+
+		assert.False(t, ok)
+	})
+}
+
+type memoryCacheSutRtn struct {
+	cache   memoryCache
+	advance func(d time.Duration)
+}
+
+func makeMemoryCacheSut() memoryCacheSutRtn {
+	t := time.Now()
+	now = func() time.Time { return t }
+
+	advance := func(d time.Duration) {
+		t = t.Add(d)
+	}
+
+	return memoryCacheSutRtn{NewMemoryCache().(memoryCache), advance}
+}
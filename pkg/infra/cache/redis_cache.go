@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisKeyPrefix = "cache:"
+	// InvalidationChannel receives a message every time the cache is cleared after a write.
+	InvalidationChannel = "cache:invalidation"
+)
+
+type redisCache struct {
+	logger interfaces.ILogger
+	client *redis.Client
+}
+
+func (pst redisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := pst.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false
+	}
+	if err != nil {
+		pst.logger.Warn(fmt.Sprintf("[RedisCache::Get] - %s", err.Error()))
+		return nil, false
+	}
+
+	return value, true
+}
+
+func (pst redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	if err := pst.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err(); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[RedisCache::Set] - %s", err.Error()))
+	}
+}
+
+func (pst redisCache) Clear(ctx context.Context) {
+	iter := pst.client.Scan(ctx, 0, redisKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := pst.client.Del(ctx, iter.Val()).Err(); err != nil {
+			pst.logger.Warn(fmt.Sprintf("[RedisCache::Clear] - %s", err.Error()))
+		}
+	}
+	if err := iter.Err(); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[RedisCache::Clear] - %s", err.Error()))
+	}
+
+	if err := pst.client.Publish(ctx, InvalidationChannel, "clear").Err(); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[RedisCache::Clear] - could not publish the invalidation: %s", err.Error()))
+	}
+}
+
+func NewRedisCache(logger interfaces.ILogger) (interfaces.ICache, error) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		return nil, errors.NewInternalError("REDIS_ADDR is required")
+	}
+
+	db := 0
+	if rawDB := os.Getenv("REDIS_DB"); rawDB != "" {
+		var err error
+		if db, err = strconv.Atoi(rawDB); err != nil {
+			return nil, errors.NewInternalError("REDIS_DB must be a number")
+		}
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       db,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		logger.Error(fmt.Sprintf("[RedisCache::Connect] - error while connect to redis: %s", err.Error()))
+		return nil, errors.NewInternalError(fmt.Sprintf("failure to connect to redis: %s", err.Error()))
+	}
+
+	return redisCache{logger, client}, nil
+}
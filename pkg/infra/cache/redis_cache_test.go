@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RedisCache_Get(t *testing.T) {
+	t.Run("should return the value stored before it expires", func(t *testing.T) {
+		sut := makeRedisCacheSut(t)
+
+		sut.cache.Set(sut.ctx, "key", []byte(`[{"ID":1}]`), time.Minute)
+		value, ok := sut.cache.Get(sut.ctx, "key")
+
+		assert.True(t, ok)
+		assert.Equal(t, []byte(`[{"ID":1}]`), value)
+	})
+
+	t.Run("should not return the value after the ttl", func(t *testing.T) {
+		sut := makeRedisCacheSut(t)
+
+		sut.cache.Set(sut.ctx, "key", []byte("value"), time.Minute)
+		sut.server.FastForward(time.Minute)
+		_, ok := sut.cache.Get(sut.ctx, "key")
+
+		assert.False(t, ok)
+	})
+
+	t.Run("should share the entries between instances", func(t *testing.T) {
+		sut := makeRedisCacheSut(t)
+		other, _ := NewRedisCache(sut.logger)
+
+		sut.cache.Set(sut.ctx, "key", []byte("value"), time.Minute)
+		value, ok := other.Get(sut.ctx, "key")
+
+		assert.True(t, ok)
+		assert.Equal(t, []byte("value"), value)
+	})
+}
+
+func Test_RedisCache_Clear(t *testing.T) {
+	t.Run("should remove only the cache entries and publish the invalidation", func(t *testing.T) {
+		sut := makeRedisCacheSut(t)
+		sut.server.Set("other", "value")
+		subscription := sut.cache.(redisCache).client.Subscribe(sut.ctx, InvalidationChannel)
+		defer subscription.Close()
+		subscription.Receive(sut.ctx)
+
+		sut.cache.Set(sut.ctx, "key", []byte("value"), time.Minute)
+		sut.cache.Clear(sut.ctx)
+		_, ok := sut.cache.Get(sut.ctx, "key")
+
+		assert.False(t, ok)
+		assert.True(t, sut.server.Exists("other"))
+
+		msg, err := subscription.ReceiveMessage(sut.ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "clear", msg.Payload)
+	})
+}
+
+func Test_NewRedisCache(t *testing.T) {
+	t.Run("should return error if REDIS_ADDR has not been defined", func(t *testing.T) {
+		os.Setenv("REDIS_ADDR", "")
+
+		_, err := NewRedisCache(logger.NewLoggerSpy())
+
+		assert.Error(t, err)
+	})
+}
+
+type redisCacheSutRtn struct {
+	ctx    context.Context
+	logger *logger.LoggerSpy
+	server *miniredis.Miniredis
+	cache  interfaces.ICache
+}
+
+func makeRedisCacheSut(t *testing.T) redisCacheSutRtn {
+	server := miniredis.RunT(t)
+	os.Setenv("REDIS_ADDR", server.Addr())
+	logger := logger.NewLoggerSpy()
+
+	cache, err := NewRedisCache(logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return redisCacheSutRtn{context.Background(), logger, server, cache}
+}
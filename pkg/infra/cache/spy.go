@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type CacheSpy struct {
+	mock.Mock
+}
+
+func (pst CacheSpy) Get(ctx context.Context, key string) ([]byte, bool) {
+	args := pst.Called(ctx, key)
+
+	return args.Get(0).([]byte), args.Bool(1)
+}
+
+func (pst CacheSpy) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	pst.Called(ctx, key, value, ttl)
+}
+
+func (pst CacheSpy) Clear(ctx context.Context) {
+	pst.Called(ctx)
+}
+
+func NewCacheSpy() *CacheSpy {
+	return new(CacheSpy)
+}
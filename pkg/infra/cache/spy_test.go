@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CacheSpy_Get(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewCacheSpy()
+
+		ctx := context.Background()
+		sut.On("Get", ctx, "key").Return([]byte("value"), true)
+
+		value, ok := sut.Get(ctx, "key")
+
+		assert.True(t, ok)
+		assert.Equal(t, []byte("value"), value)
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CacheSpy_Set(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewCacheSpy()
+
+		ctx := context.Background()
+		sut.On("Set", ctx, "key", []byte("value"), time.Second)
+
+		sut.Set(ctx, "key", []byte("value"), time.Second)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CacheSpy_Clear(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewCacheSpy()
+
+		ctx := context.Background()
+		sut.On("Clear", ctx)
+
+		sut.Clear(ctx)
+
+		sut.AssertExpectations(t)
+	})
+}
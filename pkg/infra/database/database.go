@@ -10,11 +10,35 @@ import (
 	"github.com/ralvescosta/base/pkg/app/errors"
 	"github.com/ralvescosta/base/pkg/app/interfaces"
 
+	_ "github.com/jackc/pgx/v4/stdlib"
 	_ "github.com/lib/pq"
 )
 
 var open = sql.Open
 
+var drivers = map[string]bool{"postgres": true, "pgx": true}
+
+var sleep = time.Sleep
+
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
+
+const (
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = 5 * time.Minute
+)
+
+// PoolConfig tunes the *sql.DB pool, read from DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME.
+// Zero keeps the database/sql meaning: unlimited open connections and lifetime, no idle connection.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
 func Connect(logger interfaces.ILogger, shotdown chan bool) (*sql.DB, error) {
 	connString, err := getConnectionString()
 	if err != nil {
@@ -22,13 +46,24 @@ func Connect(logger interfaces.ILogger, shotdown chan bool) (*sql.DB, error) {
 		return nil, err
 	}
 
-	db, err := open("postgres", connString)
+	driver, err := getDriver()
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Database::Connect] - %s", err.Error()))
+		return nil, err
+	}
+
+	db, err := open(driver, connString)
 	if err != nil {
 		logger.Error(fmt.Sprintf("[Database::Connect] - error while connect to database: %s", err.Error()))
 		return nil, errors.NewInternalError(fmt.Sprintf("failure to connect to the database: %s", err.Error()))
 	}
 
-	err = db.Ping()
+	pool := PoolConfigFromEnv(logger)
+	pool.Apply(db)
+	logger.Info(fmt.Sprintf("[Database::Connect] - pool configured: max open conns %d, max idle conns %d, conn max lifetime %s",
+		pool.MaxOpenConns, pool.MaxIdleConns, pool.ConnMaxLifetime))
+
+	err = ping(logger, db, connectTimeout(logger))
 	if err != nil {
 		logger.Error(fmt.Sprintf("[Database::Connect] - error while check database connection: %s", err.Error()))
 		return nil, errors.NewInternalError(fmt.Sprintf("failure to connect to the database: %s", err.Error()))
@@ -45,6 +80,108 @@ func Connect(logger interfaces.ILogger, shotdown chan bool) (*sql.DB, error) {
 	return db, nil
 }
 
+// ping retries with exponential backoff until the database answers or the timeout is spent, so the
+// service survives booting before the database in compose/k8s.
+func ping(logger interfaces.ILogger, db *sql.DB, timeout time.Duration) error {
+	backoff := initialBackoff
+	waited := time.Duration(0)
+
+	for attempt := 1; ; attempt++ {
+		err := db.Ping()
+		if err == nil {
+			return nil
+		}
+
+		if waited+backoff > timeout {
+			return err
+		}
+
+		logger.Warn(fmt.Sprintf("[Database::Connect] - database not ready on attempt %d, retrying in %s: %s", attempt, backoff, err.Error()))
+		sleep(backoff)
+		waited += backoff
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (pst PoolConfig) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(pst.MaxOpenConns)
+	db.SetMaxIdleConns(pst.MaxIdleConns)
+	db.SetConnMaxLifetime(pst.ConnMaxLifetime)
+}
+
+// PoolConfigFromEnv falls back to the default of each setting that is unset or invalid. The idle connections
+// are capped by the open ones, as database/sql would do anyway.
+func PoolConfigFromEnv(logger interfaces.ILogger) PoolConfig {
+	pool := PoolConfig{
+		MaxOpenConns:    defaultMaxOpenConns,
+		MaxIdleConns:    defaultMaxIdleConns,
+		ConnMaxLifetime: defaultConnMaxLifetime,
+	}
+
+	if raw := os.Getenv("DB_MAX_OPEN_CONNS"); raw != "" {
+		if value, err := strconv.Atoi(raw); err != nil || value < 0 {
+			logger.Warn(fmt.Sprintf("[Database::Connect] - invalid DB_MAX_OPEN_CONNS: %s", raw))
+		} else {
+			pool.MaxOpenConns = value
+		}
+	}
+
+	if raw := os.Getenv("DB_MAX_IDLE_CONNS"); raw != "" {
+		if value, err := strconv.Atoi(raw); err != nil || value < 0 {
+			logger.Warn(fmt.Sprintf("[Database::Connect] - invalid DB_MAX_IDLE_CONNS: %s", raw))
+		} else {
+			pool.MaxIdleConns = value
+		}
+	}
+
+	if raw := os.Getenv("DB_CONN_MAX_LIFETIME"); raw != "" {
+		if value, err := time.ParseDuration(raw); err != nil || value < 0 {
+			logger.Warn(fmt.Sprintf("[Database::Connect] - invalid DB_CONN_MAX_LIFETIME: %s", raw))
+		} else {
+			pool.ConnMaxLifetime = value
+		}
+	}
+
+	if pool.MaxOpenConns > 0 && pool.MaxIdleConns > pool.MaxOpenConns {
+		pool.MaxIdleConns = pool.MaxOpenConns
+	}
+
+	return pool
+}
+
+// connectTimeout reads DB_CONNECT_TIMEOUT, how long Connect keeps retrying. Zero, the default, fails on the first ping.
+func connectTimeout(logger interfaces.ILogger) time.Duration {
+	raw := os.Getenv("DB_CONNECT_TIMEOUT")
+	if raw == "" {
+		return 0
+	}
+
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout < 0 {
+		logger.Warn(fmt.Sprintf("[Database::Connect] - invalid DB_CONNECT_TIMEOUT: %s", raw))
+		return 0
+	}
+
+	return timeout
+}
+
+func getDriver() (string, error) {
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" {
+		return "postgres", nil
+	}
+
+	if !drivers[driver] {
+		return "", errors.NewInternalError(fmt.Sprintf("DB_DRIVER %s is not supported, use postgres or pgx", driver))
+	}
+
+	return driver, nil
+}
+
 func getConnectionString() (string, error) {
 	host := os.Getenv("DB_HOST")
 	if host == "" {
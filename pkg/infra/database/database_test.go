@@ -5,22 +5,27 @@ import (
 	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/ralvescosta/base/pkg/infra/logger"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zapcore"
 )
 
 func Test_Connect(t *testing.T) {
 	t.Run("should connect to database correctly", func(t *testing.T) {
 		sut := makeDatabaseSutRtn(nil)
+		sut.logger.On("Info", "[Database::Connect] - pool configured: max open conns 25, max idle conns 10, conn max lifetime 5m0s", []zapcore.Field(nil))
 
 		db, err := Connect(sut.logger, sut.shotdown)
 
 		assert.NotNil(t, db)
 		assert.NoError(t, err)
+		assert.Equal(t, 25, db.Stats().MaxOpenConnections)
+		sut.logger.AssertExpectations(t)
 	})
 
 	t.Run("should return error if some error when try to connect", func(t *testing.T) {
@@ -37,6 +42,7 @@ func Test_Connect(t *testing.T) {
 	t.Run("should return error if DB_SECONDS_TO_PING has not been defined", func(t *testing.T) {
 		sut := makeDatabaseSutRtn(nil)
 		os.Setenv("DB_SECONDS_TO_PING", "")
+		sut.logger.On("Info", mock.Anything, []zapcore.Field(nil))
 		sut.logger.On("Error", "[Database::Connect] - DB_SECONDS_TO_PING is required: strconv.Atoi: parsing \"\": invalid syntax", []zapcore.Field(nil))
 
 		db, err := Connect(sut.logger, sut.shotdown)
@@ -47,6 +53,137 @@ func Test_Connect(t *testing.T) {
 	})
 }
 
+func Test_Ping(t *testing.T) {
+	t.Run("should retry with backoff until the database is ready", func(t *testing.T) {
+		sut := makePingSut()
+		sut.sqlMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		sut.sqlMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		sut.sqlMock.ExpectPing()
+		sut.logger.On("Warn", "[Database::Connect] - database not ready on attempt 1, retrying in 500ms: connection refused", []zapcore.Field(nil))
+		sut.logger.On("Warn", "[Database::Connect] - database not ready on attempt 2, retrying in 1s: connection refused", []zapcore.Field(nil))
+
+		err := ping(sut.logger, sut.db, 30*time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, *sut.sleeps)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should give up once the timeout is spent", func(t *testing.T) {
+		sut := makePingSut()
+		for i := 0; i < 3; i++ {
+			sut.sqlMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		}
+		sut.logger.On("Warn", "[Database::Connect] - database not ready on attempt 1, retrying in 500ms: connection refused", []zapcore.Field(nil))
+		sut.logger.On("Warn", "[Database::Connect] - database not ready on attempt 2, retrying in 1s: connection refused", []zapcore.Field(nil))
+
+		err := ping(sut.logger, sut.db, 2*time.Second)
+
+		assert.EqualError(t, err, "connection refused")
+		assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, *sut.sleeps)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not retry without timeout", func(t *testing.T) {
+		sut := makePingSut()
+		sut.sqlMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+		err := ping(sut.logger, sut.db, 0)
+
+		assert.Error(t, err)
+		assert.Empty(t, *sut.sleeps)
+	})
+
+	t.Run("should cap the backoff", func(t *testing.T) {
+		sut := makePingSut()
+		for i := 0; i < 6; i++ {
+			sut.sqlMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		}
+		sut.sqlMock.ExpectPing()
+		sut.logger.On("Warn", mock.Anything, []zapcore.Field(nil))
+
+		err := ping(sut.logger, sut.db, time.Minute)
+
+		assert.NoError(t, err)
+		assert.Equal(t, maxBackoff, (*sut.sleeps)[len(*sut.sleeps)-1])
+	})
+
+	t.Run("should read the timeout from env", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_CONNECT_TIMEOUT", "45s")
+		defer os.Unsetenv("DB_CONNECT_TIMEOUT")
+
+		assert.Equal(t, 45*time.Second, connectTimeout(log))
+	})
+
+	t.Run("should not retry when the timeout is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_CONNECT_TIMEOUT", "wrong")
+		defer os.Unsetenv("DB_CONNECT_TIMEOUT")
+		log.On("Warn", "[Database::Connect] - invalid DB_CONNECT_TIMEOUT: wrong", []zapcore.Field(nil))
+
+		assert.Equal(t, time.Duration(0), connectTimeout(log))
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_GetDriver(t *testing.T) {
+	t.Run("should open lib/pq by default", func(t *testing.T) {
+		sut := makeDatabaseSutRtn(nil)
+
+		sut.logger.On("Info", mock.Anything, []zapcore.Field(nil))
+
+		db, err := Connect(sut.logger, sut.shotdown)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, db)
+		assert.Equal(t, "postgres", *sut.driver)
+	})
+
+	t.Run("should open pgx when DB_DRIVER is pgx", func(t *testing.T) {
+		sut := makeDatabaseSutRtn(nil)
+		os.Setenv("DB_DRIVER", "pgx")
+		defer os.Unsetenv("DB_DRIVER")
+
+		sut.logger.On("Info", mock.Anything, []zapcore.Field(nil))
+
+		db, err := Connect(sut.logger, sut.shotdown)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, db)
+		assert.Equal(t, "pgx", *sut.driver)
+	})
+
+	t.Run("should return error if DB_DRIVER is not supported", func(t *testing.T) {
+		sut := makeDatabaseSutRtn(nil)
+		os.Setenv("DB_DRIVER", "mysql")
+		defer os.Unsetenv("DB_DRIVER")
+		sut.logger.On("Error", "[Database::Connect] - DB_DRIVER mysql is not supported, use postgres or pgx", []zapcore.Field(nil))
+
+		db, err := Connect(sut.logger, sut.shotdown)
+
+		assert.Nil(t, db)
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should register both drivers", func(t *testing.T) {
+		assert.Contains(t, sql.Drivers(), "postgres")
+		assert.Contains(t, sql.Drivers(), "pgx")
+	})
+
+	t.Run("should accept the connection string with the pgx driver", func(t *testing.T) {
+		connString, err := getConnectionString()
+		assert.NoError(t, err)
+
+		db, err := sql.Open("pgx", connString)
+
+		assert.NoError(t, err)
+		assert.NoError(t, db.Close())
+	})
+}
+
 func Test_GetConnectionString(t *testing.T) {
 	t.Run("should return err if DB_HOST was has not been defined", func(t *testing.T) {
 		sut := makeDatabaseSutRtn(nil)
@@ -108,10 +245,66 @@ func Test_GetConnectionString(t *testing.T) {
 		sut.logger.AssertExpectations(t)
 	})
 }
+func Test_PoolConfigFromEnv(t *testing.T) {
+	t.Run("should use the defaults", func(t *testing.T) {
+		pool := PoolConfigFromEnv(logger.NewLoggerSpy())
+
+		assert.Equal(t, PoolConfig{MaxOpenConns: 25, MaxIdleConns: 10, ConnMaxLifetime: 5 * time.Minute}, pool)
+	})
+
+	t.Run("should read the settings from env", func(t *testing.T) {
+		os.Setenv("DB_MAX_OPEN_CONNS", "50")
+		os.Setenv("DB_MAX_IDLE_CONNS", "20")
+		os.Setenv("DB_CONN_MAX_LIFETIME", "30m")
+		defer os.Unsetenv("DB_MAX_OPEN_CONNS")
+		defer os.Unsetenv("DB_MAX_IDLE_CONNS")
+		defer os.Unsetenv("DB_CONN_MAX_LIFETIME")
+
+		pool := PoolConfigFromEnv(logger.NewLoggerSpy())
+
+		assert.Equal(t, PoolConfig{MaxOpenConns: 50, MaxIdleConns: 20, ConnMaxLifetime: 30 * time.Minute}, pool)
+	})
+
+	t.Run("should fall back to the default of each invalid setting", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_MAX_OPEN_CONNS", "many")
+		os.Setenv("DB_MAX_IDLE_CONNS", "-1")
+		os.Setenv("DB_CONN_MAX_LIFETIME", "forever")
+		defer os.Unsetenv("DB_MAX_OPEN_CONNS")
+		defer os.Unsetenv("DB_MAX_IDLE_CONNS")
+		defer os.Unsetenv("DB_CONN_MAX_LIFETIME")
+		log.On("Warn", "[Database::Connect] - invalid DB_MAX_OPEN_CONNS: many", []zapcore.Field(nil))
+		log.On("Warn", "[Database::Connect] - invalid DB_MAX_IDLE_CONNS: -1", []zapcore.Field(nil))
+		log.On("Warn", "[Database::Connect] - invalid DB_CONN_MAX_LIFETIME: forever", []zapcore.Field(nil))
+
+		pool := PoolConfigFromEnv(log)
+
+		assert.Equal(t, PoolConfig{MaxOpenConns: 25, MaxIdleConns: 10, ConnMaxLifetime: 5 * time.Minute}, pool)
+		log.AssertExpectations(t)
+	})
+
+	t.Run("should cap the idle connections by the open ones", func(t *testing.T) {
+		os.Setenv("DB_MAX_OPEN_CONNS", "4")
+		defer os.Unsetenv("DB_MAX_OPEN_CONNS")
+
+		pool := PoolConfigFromEnv(logger.NewLoggerSpy())
+
+		assert.Equal(t, 4, pool.MaxIdleConns)
+	})
+
+	t.Run("should apply the settings to the pool", func(t *testing.T) {
+		db, _, _ := sqlmock.New()
+
+		PoolConfig{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute}.Apply(db)
+
+		assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+	})
+}
 
 type databaseSutRtn struct {
 	logger   *logger.LoggerSpy
 	shotdown chan bool
+	driver   *string
 }
 
 func makeDatabaseSutRtn(dbConnError error) databaseSutRtn {
@@ -122,7 +315,9 @@ func makeDatabaseSutRtn(dbConnError error) databaseSutRtn {
 	os.Setenv("DB_NAME", "name")
 	os.Setenv("DB_SECONDS_TO_PING", "20")
 
+	var openedDriver string
 	open = func(driver, connectionString string) (*sql.DB, error) {
+		openedDriver = driver
 		db, _, _ := sqlmock.New()
 		return db, dbConnError
 	}
@@ -130,5 +325,23 @@ func makeDatabaseSutRtn(dbConnError error) databaseSutRtn {
 	logger := logger.NewLoggerSpy()
 	shotdown := make(chan bool)
 
-	return databaseSutRtn{logger, shotdown}
+	return databaseSutRtn{logger, shotdown, &openedDriver}
+}
+
+type pingSutRtn struct {
+	logger  *logger.LoggerSpy
+	db      *sql.DB
+	sqlMock sqlmock.Sqlmock
+	sleeps  *[]time.Duration
+}
+
+func makePingSut() pingSutRtn {
+	db, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+
+	sleeps := []time.Duration{}
+	sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	return pingSutRtn{logger.NewLoggerSpy(), db, sqlMock, &sleeps}
 }
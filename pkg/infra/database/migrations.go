@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// PendingMigrations lists the *_up.sql files of dir that are not recorded in the migrations table.
+func PendingMigrations(ctx context.Context, db *sql.DB, dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := AppliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []string{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), "_up.sql") || applied[f.Name()] {
+			continue
+		}
+		pending = append(pending, f.Name())
+	}
+
+	sort.Strings(pending)
+
+	return pending, nil
+}
+
+func AppliedMigrations(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		applied[name] = true
+	}
+
+	return applied, rows.Err()
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MarketID scans the feiras id column, an integer with the serial ids or a string with the uuid ones.
+type MarketID struct {
+	Int  int
+	UUID string
+}
+
+func (pst *MarketID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		pst.Int = int(v)
+	case string:
+		return pst.parse(v)
+	case []byte:
+		return pst.parse(string(v))
+	default:
+		return fmt.Errorf("unsupported id type %T", src)
+	}
+
+	return nil
+}
+
+// parse keeps numeric text as an integer id, drivers returning text for serial columns included.
+func (pst *MarketID) parse(text string) error {
+	if id, err := strconv.Atoi(text); err == nil {
+		pst.Int = id
+		return nil
+	}
+
+	pst.UUID = text
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarketID_Scan(t *testing.T) {
+	t.Run("should scan a serial id", func(t *testing.T) {
+		id := MarketID{}
+
+		assert.NoError(t, id.Scan(int64(42)))
+		assert.Equal(t, MarketID{Int: 42}, id)
+	})
+
+	t.Run("should scan a uuid id from text or bytes", func(t *testing.T) {
+		uuid := "4b0e8ac4-2a0a-4c9f-9a4e-58d0c1e0b6f1"
+		fromText, fromBytes := MarketID{}, MarketID{}
+
+		assert.NoError(t, fromText.Scan(uuid))
+		assert.NoError(t, fromBytes.Scan([]byte(uuid)))
+		assert.Equal(t, MarketID{UUID: uuid}, fromText)
+		assert.Equal(t, MarketID{UUID: uuid}, fromBytes)
+	})
+
+	t.Run("should scan a serial id sent as text", func(t *testing.T) {
+		id := MarketID{}
+
+		assert.NoError(t, id.Scan([]byte("7")))
+		assert.Equal(t, MarketID{Int: 7}, id)
+	})
+
+	t.Run("should reject other types", func(t *testing.T) {
+		id := MarketID{}
+
+		assert.Error(t, id.Scan(nil))
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 type MarketModel struct {
 	ID           int
+	UUID         string
 	Long         int
 	Lat          int
 	Setcens      string
@@ -32,6 +33,7 @@ type MarketModel struct {
 func (pst MarketModel) ToValueObject() valueObjects.MarketValueObjects {
 	return valueObjects.MarketValueObjects{
 		ID:         pst.ID,
+		UUID:       pst.UUID,
 		Long:       pst.Long,
 		Lat:        pst.Lat,
 		Setcens:    pst.Setcens,
@@ -48,5 +50,6 @@ func (pst MarketModel) ToValueObject() valueObjects.MarketValueObjects {
 		Numero:     pst.Numero,
 		Bairro:     pst.Bairro,
 		Referencia: pst.Referencia,
+		DeletadoEm: pst.DeletadoEm,
 	}
 }
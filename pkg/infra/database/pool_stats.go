@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+const defaultPoolStatsInterval = time.Minute
+
+// PoolStatsJob logs the pool usage on every run. A wait count growing between runs tells the pool is too small
+// for the load.
+func PoolStatsJob(logger interfaces.ILogger, db *sql.DB) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		stats := db.Stats()
+		logger.Info(fmt.Sprintf("[Database::PoolStats] - open conns %d, in use %d, idle %d, waited %d times for %s",
+			stats.OpenConnections, stats.InUse, stats.Idle, stats.WaitCount, stats.WaitDuration))
+	}
+}
+
+// PoolStatsIntervalFromEnv reads DB_POOL_STATS_INTERVAL, how often PoolStatsJob runs. Zero disables the job.
+func PoolStatsIntervalFromEnv(logger interfaces.ILogger) time.Duration {
+	raw := os.Getenv("DB_POOL_STATS_INTERVAL")
+	if raw == "" {
+		return defaultPoolStatsInterval
+	}
+
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < 0 {
+		logger.Warn(fmt.Sprintf("[Database::PoolStats] - invalid DB_POOL_STATS_INTERVAL: %s", raw))
+		return defaultPoolStatsInterval
+	}
+
+	return interval
+}
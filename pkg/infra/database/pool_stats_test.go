@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_PoolStatsJob(t *testing.T) {
+	t.Run("should log the pool usage", func(t *testing.T) {
+		db, _, _ := sqlmock.New()
+		defer db.Close()
+		log := logger.NewLoggerSpy()
+		log.On("Info", "[Database::PoolStats] - open conns 1, in use 0, idle 1, waited 0 times for 0s", []zapcore.Field(nil))
+
+		PoolStatsJob(log, db)(context.Background())
+
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_PoolStatsIntervalFromEnv(t *testing.T) {
+	t.Run("should default to one minute", func(t *testing.T) {
+		assert.Equal(t, time.Minute, PoolStatsIntervalFromEnv(logger.NewLoggerSpy()))
+	})
+
+	t.Run("should read the interval and zero from env", func(t *testing.T) {
+		defer os.Unsetenv("DB_POOL_STATS_INTERVAL")
+
+		os.Setenv("DB_POOL_STATS_INTERVAL", "30s")
+		assert.Equal(t, 30*time.Second, PoolStatsIntervalFromEnv(logger.NewLoggerSpy()))
+
+		os.Setenv("DB_POOL_STATS_INTERVAL", "0s")
+		assert.Equal(t, time.Duration(0), PoolStatsIntervalFromEnv(logger.NewLoggerSpy()))
+	})
+
+	t.Run("should fall back to the default when the env is invalid", func(t *testing.T) {
+		os.Setenv("DB_POOL_STATS_INTERVAL", "-1m")
+		defer os.Unsetenv("DB_POOL_STATS_INTERVAL")
+		log := logger.NewLoggerSpy()
+		log.On("Warn", "[Database::PoolStats] - invalid DB_POOL_STATS_INTERVAL: -1m", []zapcore.Field(nil))
+
+		assert.Equal(t, time.Minute, PoolStatsIntervalFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
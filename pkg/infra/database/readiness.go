@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+const defaultMigrationsDir = "./migrate"
+
+const (
+	IDTypeSerial = "serial"
+	IDTypeUUID   = "uuid"
+)
+
+const (
+	healthCheckPing  = "ping"
+	healthCheckQuery = "query"
+)
+
+const healthCheckSQL = "SELECT 1 FROM feiras LIMIT 1"
+
+const readinessTimeout = 2 * time.Second
+
+type readinessChecker struct {
+	logger        interfaces.ILogger
+	db            *sql.DB
+	migrationsDir string
+	healthCheck   string
+}
+
+// Ready fails while the database is unreachable or a migration of MIGRATIONS_DIR is not applied. The whole
+// check is bounded by readinessTimeout so a hung connection fails the probe instead of stalling it.
+func (pst readinessChecker) Ready(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if err := pst.reachable(ctx); err != nil {
+		pst.logger.Error(fmt.Sprintf("[Database::Ready] - database unreachable: %s", err.Error()))
+		return errors.NewInternalError(fmt.Sprintf("database unreachable: %s", err.Error()))
+	}
+
+	pending, err := PendingMigrations(ctx, pst.db, pst.migrationsDir)
+	if err != nil {
+		pst.logger.Error(fmt.Sprintf("[Database::Ready] - checking the migrations failure: %s", err.Error()))
+		return errors.NewInternalError("unable to check the migrations")
+	}
+
+	if len(pending) > 0 {
+		pst.logger.Warn(fmt.Sprintf("[Database::Ready] - pending migrations: %s", strings.Join(pending, ", ")))
+		return errors.NewInternalError(fmt.Sprintf("pending migrations: %s", strings.Join(pending, ", ")))
+	}
+
+	return nil
+}
+
+// reachable pings the database, or with DB_HEALTH_CHECK=query reads feiras so a missing table or
+// revoked grant also fails the check. An empty table is still healthy.
+func (pst readinessChecker) reachable(ctx context.Context) error {
+	if pst.healthCheck != healthCheckQuery {
+		return pst.db.PingContext(ctx)
+	}
+
+	var one int
+	if err := pst.db.QueryRowContext(ctx, healthCheckSQL).Scan(&one); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	return nil
+}
+
+func healthCheckFromEnv(logger interfaces.ILogger) string {
+	switch mode := os.Getenv("DB_HEALTH_CHECK"); mode {
+	case "", healthCheckPing:
+		return healthCheckPing
+	case healthCheckQuery:
+		return healthCheckQuery
+	default:
+		logger.Warn(fmt.Sprintf("[Database] - invalid DB_HEALTH_CHECK: %s, using ping", mode))
+		return healthCheckPing
+	}
+}
+
+// MigrationsDir reads MIGRATIONS_DIR. With DB_ID_TYPE=uuid it points to its uuid subdirectory, whose migrations
+// create feiras with UUID primary keys, an option meant for new deployments.
+func MigrationsDir() string {
+	dir := defaultMigrationsDir
+	if env := os.Getenv("MIGRATIONS_DIR"); env != "" {
+		dir = env
+	}
+
+	if os.Getenv("DB_ID_TYPE") == IDTypeUUID {
+		return filepath.Join(dir, IDTypeUUID)
+	}
+
+	return dir
+}
+
+func NewReadinessChecker(logger interfaces.ILogger, db *sql.DB) interfaces.IReadinessChecker {
+	return readinessChecker{logger, db, MigrationsDir(), healthCheckFromEnv(logger)}
+}
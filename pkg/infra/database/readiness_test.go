@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_PendingMigrations(t *testing.T) {
+	t.Run("should list the up migrations not applied", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(sut.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql"))
+
+		pending, err := PendingMigrations(context.Background(), sut.db, sut.dir)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"registro_unique_up.sql"}, pending)
+	})
+
+	t.Run("should return err when the migrations dir does not exist", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+
+		_, err := PendingMigrations(context.Background(), sut.db, filepath.Join(sut.dir, "missing"))
+
+		assert.Error(t, err)
+	})
+}
+
+func Test_ReadinessChecker_Ready(t *testing.T) {
+	t.Run("should be ready when every migration is applied", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.sqlMock.ExpectPing()
+		sut.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(
+			sut.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql").AddRow("registro_unique_up.sql"),
+		)
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not be ready while a migration is pending", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.sqlMock.ExpectPing()
+		sut.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(sut.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql"))
+		sut.logger.On("Warn", "[Database::Ready] - pending migrations: registro_unique_up.sql", []zapcore.Field(nil))
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.EqualError(t, err, "pending migrations: registro_unique_up.sql")
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not be ready when the database is unreachable", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.sqlMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		sut.logger.On("Error", "[Database::Ready] - database unreachable: connection refused", []zapcore.Field(nil))
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.EqualError(t, err, "database unreachable: connection refused")
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not be ready when the migrations table can not be read", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.sqlMock.ExpectPing()
+		sut.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnError(errors.New("relation \"migrations\" does not exist"))
+		sut.logger.On("Error", "[Database::Ready] - checking the migrations failure: relation \"migrations\" does not exist", []zapcore.Field(nil))
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should check the database with a query when configured", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.checker.healthCheck = healthCheckQuery
+		sut.sqlMock.ExpectQuery("SELECT 1 FROM feiras LIMIT 1").WillReturnRows(sut.sqlMock.NewRows([]string{"?column?"}).AddRow(1))
+		sut.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(
+			sut.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql").AddRow("registro_unique_up.sql"),
+		)
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should be ready with the query check when feiras is empty", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.checker.healthCheck = healthCheckQuery
+		sut.sqlMock.ExpectQuery("SELECT 1 FROM feiras LIMIT 1").WillReturnRows(sut.sqlMock.NewRows([]string{"?column?"}))
+		sut.sqlMock.ExpectQuery("SELECT name FROM migrations").WillReturnRows(
+			sut.sqlMock.NewRows([]string{"name"}).AddRow("feiras_up.sql").AddRow("registro_unique_up.sql"),
+		)
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not be ready when the query check fails", func(t *testing.T) {
+		sut := makeReadinessSut(t)
+		sut.checker.healthCheck = healthCheckQuery
+		sut.sqlMock.ExpectQuery("SELECT 1 FROM feiras LIMIT 1").WillReturnError(errors.New("permission denied for table feiras"))
+		sut.logger.On("Error", "[Database::Ready] - database unreachable: permission denied for table feiras", []zapcore.Field(nil))
+
+		err := sut.checker.Ready(context.Background())
+
+		assert.EqualError(t, err, "database unreachable: permission denied for table feiras")
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should read the health check mode from env", func(t *testing.T) {
+		os.Setenv("DB_HEALTH_CHECK", "query")
+		defer os.Unsetenv("DB_HEALTH_CHECK")
+
+		checker := NewReadinessChecker(logger.NewLoggerSpy(), nil)
+
+		assert.Equal(t, healthCheckQuery, checker.(readinessChecker).healthCheck)
+	})
+
+	t.Run("should fall back to ping when the health check mode is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_HEALTH_CHECK", "select")
+		defer os.Unsetenv("DB_HEALTH_CHECK")
+		log.On("Warn", "[Database] - invalid DB_HEALTH_CHECK: select, using ping", []zapcore.Field(nil))
+
+		checker := NewReadinessChecker(log, nil)
+
+		assert.Equal(t, healthCheckPing, checker.(readinessChecker).healthCheck)
+		log.AssertExpectations(t)
+	})
+
+	t.Run("should read the migrations dir from env", func(t *testing.T) {
+		os.Setenv("MIGRATIONS_DIR", "/migrations")
+		defer os.Unsetenv("MIGRATIONS_DIR")
+
+		checker := NewReadinessChecker(logger.NewLoggerSpy(), nil)
+
+		assert.Equal(t, "/migrations", checker.(readinessChecker).migrationsDir)
+	})
+
+	t.Run("should use the uuid migrations when DB_ID_TYPE is uuid", func(t *testing.T) {
+		os.Setenv("MIGRATIONS_DIR", "/migrations")
+		os.Setenv("DB_ID_TYPE", "uuid")
+		defer os.Unsetenv("MIGRATIONS_DIR")
+		defer os.Unsetenv("DB_ID_TYPE")
+
+		assert.Equal(t, "/migrations/uuid", MigrationsDir())
+	})
+
+	t.Run("should keep the serial migrations by default", func(t *testing.T) {
+		os.Setenv("DB_ID_TYPE", "serial")
+		defer os.Unsetenv("DB_ID_TYPE")
+
+		assert.Equal(t, "./migrate", MigrationsDir())
+	})
+}
+
+type readinessSutRtn struct {
+	logger  *logger.LoggerSpy
+	db      *sql.DB
+	sqlMock sqlmock.Sqlmock
+	dir     string
+	checker readinessChecker
+}
+
+func makeReadinessSut(t *testing.T) readinessSutRtn {
+	dir := t.TempDir()
+	for _, name := range []string{"feiras_up.sql", "feiras_down.sql", "registro_unique_up.sql", "registro_unique_down.sql"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(""), 0644)
+	}
+
+	db, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	logger := logger.NewLoggerSpy()
+
+	return readinessSutRtn{logger, db, sqlMock, dir, readinessChecker{logger, db, dir, healthCheckPing}}
+}
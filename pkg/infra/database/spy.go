@@ -0,0 +1,21 @@
+package database
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ReadinessCheckerSpy struct {
+	mock.Mock
+}
+
+func (pst ReadinessCheckerSpy) Ready(ctx context.Context) error {
+	args := pst.Called(ctx)
+
+	return args.Error(0)
+}
+
+func NewReadinessCheckerSpy() *ReadinessCheckerSpy {
+	return new(ReadinessCheckerSpy)
+}
@@ -0,0 +1,19 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_ReadinessCheckerSpy_Ready(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewReadinessCheckerSpy()
+
+		ctx := context.Background()
+		sut.On("Ready", ctx).Return(nil)
+
+		sut.Ready(ctx)
+
+		sut.AssertExpectations(t)
+	})
+}
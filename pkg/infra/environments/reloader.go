@@ -0,0 +1,94 @@
+package environments
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+// restartOnlyKeys make up the DSN, read once when the database connects.
+var restartOnlyKeys = []string{"DB_DRIVER", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME"}
+
+// Reloadable is a config value applied without a restart. Validate may be nil when every value is accepted.
+type Reloadable struct {
+	Key      string
+	Validate func(raw string) error
+	Apply    func(raw string)
+}
+
+type configReloader struct {
+	mu          *sync.Mutex
+	logger      interfaces.ILogger
+	env         interfaces.IEnvironments
+	reloadables []Reloadable
+}
+
+// Reload re-reads the env file and applies the reloadables atomically: when any of them is invalid the
+// previous values are restored and nothing is applied. Changed restart-only keys keep their running value.
+func (pst configReloader) Reload() (interfaces.ReloadResult, error) {
+	pst.mu.Lock()
+	defer pst.mu.Unlock()
+
+	previous := pst.snapshot()
+	if err := pst.env.Configure(); err != nil {
+		restore(previous)
+		pst.logger.Error(fmt.Sprintf("[ConfigReloader::Reload] - %s", err.Error()))
+		return interfaces.ReloadResult{}, err
+	}
+
+	for _, reloadable := range pst.reloadables {
+		if reloadable.Validate == nil {
+			continue
+		}
+
+		if err := reloadable.Validate(os.Getenv(reloadable.Key)); err != nil {
+			restore(previous)
+			pst.logger.Warn(fmt.Sprintf("[ConfigReloader::Reload] - invalid %s, nothing applied: %s", reloadable.Key, err.Error()))
+			return interfaces.ReloadResult{}, errors.NewValidationError(fmt.Sprintf("invalid %s: %s", reloadable.Key, err.Error()))
+		}
+	}
+
+	result := interfaces.ReloadResult{Applied: map[string]string{}, Ignored: []string{}}
+	for _, key := range restartOnlyKeys {
+		if os.Getenv(key) != previous[key] {
+			pst.logger.Warn(fmt.Sprintf("[ConfigReloader::Reload] - %s changed, ignored until the next restart", key))
+			result.Ignored = append(result.Ignored, key)
+			os.Setenv(key, previous[key])
+		}
+	}
+
+	for _, reloadable := range pst.reloadables {
+		raw := os.Getenv(reloadable.Key)
+		reloadable.Apply(raw)
+		result.Applied[reloadable.Key] = raw
+	}
+
+	pst.logger.Info(fmt.Sprintf("[ConfigReloader::Reload] - %d applied, %d ignored", len(result.Applied), len(result.Ignored)))
+
+	return result, nil
+}
+
+func (pst configReloader) snapshot() map[string]string {
+	values := map[string]string{}
+	for _, key := range restartOnlyKeys {
+		values[key] = os.Getenv(key)
+	}
+	for _, reloadable := range pst.reloadables {
+		values[reloadable.Key] = os.Getenv(reloadable.Key)
+	}
+
+	return values
+}
+
+func restore(values map[string]string) {
+	for key, value := range values {
+		os.Setenv(key, value)
+	}
+}
+
+func NewConfigReloader(logger interfaces.ILogger, env interfaces.IEnvironments, reloadables ...Reloadable) interfaces.IConfigReloader {
+	return configReloader{&sync.Mutex{}, logger, env, reloadables}
+}
@@ -0,0 +1,123 @@
+package environments
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	appErrors "github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/ralvescosta/dotenv"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_ConfigReloader(t *testing.T) {
+	t.Run("should change the log level after reload", func(t *testing.T) {
+		sut := makeConfigReloaderSut(t, map[string]string{"LOG_LEVEL": "warn"})
+		sut.logger.On("Info", "[ConfigReloader::Reload] - 2 applied, 0 ignored", []zap.Field(nil))
+
+		result, err := sut.reloader.Reload()
+
+		assert.NoError(t, err)
+		assert.Equal(t, interfaces.ReloadResult{Applied: map[string]string{"LOG_LEVEL": "warn", "LATENCY_BUDGETS": ""}, Ignored: []string{}}, result)
+		assert.Equal(t, "warn", logger.Level())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should apply the latency budgets read from the env file", func(t *testing.T) {
+		sut := makeConfigReloaderSut(t, nil)
+		file := filepath.Join(t.TempDir(), ".env.development")
+		ioutil.WriteFile(file, []byte("LOG_LEVEL = warn\nLATENCY_BUDGETS = GET /api/v1/markets/:registerCode:100ms,POST /api/v1/markets:1s\n"), 0o600)
+		dotEnvConfig = func(path string) error { return dotenv.Configure(file) }
+		sut.logger.On("Info", "[ConfigReloader::Reload] - 2 applied, 0 ignored", []zap.Field(nil))
+
+		result, err := sut.reloader.Reload()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "GET /api/v1/markets/:registerCode:100ms,POST /api/v1/markets:1s", result.Applied["LATENCY_BUDGETS"])
+		assert.Equal(t, []string{"GET /api/v1/markets/:registerCode:100ms,POST /api/v1/markets:1s"}, *sut.budgets)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should ignore a changed DSN and keep the running one", func(t *testing.T) {
+		sut := makeConfigReloaderSut(t, map[string]string{"LOG_LEVEL": "error", "DB_HOST": "replica", "DB_PASSWORD": "other"})
+		sut.logger.On("Warn", "[ConfigReloader::Reload] - DB_HOST changed, ignored until the next restart", []zap.Field(nil))
+		sut.logger.On("Warn", "[ConfigReloader::Reload] - DB_PASSWORD changed, ignored until the next restart", []zap.Field(nil))
+		sut.logger.On("Info", "[ConfigReloader::Reload] - 2 applied, 2 ignored", []zap.Field(nil))
+
+		result, err := sut.reloader.Reload()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"DB_HOST", "DB_PASSWORD"}, result.Ignored)
+		assert.Equal(t, "error", logger.Level())
+		assert.Equal(t, "localhost", os.Getenv("DB_HOST"))
+		assert.Equal(t, "postgres", os.Getenv("DB_PASSWORD"))
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should apply nothing when a value is invalid", func(t *testing.T) {
+		sut := makeConfigReloaderSut(t, map[string]string{"LOG_LEVEL": "verbose", "LATENCY_BUDGETS": "GET /api/v1/markets:100ms"})
+		sut.logger.On("Warn", `[ConfigReloader::Reload] - invalid LOG_LEVEL, nothing applied: unknown log level "verbose"`, []zap.Field(nil))
+
+		_, err := sut.reloader.Reload()
+
+		assert.Equal(t, appErrors.NewValidationError(`invalid LOG_LEVEL: unknown log level "verbose"`), err)
+		assert.Equal(t, "debug", logger.Level())
+		assert.Equal(t, "debug", os.Getenv("LOG_LEVEL"))
+		assert.Empty(t, *sut.budgets)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return the error when the env file can not be read", func(t *testing.T) {
+		sut := makeConfigReloaderSut(t, nil)
+		dotEnvConfig = func(path string) error { return errors.New("error while opening env file") }
+		sut.logger.On("Error", "[ConfigReloader::Reload] - error while opening env file", []zap.Field(nil))
+
+		_, err := sut.reloader.Reload()
+
+		assert.Error(t, err)
+		assert.Equal(t, "debug", logger.Level())
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+type configReloaderSutRtn struct {
+	logger   *logger.LoggerSpy
+	reloader interfaces.IConfigReloader
+	budgets  *[]string
+}
+
+// makeConfigReloaderSut starts at LOG_LEVEL=debug with a local DSN, and the env file sets file.
+func makeConfigReloaderSut(t *testing.T, file map[string]string) configReloaderSutRtn {
+	running := map[string]string{"LOG_LEVEL": "debug", "LATENCY_BUDGETS": "", "DB_HOST": "localhost", "DB_PASSWORD": "postgres"}
+	for key, value := range running {
+		os.Setenv(key, value)
+	}
+	logger.SetLevel("debug")
+
+	dotEnvConfig = func(path string) error {
+		for key, value := range file {
+			os.Setenv(key, value)
+		}
+		return nil
+	}
+	t.Cleanup(func() {
+		for key := range running {
+			os.Unsetenv(key)
+		}
+	})
+
+	log := logger.NewLoggerSpy()
+	budgets := []string{}
+	reloader := NewConfigReloader(log, NewEnvironment(),
+		Reloadable{Key: "LOG_LEVEL", Validate: logger.ValidateLevel, Apply: logger.SetLevel},
+		Reloadable{Key: "LATENCY_BUDGETS", Apply: func(raw string) { budgets = append(budgets, raw) }},
+	)
+
+	return configReloaderSutRtn{log, reloader, &budgets}
+}
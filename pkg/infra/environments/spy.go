@@ -1,6 +1,10 @@
 package environments
 
-import "github.com/stretchr/testify/mock"
+import (
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type EnvironmentsSpy struct {
 	mock.Mock
@@ -35,3 +39,17 @@ func (pst EnvironmentsSpy) PROFILING_ENV() string {
 func NewEnvironmentsSpy() *EnvironmentsSpy {
 	return new(EnvironmentsSpy)
 }
+
+type ConfigReloaderSpy struct {
+	mock.Mock
+}
+
+func (pst ConfigReloaderSpy) Reload() (interfaces.ReloadResult, error) {
+	args := pst.Called()
+
+	return args.Get(0).(interfaces.ReloadResult), args.Error(1)
+}
+
+func NewConfigReloaderSpy() *ConfigReloaderSpy {
+	return new(ConfigReloaderSpy)
+}
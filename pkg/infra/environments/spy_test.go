@@ -3,6 +3,8 @@ package environments
 import (
 	"testing"
 
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,3 +79,16 @@ func Test_PROFILING_ENVSpy(t *testing.T) {
 		assert.Equal(t, "enabled", result)
 	})
 }
+
+func Test_ConfigReloaderSpy(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewConfigReloaderSpy()
+
+		sut.On("Reload").Return(interfaces.ReloadResult{Applied: map[string]string{"LOG_LEVEL": "warn"}}, nil)
+
+		result, err := sut.Reload()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "warn", result.Applied["LOG_LEVEL"])
+	})
+}
@@ -0,0 +1,23 @@
+package geocoder
+
+import (
+	"context"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type GeocoderSpy struct {
+	mock.Mock
+}
+
+func (pst GeocoderSpy) Geocode(ctx context.Context, address string) (valueObjects.Coordinate, error) {
+	args := pst.Called(ctx, address)
+
+	return args.Get(0).(valueObjects.Coordinate), args.Error(1)
+}
+
+func NewGeocoderSpy() *GeocoderSpy {
+	return new(GeocoderSpy)
+}
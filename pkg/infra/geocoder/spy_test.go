@@ -0,0 +1,26 @@
+package geocoder
+
+import (
+	"context"
+	"testing"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GeocoderSpy_Geocode(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewGeocoderSpy()
+
+		ctx := context.Background()
+
+		sut.On("Geocode", ctx, "Praca da Se").Return(valueObjects.Coordinate{Long: -46634000, Lat: -23550000}, nil)
+
+		result, err := sut.Geocode(ctx, "Praca da Se")
+
+		assert.NoError(t, err)
+		assert.Equal(t, valueObjects.Coordinate{Long: -46634000, Lat: -23550000}, result)
+		sut.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,78 @@
+package httpServer
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ralvescosta/base/pkg/infra/logger"
+)
+
+type bodyCapture struct {
+	enabled bool
+	masked  map[string]bool
+}
+
+// bodyCaptureFromEnv enables the debug capture of request bodies on failed requests when LOG_FAILED_REQUEST_BODY
+// is true. The JSON keys of logger.MaskedFields are masked in every body logged, the access log included, and
+// the headers with those names are left out of it.
+func bodyCaptureFromEnv() bodyCapture {
+	return bodyCapture{
+		enabled: os.Getenv("LOG_FAILED_REQUEST_BODY") == "true",
+		masked:  logger.MaskedFields(),
+	}
+}
+
+func (pst bodyCapture) shouldCapture(statusCode int) bool {
+	return pst.enabled && statusCode >= 400
+}
+
+func (pst bodyCapture) redact(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "[non-json body omitted]"
+	}
+
+	redacted, _ := json.Marshal(pst.mask(payload))
+
+	return string(redacted)
+}
+
+func (pst bodyCapture) mask(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if pst.masked[strings.ToLower(key)] {
+				v[key] = logger.MaskedValue
+				continue
+			}
+			v[key] = pst.mask(nested)
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = pst.mask(nested)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// headers leaves out Authorization, which carries the ADMIN_TOKEN, and the headers named in LOG_MASKED_FIELDS.
+func (pst bodyCapture) headers(header http.Header) http.Header {
+	logged := make(http.Header, len(header))
+	for key, values := range header {
+		if strings.EqualFold(key, "Authorization") || pst.masked[strings.ToLower(key)] {
+			continue
+		}
+		logged[key] = values
+	}
+
+	return logged
+}
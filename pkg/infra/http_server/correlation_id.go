@@ -0,0 +1,33 @@
+package httpServer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CorrelationID keeps the X-Correlation-Id sent by the caller, or generates one, and stores it in the request
+// context so the loggers scoped with WithContext tag every line of the request with it.
+func CorrelationID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(logger.CorrelationIDHeader)
+		if id == "" {
+			id = newCorrelationID()
+		}
+
+		ctx.Request = ctx.Request.WithContext(logger.ContextWithCorrelationID(ctx.Request.Context(), id))
+		ctx.Header(logger.CorrelationIDHeader, id)
+
+		ctx.Next()
+	}
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,49 @@
+package httpServer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CorrelationID(t *testing.T) {
+	t.Run("should keep the correlation id sent by the caller", func(t *testing.T) {
+		w, seen := runCorrelationID("abc")
+
+		assert.Equal(t, "abc", seen)
+		assert.Equal(t, "abc", w.Header().Get(logger.CorrelationIDHeader))
+	})
+
+	t.Run("should generate a correlation id when none is sent", func(t *testing.T) {
+		w, seen := runCorrelationID("")
+
+		assert.Len(t, seen, 32)
+		assert.Equal(t, seen, w.Header().Get(logger.CorrelationIDHeader))
+	})
+}
+
+func runCorrelationID(header string) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CorrelationID())
+
+	seen := ""
+	router.GET("/", func(ctx *gin.Context) {
+		seen = logger.CorrelationID(ctx.Request.Context())
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(logger.CorrelationIDHeader, header)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w, seen
+}
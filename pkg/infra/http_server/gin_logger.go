@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ralvescosta/base/pkg/app/interfaces"
@@ -26,16 +28,24 @@ func (r responseBodyWriter) Write(b []byte) (int, error) {
 
 var now = time.Now
 
+// budgets holds the LATENCY_BUDGETS in use, swapped by ReloadLatencyBudgets while requests are served.
+var budgets atomic.Value
+
 func GinLogger(logger interfaces.ILogger) gin.HandlerFunc {
+	ReloadLatencyBudgets(logger)
+	capture := bodyCaptureFromEnv()
+
 	return func(ctx *gin.Context) {
 		w := &responseBodyWriter{body: &bytes.Buffer{}, ResponseWriter: ctx.Writer}
 		ctx.Writer = w
+		logger := logger.WithContext(ctx.Request.Context())
 
 		startTime := now()
 		ctx.Next()
 		endTime := now()
 
-		latencyTimeInMileseconds := float64(endTime.Sub(startTime).Nanoseconds() / 1000)
+		latency := endTime.Sub(startTime)
+		latencyTimeInMileseconds := float64(latency.Nanoseconds() / 1000)
 
 		requestBody, _ := ioutil.ReadAll(ctx.Request.Body)
 		responseBody, _ := ioutil.ReadAll(w.body)
@@ -51,6 +61,11 @@ func GinLogger(logger interfaces.ILogger) gin.HandlerFunc {
 				Type:   zapcore.StringType,
 				String: ctx.Request.RequestURI,
 			},
+			zapcore.Field{
+				Key:    "clientIP",
+				Type:   zapcore.StringType,
+				String: ctx.ClientIP(),
+			},
 			zapcore.Field{
 				Key:     "statusCode",
 				Type:    zapcore.Int64Type,
@@ -64,20 +79,82 @@ func GinLogger(logger interfaces.ILogger) gin.HandlerFunc {
 			zapcore.Field{
 				Key:    "headers",
 				Type:   zapcore.StringType,
-				String: headerToString(ctx.Request.Header),
+				String: headerToString(capture.headers(ctx.Request.Header)),
 			},
 			zapcore.Field{
 				Key:    "request",
 				Type:   zapcore.StringType,
-				String: string(requestBody),
+				String: capture.redact(requestBody),
 			},
 			zapcore.Field{
 				Key:    "response",
 				Type:   zapcore.StringType,
-				String: string(responseBody),
+				String: capture.redact(responseBody),
 			},
 		)
+
+		if capture.shouldCapture(ctx.Writer.Status()) {
+			logger.Debug("[HTTP Request] - failed request body",
+				zapcore.Field{
+					Key:    "method",
+					Type:   zapcore.StringType,
+					String: ctx.Request.Method,
+				},
+				zapcore.Field{
+					Key:    "uri",
+					Type:   zapcore.StringType,
+					String: ctx.Request.RequestURI,
+				},
+				zapcore.Field{
+					Key:     "statusCode",
+					Type:    zapcore.Int64Type,
+					Integer: int64(ctx.Writer.Status()),
+				},
+				zapcore.Field{
+					Key:    "body",
+					Type:   zapcore.StringType,
+					String: capture.redact(requestBody),
+				},
+			)
+		}
+
+		route := ctx.Request.Method + " " + ctx.FullPath()
+		if budget, ok := budgets.Load().(map[string]time.Duration)[route]; ok && latency > budget {
+			logger.Warn(fmt.Sprintf("[HTTP Request] - %s exceeded its %s latency budget: %s", route, budget, latency))
+		}
+	}
+}
+
+func ReloadLatencyBudgets(logger interfaces.ILogger) {
+	budgets.Store(latencyBudgetsFromEnv(logger))
+}
+
+// latencyBudgetsFromEnv reads LATENCY_BUDGETS, a comma separated list of "METHOD /route:duration"
+// entries where the route is the registered one, e.g. "GET /api/v1/markets/:registerCode:300ms". The
+// separator is the last colon since the env file loader cuts the values at "=".
+func latencyBudgetsFromEnv(logger interfaces.ILogger) map[string]time.Duration {
+	budgets := map[string]time.Duration{}
+	for _, entry := range strings.Split(os.Getenv("LATENCY_BUDGETS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		separator := strings.LastIndex(entry, ":")
+		if separator < 0 {
+			logger.Warn(fmt.Sprintf("[HttpServer::GinLogger] - invalid LATENCY_BUDGETS entry: %s", entry))
+			continue
+		}
+
+		budget, err := time.ParseDuration(strings.TrimSpace(entry[separator+1:]))
+		if err != nil || budget <= 0 {
+			logger.Warn(fmt.Sprintf("[HttpServer::GinLogger] - invalid LATENCY_BUDGETS entry: %s", entry))
+			continue
+		}
+
+		budgets[strings.Join(strings.Fields(entry[:separator]), " ")] = budget
 	}
+
+	return budgets
 }
 
 func headerToString(header http.Header) string {
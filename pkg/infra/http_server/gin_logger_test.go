@@ -7,13 +7,18 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ralvescosta/base/pkg/infra/logger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ralvescosta/dotenv"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -36,6 +41,11 @@ func Test_GinLogger(t *testing.T) {
 					Type:   zapcore.StringType,
 					String: sut.ginCtx.Request.RequestURI,
 				},
+				{
+					Key:    "clientIP",
+					Type:   zapcore.StringType,
+					String: "",
+				},
 				{
 					Key:     "statusCode",
 					Type:    zapcore.Int64Type,
@@ -80,6 +90,230 @@ func Test_GinLogger(t *testing.T) {
 	})
 }
 
+func Test_GinLogger_LatencyBudget(t *testing.T) {
+	t.Run("should warn when the route exceeds its budget", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LATENCY_BUDGETS", "GET /markets/:id:200ms, POST /markets:1s")
+		defer os.Unsetenv("LATENCY_BUDGETS")
+		sut.elapse(350 * time.Millisecond)
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+		sut.logger.On("Warn", "[HTTP Request] - GET /markets/:id exceeded its 200ms latency budget: 350ms", []zap.Field(nil))
+
+		sut.serve(GinLogger(sut.logger), "GET", "/markets/:id", "/markets/10")
+
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not warn when the route is within its budget", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LATENCY_BUDGETS", "GET /markets/:id:200ms")
+		defer os.Unsetenv("LATENCY_BUDGETS")
+		sut.elapse(150 * time.Millisecond)
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		sut.serve(GinLogger(sut.logger), "GET", "/markets/:id", "/markets/10")
+
+		sut.logger.AssertNotCalled(t, "Warn", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should not warn for a route without budget", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LATENCY_BUDGETS", "GET /markets/:id:200ms")
+		defer os.Unsetenv("LATENCY_BUDGETS")
+		sut.elapse(time.Second)
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		sut.serve(GinLogger(sut.logger), "DELETE", "/markets/:id", "/markets/10")
+
+		sut.logger.AssertNotCalled(t, "Warn", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should apply the reloaded budgets to a running handler", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LATENCY_BUDGETS", "GET /markets/:id:500ms")
+		defer os.Unsetenv("LATENCY_BUDGETS")
+		handler := GinLogger(sut.logger)
+		os.Setenv("LATENCY_BUDGETS", "GET /markets/:id:100ms")
+		ReloadLatencyBudgets(sut.logger)
+		sut.elapse(350 * time.Millisecond)
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+		sut.logger.On("Warn", "[HTTP Request] - GET /markets/:id exceeded its 100ms latency budget: 350ms", []zap.Field(nil))
+
+		sut.serve(handler, "GET", "/markets/:id", "/markets/10")
+
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should read the budgets written in an env file", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		file := filepath.Join(t.TempDir(), ".env.test")
+		ioutil.WriteFile(file, []byte("LATENCY_BUDGETS = GET /markets/:id:200ms,POST /markets:1s\n"), 0o600)
+		defer os.Unsetenv("LATENCY_BUDGETS")
+
+		assert.NoError(t, dotenv.Configure(file))
+		budgets := latencyBudgetsFromEnv(sut.logger)
+
+		assert.Equal(t, map[string]time.Duration{"GET /markets/:id": 200 * time.Millisecond, "POST /markets": time.Second}, budgets)
+	})
+
+	t.Run("should warn and skip invalid budgets", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LATENCY_BUDGETS", "GET /markets,GET /markets/:id:wrong,GET /markets/:id/x:-1s")
+		defer os.Unsetenv("LATENCY_BUDGETS")
+		sut.logger.On("Warn", "[HttpServer::GinLogger] - invalid LATENCY_BUDGETS entry: GET /markets", []zap.Field(nil))
+		sut.logger.On("Warn", "[HttpServer::GinLogger] - invalid LATENCY_BUDGETS entry: GET /markets/:id:wrong", []zap.Field(nil))
+		sut.logger.On("Warn", "[HttpServer::GinLogger] - invalid LATENCY_BUDGETS entry: GET /markets/:id/x:-1s", []zap.Field(nil))
+
+		budgets := latencyBudgetsFromEnv(sut.logger)
+
+		assert.Empty(t, budgets)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_GinLogger_FailedRequestBody(t *testing.T) {
+	body := `{"nome":"FEIRA","password":"123","nested":[{"Token":"abc"}]}`
+
+	t.Run("should log the redacted body at debug level on client errors", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LOG_FAILED_REQUEST_BODY", "true")
+		defer os.Unsetenv("LOG_FAILED_REQUEST_BODY")
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+		sut.logger.On("Debug", "[HTTP Request] - failed request body", []zap.Field{
+			{Key: "method", Type: zapcore.StringType, String: "POST"},
+			{Key: "uri", Type: zapcore.StringType, String: "/markets"},
+			{Key: "statusCode", Type: zapcore.Int64Type, Integer: http.StatusBadRequest},
+			{Key: "body", Type: zapcore.StringType, String: `{"nested":[{"Token":"***"}],"nome":"FEIRA","password":"***"}`},
+		})
+
+		sut.respond(GinLogger(sut.logger), http.StatusBadRequest, body)
+
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should log the redacted body on server errors", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LOG_FAILED_REQUEST_BODY", "true")
+		os.Setenv("LOG_MASKED_FIELDS", "nome")
+		defer os.Unsetenv("LOG_FAILED_REQUEST_BODY")
+		defer os.Unsetenv("LOG_MASKED_FIELDS")
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+		sut.logger.On("Debug", "[HTTP Request] - failed request body", mock.MatchedBy(func(fields []zap.Field) bool {
+			return fields[3].String == `{"nested":[{"Token":"abc"}],"nome":"***","password":"123"}`
+		}))
+
+		sut.respond(GinLogger(sut.logger), http.StatusInternalServerError, body)
+
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not log the body on successful requests", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		os.Setenv("LOG_FAILED_REQUEST_BODY", "true")
+		defer os.Unsetenv("LOG_FAILED_REQUEST_BODY")
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		sut.respond(GinLogger(sut.logger), http.StatusOK, body)
+
+		sut.logger.AssertNotCalled(t, "Debug", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should not log the body when the capture is disabled", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		sut.respond(GinLogger(sut.logger), http.StatusInternalServerError, body)
+
+		sut.logger.AssertNotCalled(t, "Debug", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should redact the request and response bodies of the access log", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		var request, response string
+		sut.logger.On("Info", "[HTTP Request]", mock.MatchedBy(func(fields []zap.Field) bool {
+			request, response = fields[6].String, fields[7].String
+			return true
+		}))
+
+		router := gin.New()
+		router.Use(GinLogger(sut.logger))
+		router.POST("/markets", func(ctx *gin.Context) {
+			ctx.Data(http.StatusCreated, "application/json", []byte(`{"token":"abc","id":1}`))
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/markets", strings.NewReader(body)))
+
+		assert.Equal(t, `{"nested":[{"Token":"***"}],"nome":"FEIRA","password":"***"}`, request)
+		assert.Equal(t, `{"id":1,"token":"***"}`, response)
+	})
+
+	t.Run("should omit bodies that are not json", func(t *testing.T) {
+		capture := bodyCapture{enabled: true}
+
+		assert.Equal(t, "[non-json body omitted]", capture.redact([]byte("password=123")))
+		assert.Equal(t, "", capture.redact(nil))
+	})
+}
+
+func Test_GinLogger_MaskedFields(t *testing.T) {
+	t.Run("should keep the masked values out of every field of the access log", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		var fields []zap.Field
+		sut.logger.On("Info", "[HTTP Request]", mock.MatchedBy(func(f []zap.Field) bool {
+			fields = f
+			return true
+		}))
+
+		router := gin.New()
+		router.Use(GinLogger(sut.logger))
+		router.POST("/markets", func(ctx *gin.Context) {
+			ctx.Data(http.StatusCreated, "application/json", []byte(`{"registro":"4041-0","token":"response-token"}`))
+		})
+		req := httptest.NewRequest("POST", "/markets", strings.NewReader(`{"registro":"4041-0","password":"request-password"}`))
+		req.Header.Set("Authorization", "Bearer admin-token")
+		req.Header.Set("Secret", "header-secret")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.NotEmpty(t, fields)
+		for _, field := range fields {
+			for _, value := range []string{"admin-token", "header-secret", "request-password", "response-token"} {
+				assert.NotContains(t, field.String, value, field.Key)
+			}
+		}
+	})
+}
+
+func Test_GinLogger_Headers(t *testing.T) {
+	t.Run("should leave the admin token out of the logged headers", func(t *testing.T) {
+		sut := makeGinLoggerSut()
+		var headers string
+		sut.logger.On("Info", "[HTTP Request]", mock.MatchedBy(func(fields []zap.Field) bool {
+			headers = fields[5].String
+			return true
+		}))
+
+		router := gin.New()
+		router.Use(GinLogger(sut.logger))
+		router.POST("/admin/reload", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+		req := httptest.NewRequest("POST", "/admin/reload", nil)
+		req.Header.Set("Authorization", "Bearer admin-token")
+		req.Header.Set("X-Request-Id", "abc")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.NotContains(t, headers, "admin-token")
+		assert.NotContains(t, headers, "Authorization")
+		assert.Contains(t, headers, "X-Request-Id:abc;")
+	})
+
+	t.Run("should leave out the headers named in LOG_MASKED_FIELDS", func(t *testing.T) {
+		os.Setenv("LOG_MASKED_FIELDS", "x-api-key")
+		defer os.Unsetenv("LOG_MASKED_FIELDS")
+
+		headers := bodyCaptureFromEnv().headers(http.Header{"X-Api-Key": {"key"}, "Authorization": {"Bearer token"}, "Accept": {"*/*"}})
+
+		assert.Equal(t, http.Header{"Accept": {"*/*"}}, headers)
+	})
+}
+
 type ginLoggerSutRtn struct {
 	logger      *logger.LoggerSpy
 	ginCtx      *gin.Context
@@ -107,3 +341,30 @@ func makeGinLoggerSut() ginLoggerSutRtn {
 
 	return ginLoggerSutRtn{logger, ginCtx, requestBody, t}
 }
+
+func (sut ginLoggerSutRtn) elapse(latency time.Duration) {
+	calls := 0
+	now = func() time.Time {
+		calls++
+		if calls%2 == 0 {
+			return sut.time.Add(latency)
+		}
+		return sut.time
+	}
+}
+
+func (sut ginLoggerSutRtn) serve(middleware gin.HandlerFunc, method, route, path string) {
+	router := gin.New()
+	router.Use(middleware)
+	router.Handle(method, route, func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+func (sut ginLoggerSutRtn) respond(middleware gin.HandlerFunc, status int, body string) {
+	router := gin.New()
+	router.Use(middleware)
+	router.POST("/markets", func(ctx *gin.Context) { ctx.Status(status) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/markets", strings.NewReader(body)))
+}
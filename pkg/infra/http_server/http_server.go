@@ -3,8 +3,13 @@ package httpServer
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/ralvescosta/base/pkg/app/errors"
@@ -23,21 +28,45 @@ type IHTTPServer interface {
 }
 
 type HTTPServer struct {
-	env      interfaces.IEnvironments
-	addr     string
-	logger   interfaces.ILogger
-	router   *gin.Engine
-	server   *http.Server
-	shotdown chan bool
+	env         interfaces.IEnvironments
+	addr        string
+	logger      interfaces.ILogger
+	router      *gin.Engine
+	server      *http.Server
+	shotdown    chan bool
+	signals     chan os.Signal
+	done        chan struct{}
+	connections *int64
 }
 
+const defaultShutdownGracePeriod = 10 * time.Second
+
 var httpServerWrapper = gin.New
 
 func (pst *HTTPServer) Default() {
 	pst.router = httpServerWrapper()
+	pst.router.Use(CorrelationID())
 	pst.router.Use(GinLogger(pst.logger))
 	pst.router.Use(apm.Middleware(pst.router)) //apm also carry about the recovery strategy
-	pst.router.SetTrustedProxies(nil)
+	pst.configureTrustedProxies()
+}
+
+// configureTrustedProxies only honors X-Forwarded-For/X-Real-IP when the request comes from one of
+// the CIDRs listed in TRUSTED_PROXIES (comma separated). Without it the remote address is always used.
+func (pst *HTTPServer) configureTrustedProxies() {
+	pst.router.RemoteIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+	var proxies []string
+	for _, cidr := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			proxies = append(proxies, cidr)
+		}
+	}
+
+	if err := pst.router.SetTrustedProxies(proxies); err != nil {
+		pst.logger.Error(fmt.Sprintf("[HttpServer::Default] - invalid TRUSTED_PROXIES: %s", err.Error()))
+		pst.router.SetTrustedProxies(nil)
+	}
 }
 
 func (hs HTTPServer) RegisterRoute(method string, path string, handlers ...gin.HandlerFunc) error {
@@ -63,14 +92,29 @@ func (pst *HTTPServer) Setup() {
 	port := os.Getenv("PORT")
 	pst.addr = fmt.Sprintf("%s:%s", host, port)
 
+	pst.connections = new(int64)
 	pst.server = &http.Server{
-		Addr:    pst.addr,
-		Handler: pst.router,
+		Addr:      pst.addr,
+		Handler:   pst.router,
+		ConnState: pst.trackConnections,
 	}
 
+	pst.done = make(chan struct{})
+	pst.signals = make(chan os.Signal, 1)
+	signal.Notify(pst.signals, syscall.SIGINT, syscall.SIGTERM)
+
 	go pst.gracefullShutdown()
 }
 
+func (pst HTTPServer) trackConnections(_ net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		atomic.AddInt64(pst.connections, 1)
+	case http.StateHijacked, http.StateClosed:
+		atomic.AddInt64(pst.connections, -1)
+	}
+}
+
 func (pst HTTPServer) Run() error {
 	if pst.env.PROFILING_ENV() == "enabled" {
 		pst.router.GET("/debug/vars", expvar.Handler())
@@ -83,25 +127,66 @@ func (pst HTTPServer) Run() error {
 		pst.logger.Info(fmt.Sprintf("[HttpServer::Run] - Server running at: https://%s", pst.addr))
 		err := pst.server.ListenAndServeTLS(certPath, keyPath)
 
-		return errors.NewInternalError(err.Error())
+		return pst.stopped(err)
 	}
 
 	pst.logger.Info(fmt.Sprintf("[HttpServer::Run] - Server running at: http://%s", pst.addr))
 	err := pst.server.ListenAndServe()
 
-	return errors.NewInternalError(err.Error())
+	return pst.stopped(err)
+}
+
+// stopped waits for the shutdown to drain the active requests when the server was closed by it, so the
+// caller only releases the database and the logger once no handler is using them.
+func (pst HTTPServer) stopped(err error) error {
+	if err != http.ErrServerClosed {
+		return errors.NewInternalError(err.Error())
+	}
+
+	<-pst.done
+
+	return nil
 }
 
+// gracefullShutdown runs on SIGINT, SIGTERM or when the database connection is lost. It stops accepting
+// connections and waits up to HTTP_SHUTDOWN_GRACE_PERIOD for the active requests to finish.
 func (pst HTTPServer) gracefullShutdown() {
-	<-pst.shotdown
+	defer close(pst.done)
+
+	select {
+	case <-pst.shotdown:
+	case sig := <-pst.signals:
+		pst.logger.Info(fmt.Sprintf("[HttpServer::GracefullShutdown] - %s received", sig))
+	}
+	signal.Stop(pst.signals)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriodFromEnv(pst.logger))
 	defer cancel()
 
+	open := atomic.LoadInt64(pst.connections)
 	if err := pst.server.Shutdown(ctx); err != nil {
-		pst.logger.Error("[HttpServer::GracefullShutdown] - could'ent shutdown properly")
+		pst.logger.Error(fmt.Sprintf("[HttpServer::GracefullShutdown] - could'ent shutdown properly, %d of %d connections still open: %s",
+			atomic.LoadInt64(pst.connections), open, err.Error()))
 		return
 	}
+
+	pst.logger.Info(fmt.Sprintf("[HttpServer::GracefullShutdown] - %d connections drained", open))
+}
+
+// shutdownGracePeriodFromEnv reads HTTP_SHUTDOWN_GRACE_PERIOD, how long the shutdown waits for the active requests.
+func shutdownGracePeriodFromEnv(logger interfaces.ILogger) time.Duration {
+	raw := os.Getenv("HTTP_SHUTDOWN_GRACE_PERIOD")
+	if raw == "" {
+		return defaultShutdownGracePeriod
+	}
+
+	period, err := time.ParseDuration(raw)
+	if err != nil || period <= 0 {
+		logger.Warn(fmt.Sprintf("[HttpServer] - invalid HTTP_SHUTDOWN_GRACE_PERIOD: %s", raw))
+		return defaultShutdownGracePeriod
+	}
+
+	return period
 }
 
 func NewHTTPServer(environments interfaces.IEnvironments, logger interfaces.ILogger, shotdown chan bool) IHTTPServer {
@@ -15,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -113,6 +114,58 @@ func Test_RegisterRoutes(t *testing.T) {
 	})
 }
 
+func Test_TrustedProxies(t *testing.T) {
+	t.Run("should use the forwarded client ip when the request comes from a trusted proxy", func(t *testing.T) {
+		sut := makeHTTPServerSutRtn("GET")
+		os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.0.1/32")
+		defer os.Unsetenv("TRUSTED_PROXIES")
+		sut.httpServer.Default()
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		clientIP := sut.clientIPFrom("10.0.0.5:4000", http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+
+		assert.Equal(t, "203.0.113.7", clientIP)
+	})
+
+	t.Run("should accept X-Real-IP from a trusted proxy", func(t *testing.T) {
+		sut := makeHTTPServerSutRtn("GET")
+		os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+		defer os.Unsetenv("TRUSTED_PROXIES")
+		sut.httpServer.Default()
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		clientIP := sut.clientIPFrom("10.0.0.5:4000", http.Header{"X-Real-Ip": {"203.0.113.7"}})
+
+		assert.Equal(t, "203.0.113.7", clientIP)
+	})
+
+	t.Run("should ignore the forwarded headers from an untrusted source", func(t *testing.T) {
+		sut := makeHTTPServerSutRtn("GET")
+		os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+		defer os.Unsetenv("TRUSTED_PROXIES")
+		sut.httpServer.Default()
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		clientIP := sut.clientIPFrom("172.16.0.9:4000", http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+
+		assert.Equal(t, "172.16.0.9", clientIP)
+	})
+
+	t.Run("should trust no proxy when TRUSTED_PROXIES is invalid", func(t *testing.T) {
+		sut := makeHTTPServerSutRtn("GET")
+		os.Setenv("TRUSTED_PROXIES", "wrong")
+		defer os.Unsetenv("TRUSTED_PROXIES")
+		sut.logger.On("Error", mock.Anything, []zap.Field(nil))
+		sut.httpServer.Default()
+		sut.logger.On("Info", "[HTTP Request]", mock.Anything)
+
+		clientIP := sut.clientIPFrom("10.0.0.5:4000", http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+
+		assert.Equal(t, "10.0.0.5", clientIP)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
 func Test_Run(t *testing.T) {
 	t.Run("should execute Run with SSL correctly", func(t *testing.T) {
 		sut := makeHTTPServerSutRtn("POST")
@@ -153,6 +206,68 @@ func Test_Run(t *testing.T) {
 	})
 }
 
+func Test_GracefullShutdown(t *testing.T) {
+	t.Run("should wait for the active requests before Run returns", func(t *testing.T) {
+		sut := makeHTTPServerSutRtn("GET")
+		sut.env.On("GO_ENV").Return("production")
+		sut.env.On("PROD_ENV").Return("production")
+		sut.env.On("PROFILING_ENV").Return("disabled")
+		os.Setenv("HOST", "localhost")
+		os.Setenv("PORT", "3335")
+		sut.httpServer.router = gin.New()
+		started := make(chan bool)
+		sut.httpServer.RegisterRoute("GET", "/api/v1/slow", func(ctx *gin.Context) {
+			started <- true
+			time.Sleep(100 * time.Millisecond)
+			ctx.String(http.StatusOK, "done")
+		})
+		sut.httpServer.Setup()
+		sut.logger.On("Info", "[HttpServer::Run] - Server running at: http://localhost:3335", []zap.Field(nil))
+		sut.logger.On("Info", "[HttpServer::GracefullShutdown] - 1 connections drained", []zap.Field(nil))
+
+		stopped := make(chan error)
+		go func() { stopped <- sut.httpServer.Run() }()
+
+		responses := make(chan *http.Response)
+		go func() {
+			for {
+				response, err := http.Get("http://localhost:3335/api/v1/slow")
+				if err == nil {
+					responses <- response
+					return
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+		<-started
+		sut.shotdown <- true
+
+		response := <-responses
+		body, _ := ioutil.ReadAll(response.Body)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "done", string(body))
+		assert.NoError(t, <-stopped)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should read the grace period from env", func(t *testing.T) {
+		os.Setenv("HTTP_SHUTDOWN_GRACE_PERIOD", "30s")
+		defer os.Unsetenv("HTTP_SHUTDOWN_GRACE_PERIOD")
+
+		assert.Equal(t, 30*time.Second, shutdownGracePeriodFromEnv(logger.NewLoggerSpy()))
+	})
+
+	t.Run("should use the default grace period when the env is invalid", func(t *testing.T) {
+		os.Setenv("HTTP_SHUTDOWN_GRACE_PERIOD", "soon")
+		defer os.Unsetenv("HTTP_SHUTDOWN_GRACE_PERIOD")
+		logger := logger.NewLoggerSpy()
+		logger.On("Warn", "[HttpServer] - invalid HTTP_SHUTDOWN_GRACE_PERIOD: soon", []zap.Field(nil))
+
+		assert.Equal(t, defaultShutdownGracePeriod, shutdownGracePeriodFromEnv(logger))
+		logger.AssertExpectations(t)
+	})
+}
+
 type httpServerSutRtn struct {
 	httpServer HTTPServer
 	logger     *logger.LoggerSpy
@@ -200,6 +315,21 @@ func (sut httpServerSutRtn) doRequest(method, path string) (*http.Response, erro
 	return w.Result(), nil
 }
 
+func (sut httpServerSutRtn) clientIPFrom(remoteAddr string, headers http.Header) string {
+	sut.httpServer.RegisterRoute("GET", "/api/v1/ip", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.ClientIP())
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/ip", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header = headers
+
+	w := httptest.NewRecorder()
+	sut.httpServer.router.ServeHTTP(w, req)
+
+	return w.Body.String()
+}
+
 func (sut httpServerSutRtn) spyLogger() {
 	sut.logger.On(
 		"Info",
@@ -215,6 +345,11 @@ func (sut httpServerSutRtn) spyLogger() {
 				Type:   zapcore.StringType,
 				String: sut.ginCtx.Request.RequestURI,
 			},
+			{
+				Key:    "clientIP",
+				Type:   zapcore.StringType,
+				String: "",
+			},
 			{
 				Key:     "statusCode",
 				Type:    zapcore.Int64Type,
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+const (
+	CorrelationIDHeader = "X-Correlation-Id"
+	CorrelationIDField  = "x-correlation-id"
+)
+
+type correlationIDKey struct{}
+
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// zapLogger adds WithContext to zap, remembering the correlation ID it carries so scoping the same
+// request twice does not repeat the field.
+type zapLogger struct {
+	*zap.Logger
+	correlationID string
+}
+
+func (pst zapLogger) WithContext(ctx context.Context) interfaces.ILogger {
+	id := CorrelationID(ctx)
+	if id == "" || id == pst.correlationID {
+		return pst
+	}
+
+	return zapLogger{pst.Logger.With(zap.String(CorrelationIDField, id)), id}
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_WithContext(t *testing.T) {
+	t.Run("should add the correlation id of the context to every line", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		sut := zapLogger{Logger: zap.New(core)}
+
+		scoped := sut.WithContext(ContextWithCorrelationID(context.Background(), "abc"))
+		scoped.Info("first")
+		scoped.Warn("second")
+
+		for _, entry := range logs.All() {
+			assert.Equal(t, map[string]interface{}{CorrelationIDField: "abc"}, entry.ContextMap())
+		}
+		assert.Equal(t, 2, logs.Len())
+	})
+
+	t.Run("should not repeat the field when scoped twice with the same context", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		sut := zapLogger{Logger: zap.New(core)}
+		ctx := ContextWithCorrelationID(context.Background(), "abc")
+
+		sut.WithContext(ctx).WithContext(ctx).Info("message")
+
+		assert.Len(t, logs.All()[0].Context, 1)
+	})
+
+	t.Run("should keep the logger when the context has no correlation id", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		sut := zapLogger{Logger: zap.New(core)}
+
+		sut.WithContext(context.Background()).Info("message")
+
+		assert.Empty(t, logs.All()[0].Context)
+	})
+}
+
+func Test_CorrelationID(t *testing.T) {
+	t.Run("should read the id stored in the context", func(t *testing.T) {
+		assert.Equal(t, "abc", CorrelationID(ContextWithCorrelationID(context.Background(), "abc")))
+		assert.Equal(t, "", CorrelationID(context.Background()))
+	})
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 
 	"go.uber.org/zap"
@@ -9,17 +10,21 @@ import (
 	"github.com/ralvescosta/base/pkg/app/interfaces"
 )
 
+// level is shared by every logger NewLogger builds, so SetLevel changes all of them at once.
+var level = zap.NewAtomicLevel()
+
 func NewLogger() (interfaces.ILogger, error) {
 	goEnv := os.Getenv("GO_ENV")
 
-	zapLogLevel := getLogLevel()
+	level.SetLevel(getLogLevel())
+	masked := MaskedFields()
 
 	if goEnv == "production" || goEnv == "staging" {
 		config := zap.NewProductionEncoderConfig()
 		config.EncodeTime = zapcore.ISO8601TimeEncoder
 		encoder := zapcore.NewJSONEncoder(config)
 
-		return zap.New(zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), zapLogLevel)), nil
+		return zapLogger{Logger: zap.New(newMaskingCore(zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level), masked))}, nil
 	}
 
 	config := zap.NewDevelopmentEncoderConfig()
@@ -27,23 +32,44 @@ func NewLogger() (interfaces.ILogger, error) {
 	config.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	consoleEncoder := zapcore.NewConsoleEncoder(config)
 
-	return zap.New(zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), zapLogLevel)), nil
+	return zapLogger{Logger: zap.New(newMaskingCore(zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), level), masked))}, nil
+}
+
+var logLevels = map[string]zapcore.Level{
+	"debug": zap.DebugLevel,
+	"info":  zap.InfoLevel,
+	"warn":  zap.WarnLevel,
+	"error": zap.ErrorLevel,
+	"panic": zap.PanicLevel,
 }
 
 func getLogLevel() zapcore.Level {
-	logLevel := os.Getenv("LOG_LEVEL")
-	switch logLevel {
-	case "debug":
-		return zap.DebugLevel
-	case "info":
-		return zap.InfoLevel
-	case "warn":
-		return zap.WarnLevel
-	case "error":
-		return zap.ErrorLevel
-	case "panic":
-		return zap.PanicLevel
-	default:
-		return zap.InfoLevel
+	if logLevel, ok := logLevels[os.Getenv("LOG_LEVEL")]; ok {
+		return logLevel
+	}
+
+	return zap.InfoLevel
+}
+
+// ValidateLevel rejects a LOG_LEVEL other than debug, info, warn, error and panic.
+func ValidateLevel(raw string) error {
+	if _, ok := logLevels[raw]; !ok {
+		return fmt.Errorf("unknown log level %q", raw)
 	}
+
+	return nil
+}
+
+// SetLevel changes the level of the running loggers, an unknown one falls back to info.
+func SetLevel(raw string) {
+	if logLevel, ok := logLevels[raw]; ok {
+		level.SetLevel(logLevel)
+		return
+	}
+
+	level.SetLevel(zap.InfoLevel)
+}
+
+func Level() string {
+	return level.String()
 }
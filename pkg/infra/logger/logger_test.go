@@ -5,7 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func Test_Logger(t *testing.T) {
@@ -15,7 +15,7 @@ func Test_Logger(t *testing.T) {
 		logger, err := NewLogger()
 
 		assert.NoError(t, err)
-		assert.IsType(t, &zap.Logger{}, logger)
+		assert.IsType(t, zapLogger{}, logger)
 	})
 
 	t.Run("should create production logger correctly", func(t *testing.T) {
@@ -25,7 +25,7 @@ func Test_Logger(t *testing.T) {
 		logger, err := NewLogger()
 
 		assert.NoError(t, err)
-		assert.IsType(t, &zap.Logger{}, logger)
+		assert.IsType(t, zapLogger{}, logger)
 	})
 
 	t.Run("should create logger with different logger level", func(t *testing.T) {
@@ -35,27 +35,52 @@ func Test_Logger(t *testing.T) {
 		logger, err := NewLogger()
 
 		assert.NoError(t, err)
-		assert.IsType(t, &zap.Logger{}, logger)
+		assert.IsType(t, zapLogger{}, logger)
 
 		os.Setenv("LOG_LEVEL", "error")
 
 		logger, err = NewLogger()
 
 		assert.NoError(t, err)
-		assert.IsType(t, &zap.Logger{}, logger)
+		assert.IsType(t, zapLogger{}, logger)
 
 		os.Setenv("LOG_LEVEL", "panic")
 
 		logger, err = NewLogger()
 
 		assert.NoError(t, err)
-		assert.IsType(t, &zap.Logger{}, logger)
+		assert.IsType(t, zapLogger{}, logger)
 
 		os.Setenv("LOG_LEVEL", "wrong log level")
 
 		logger, err = NewLogger()
 
 		assert.NoError(t, err)
-		assert.IsType(t, &zap.Logger{}, logger)
+		assert.IsType(t, zapLogger{}, logger)
 	})
 }
+
+func Test_SetLevel(t *testing.T) {
+	t.Run("should change the level of a running logger", func(t *testing.T) {
+		os.Setenv("LOG_LEVEL", "debug")
+		os.Setenv("GO_ENV", "development")
+		logger, _ := NewLogger()
+
+		SetLevel("error")
+
+		assert.Equal(t, "error", Level())
+		assert.False(t, logger.(zapLogger).Core().Enabled(zapcore.WarnLevel))
+		assert.True(t, logger.(zapLogger).Core().Enabled(zapcore.ErrorLevel))
+	})
+
+	t.Run("should fall back to info on an unknown level", func(t *testing.T) {
+		SetLevel("verbose")
+
+		assert.Equal(t, "info", Level())
+	})
+}
+
+func Test_ValidateLevel(t *testing.T) {
+	assert.NoError(t, ValidateLevel("warn"))
+	assert.EqualError(t, ValidateLevel("verbose"), `unknown log level "verbose"`)
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const MaskedValue = "***"
+
+var defaultMaskedFields = []string{"password", "token", "secret", "authorization"}
+
+// MaskedFields reads LOG_MASKED_FIELDS, a comma separated list of keys matched without case that replaces
+// the default one. The keys are masked both in the log fields and in the headers and bodies of the access log.
+func MaskedFields() map[string]bool {
+	fields := defaultMaskedFields
+	if raw := os.Getenv("LOG_MASKED_FIELDS"); raw != "" {
+		fields = strings.Split(raw, ",")
+	}
+
+	masked := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			masked[field] = true
+		}
+	}
+
+	return masked
+}
+
+// maskingCore replaces the value of the masked fields before they reach the encoder, including the
+// fields bound with With. It matches the field keys only: a masked name inside a string value, such as the
+// headers and bodies of the access log, is left as is, so those payloads are redacted where they are built.
+type maskingCore struct {
+	zapcore.Core
+	masked map[string]bool
+}
+
+func newMaskingCore(core zapcore.Core, masked map[string]bool) zapcore.Core {
+	if len(masked) == 0 {
+		return core
+	}
+
+	return maskingCore{core, masked}
+}
+
+func (pst maskingCore) With(fields []zapcore.Field) zapcore.Core {
+	return maskingCore{pst.Core.With(pst.mask(fields)), pst.masked}
+}
+
+func (pst maskingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if pst.Enabled(entry.Level) {
+		return checked.AddCore(entry, pst)
+	}
+
+	return checked
+}
+
+func (pst maskingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return pst.Core.Write(entry, pst.mask(fields))
+}
+
+func (pst maskingCore) mask(fields []zapcore.Field) []zapcore.Field {
+	masked := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		if pst.masked[strings.ToLower(field.Key)] {
+			field = zap.String(field.Key, MaskedValue)
+		}
+		masked[i] = field
+	}
+
+	return masked
+}
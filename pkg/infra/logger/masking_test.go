@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_MaskedFields(t *testing.T) {
+	t.Run("should use the default fields", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{"password": true, "token": true, "secret": true, "authorization": true}, MaskedFields())
+	})
+
+	t.Run("should read the fields from env", func(t *testing.T) {
+		os.Setenv("LOG_MASKED_FIELDS", " Registro, logradouro ,")
+		defer os.Unsetenv("LOG_MASKED_FIELDS")
+
+		assert.Equal(t, map[string]bool{"registro": true, "logradouro": true}, MaskedFields())
+	})
+}
+
+func Test_MaskingCore(t *testing.T) {
+	t.Run("should redact the masked fields and keep the others", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		logger := zap.New(newMaskingCore(core, map[string]bool{"registro": true, "logradouro": true}))
+
+		logger.Info("[MarketRepository::Create] - success", zap.String("Registro", "4041-0"), zap.Int("id", 1),
+			zap.String("logradouro", "RUA MARAGOJIPE"))
+
+		assert.Equal(t, map[string]interface{}{"Registro": "***", "id": int64(1), "logradouro": "***"}, logs.All()[0].ContextMap())
+	})
+
+	t.Run("should redact the fields bound with With", func(t *testing.T) {
+		core, logs := observer.New(zapcore.DebugLevel)
+		logger := zap.New(newMaskingCore(core, map[string]bool{"registro": true}))
+
+		logger.With(zap.String("registro", "4041-0")).Warn("conflict", zap.String("operation", "create"))
+
+		assert.Equal(t, map[string]interface{}{"registro": "***", "operation": "create"}, logs.All()[0].ContextMap())
+	})
+
+	t.Run("should respect the level of the wrapped core", func(t *testing.T) {
+		core, logs := observer.New(zapcore.WarnLevel)
+		logger := zap.New(newMaskingCore(core, map[string]bool{"registro": true}))
+
+		logger.Info("ignored", zap.String("registro", "4041-0"))
+
+		assert.Zero(t, logs.Len())
+	})
+
+	t.Run("should not wrap the core without masked fields", func(t *testing.T) {
+		core, _ := observer.New(zapcore.DebugLevel)
+
+		assert.Equal(t, core, newMaskingCore(core, map[string]bool{}))
+	})
+}
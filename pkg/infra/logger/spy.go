@@ -1,6 +1,10 @@
 package logger
 
 import (
+	"context"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
@@ -21,6 +25,14 @@ func (pst LoggerSpy) Warn(msg string, fields ...zap.Field) {
 func (pst LoggerSpy) Error(msg string, fields ...zap.Field) {
 	pst.Called(msg, fields)
 }
+func (pst *LoggerSpy) WithContext(ctx context.Context) interfaces.ILogger {
+	return pst
+}
+func (pst LoggerSpy) Sync() error {
+	args := pst.Called()
+
+	return args.Error(0)
+}
 func NewLoggerSpy() *LoggerSpy {
 	return new(LoggerSpy)
 }
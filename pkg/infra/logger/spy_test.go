@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"testing"
 
 	"go.uber.org/zap/zapcore"
@@ -53,3 +54,27 @@ func Test_Error(t *testing.T) {
 		sut.AssertExpectations(t)
 	})
 }
+
+func Test_WithContextSpy(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewLoggerSpy()
+
+		sut.On("Info", "[SomeInfo]", []zapcore.Field(nil))
+
+		sut.WithContext(context.Background()).Info("[SomeInfo]")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_SyncSpy(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewLoggerSpy()
+
+		sut.On("Sync").Return(nil)
+
+		sut.Sync()
+
+		sut.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,342 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Concurrent misses for the same key are collapsed into a single query through a singleflight.Group,
+// so a hot key expiring under load does not stampede the database. The shared query runs detached from
+// the cancellation of the request that started it, bounded by DB_QUERY_TIMEOUT or sharedQueryTimeout,
+// so one aborted request does not fail the others waiting on the same key.
+//
+// cachedMethods lists the read methods that can be cached. Each one reads its TTL from
+// CACHE_TTL_<METHOD> (e.g. CACHE_TTL_FIND=60s, CACHE_TTL_FIND_BY_ID=5m), falling back to CACHE_TTL.
+// A zero TTL disables caching.
+var cachedMethods = []string{"Find", "FindByID", "FindByRegistro"}
+
+const sharedQueryTimeout = 30 * time.Second
+
+type cachedMarketRepository struct {
+	interfaces.IMarketRepository
+	logger  interfaces.ILogger
+	cache   interfaces.ICache
+	ttls    map[string]time.Duration
+	group   *singleflight.Group
+	timeout time.Duration
+}
+
+// detachedContext keeps the values of the request context, its trace ids included, without its deadline and
+// cancellation.
+type detachedContext struct{ context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (pst cachedMarketRepository) Find(ctx context.Context, market valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	key := cacheKey("Find", market)
+
+	var results []valueObjects.MarketValueObjects
+	if pst.load(ctx, "Find", key, &results) {
+		return results, nil
+	}
+
+	shared, err := pst.share(ctx, key, func(sharedCtx context.Context) (interface{}, error) {
+		if pst.load(sharedCtx, "Find", key, &results) {
+			return results, nil
+		}
+
+		results, err := pst.IMarketRepository.Find(sharedCtx, market)
+		if err != nil {
+			return nil, err
+		}
+
+		pst.store(sharedCtx, "Find", key, results)
+
+		return results, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// every caller gets its own copy, the shared slice is never handed out
+	markets := shared.([]valueObjects.MarketValueObjects)
+	return append(make([]valueObjects.MarketValueObjects, 0, len(markets)), markets...), nil
+}
+
+func (pst cachedMarketRepository) FindByID(ctx context.Context, id int) (valueObjects.MarketValueObjects, error) {
+	return pst.findOne(ctx, "FindByID", id, func(sharedCtx context.Context) (valueObjects.MarketValueObjects, error) {
+		return pst.IMarketRepository.FindByID(sharedCtx, id)
+	})
+}
+
+func (pst cachedMarketRepository) FindByRegistro(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	return pst.findOne(ctx, "FindByRegistro", registro, func(sharedCtx context.Context) (valueObjects.MarketValueObjects, error) {
+		return pst.IMarketRepository.FindByRegistro(sharedCtx, registro)
+	})
+}
+
+// findOne caches the single market read by method, keyed on its only parameter.
+func (pst cachedMarketRepository) findOne(ctx context.Context, method string, param interface{},
+	find func(ctx context.Context) (valueObjects.MarketValueObjects, error)) (valueObjects.MarketValueObjects, error) {
+	key := cacheKey(method, param)
+
+	var market valueObjects.MarketValueObjects
+	if pst.load(ctx, method, key, &market) {
+		return market, nil
+	}
+
+	shared, err := pst.share(ctx, key, func(sharedCtx context.Context) (interface{}, error) {
+		if pst.load(sharedCtx, method, key, &market) {
+			return market, nil
+		}
+
+		market, err := find(sharedCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		pst.store(sharedCtx, method, key, market)
+
+		return market, nil
+	})
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	return shared.(valueObjects.MarketValueObjects), nil
+}
+
+// share runs query once for the concurrent misses of key, detached from the cancellation of ctx, while each
+// caller still returns as soon as its own ctx is done.
+func (pst cachedMarketRepository) share(ctx context.Context, key string, query func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	shared := pst.group.DoChan(key, func() (interface{}, error) {
+		sharedCtx, cancel := context.WithTimeout(detachedContext{ctx}, pst.timeout)
+		defer cancel()
+
+		return query(sharedCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-shared:
+		return result.Val, result.Err
+	}
+}
+
+func (pst cachedMarketRepository) Create(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	result, err := pst.IMarketRepository.Create(ctx, market)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return result, nil
+}
+
+func (pst cachedMarketRepository) Upsert(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, bool, error) {
+	result, inserted, err := pst.IMarketRepository.Upsert(ctx, market)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, false, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return result, inserted, nil
+}
+
+func (pst cachedMarketRepository) CreateMany(ctx context.Context, markets []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	results, err := pst.IMarketRepository.CreateMany(ctx, markets)
+	if err != nil {
+		return nil, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return results, nil
+}
+
+func (pst cachedMarketRepository) DedupeByProximity(ctx context.Context, thresholdMeters float64) (int64, error) {
+	removed, err := pst.IMarketRepository.DedupeByProximity(ctx, thresholdMeters)
+	if err != nil {
+		return 0, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return removed, nil
+}
+
+// WithTx hands fn the uncached repository, so reads inside the transaction see its own writes, and
+// clears the cache once it commits.
+func (pst cachedMarketRepository) WithTx(ctx context.Context, fn func(repo interfaces.IMarketRepository) error) error {
+	if err := pst.IMarketRepository.WithTx(ctx, fn); err != nil {
+		return err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return nil
+}
+
+func (pst cachedMarketRepository) Update(ctx context.Context, registerCode string, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	result, err := pst.IMarketRepository.Update(ctx, registerCode, market)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return result, nil
+}
+
+func (pst cachedMarketRepository) UpdateByID(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	result, err := pst.IMarketRepository.UpdateByID(ctx, market)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return result, nil
+}
+
+func (pst cachedMarketRepository) Delete(ctx context.Context, registerCode string) error {
+	if err := pst.IMarketRepository.Delete(ctx, registerCode); err != nil {
+		return err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return nil
+}
+
+func (pst cachedMarketRepository) Restore(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	result, err := pst.IMarketRepository.Restore(ctx, registro)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return result, nil
+}
+
+func (pst cachedMarketRepository) Touch(ctx context.Context, id int) error {
+	if err := pst.IMarketRepository.Touch(ctx, id); err != nil {
+		return err
+	}
+
+	pst.cache.Clear(ctx)
+
+	return nil
+}
+
+func (pst cachedMarketRepository) load(ctx context.Context, method, key string, dest interface{}) bool {
+	if pst.ttls[method] <= 0 {
+		return false
+	}
+
+	raw, ok := pst.cache.Get(ctx, key)
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(raw, dest); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[CachedMarketRepository::%s] - ignoring unreadable cache entry", method))
+		return false
+	}
+
+	return true
+}
+
+func (pst cachedMarketRepository) store(ctx context.Context, method, key string, value interface{}) {
+	ttl := pst.ttls[method]
+	if ttl <= 0 {
+		return
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		pst.logger.Warn(fmt.Sprintf("[CachedMarketRepository::%s] - could not serialize the result to cache", method))
+		return
+	}
+
+	pst.cache.Set(ctx, key, raw, ttl)
+}
+
+func cacheKey(method string, params ...interface{}) string {
+	raw, _ := json.Marshal(params)
+	return fmt.Sprintf("markets:%s:%s", method, raw)
+}
+
+func cacheTTLsFromEnv(logger interfaces.ILogger) map[string]time.Duration {
+	defaultTTL := parseCacheTTL(logger, "CACHE_TTL", 0)
+
+	ttls := make(map[string]time.Duration, len(cachedMethods))
+	for _, method := range cachedMethods {
+		ttls[method] = parseCacheTTL(logger, "CACHE_TTL_"+toEnvName(method), defaultTTL)
+	}
+
+	return ttls
+}
+
+func parseCacheTTL(logger interfaces.ILogger, key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		logger.Warn(fmt.Sprintf("[CachedMarketRepository] - invalid %s value: %s", key, value))
+		return fallback
+	}
+
+	return ttl
+}
+
+// toEnvName turns a method name into its env suffix, keeping the acronyms whole: FindByID is FIND_BY_ID.
+func toEnvName(method string) string {
+	runes := []rune(method)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			wordStart := unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+			if wordStart {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+func NewCachedMarketRepository(logger interfaces.ILogger, repo interfaces.IMarketRepository, cache interfaces.ICache) interfaces.IMarketRepository {
+	return cachedMarketRepository{repo, logger, cache, cacheTTLsFromEnv(logger), &singleflight.Group{}, sharedQueryTimeoutFromEnv(logger)}
+}
+
+// sharedQueryTimeoutFromEnv bounds the shared query by DB_QUERY_TIMEOUT, since it no longer inherits the deadline
+// of a request, or by sharedQueryTimeout when DB_QUERY_TIMEOUT is not set.
+func sharedQueryTimeoutFromEnv(logger interfaces.ILogger) time.Duration {
+	if timeout := queryTimeoutFromEnv(logger); timeout > 0 {
+		return timeout
+	}
+
+	return sharedQueryTimeout
+}
@@ -0,0 +1,457 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/cache"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sync/singleflight"
+)
+
+func Test_CachedMarketRepo_Find(t *testing.T) {
+	t.Run("should return the cached result without querying the repository", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		raw, _ := json.Marshal(sut.markets)
+		sut.cache.On("Get", mock.Anything, sut.findKey).Return(raw, true)
+
+		result, err := sut.repo.Find(sut.ctx, sut.filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.markets, result)
+		sut.inner.AssertNotCalled(t, "Find", mock.Anything, sut.filter)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should store the result using the ttl configured for Find", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		raw, _ := json.Marshal(sut.markets)
+		sut.cache.On("Get", mock.Anything, sut.findKey).Return([]byte(nil), false)
+		sut.inner.On("Find", mock.Anything, sut.filter).Return(sut.markets, nil)
+		sut.cache.On("Set", mock.Anything, sut.findKey, raw, 60*time.Second)
+
+		result, err := sut.repo.Find(sut.ctx, sut.filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.markets, result)
+		sut.inner.AssertExpectations(t)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should bypass the cache when Find has no ttl", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.ttls = map[string]time.Duration{}
+
+		sut.inner.On("Find", mock.Anything, sut.filter).Return(sut.markets, nil)
+
+		_, err := sut.repo.Find(sut.ctx, sut.filter)
+
+		assert.NoError(t, err)
+		sut.cache.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+		sut.cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.cache.On("Get", mock.Anything, sut.findKey).Return([]byte(nil), false)
+		sut.inner.On("Find", mock.Anything, sut.filter).Return([]valueObjects.MarketValueObjects(nil), errors.NewInternalError("some error"))
+
+		_, err := sut.repo.Find(sut.ctx, sut.filter)
+
+		assert.Error(t, err)
+		sut.cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should query the repository again once the entry expired", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.cache = cache.NewMemoryCache()
+		sut.repo.ttls = map[string]time.Duration{"Find": time.Nanosecond}
+
+		sut.inner.On("Find", mock.Anything, sut.filter).Return(sut.markets, nil).Twice()
+
+		sut.repo.Find(sut.ctx, sut.filter)
+		time.Sleep(time.Millisecond)
+		sut.repo.Find(sut.ctx, sut.filter)
+
+		sut.inner.AssertExpectations(t)
+	})
+
+	t.Run("should query the repository once for concurrent requests of the same expired key", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.cache = cache.NewMemoryCache()
+		sut.repo.cache.Set(sut.ctx, sut.findKey, []byte("[]"), time.Nanosecond)
+		time.Sleep(time.Millisecond)
+
+		var calls int32
+		release := make(chan struct{})
+		sut.inner.On("Find", mock.Anything, sut.filter).Return(sut.markets, nil).Run(func(mock.Arguments) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+		})
+
+		wg := sync.WaitGroup{}
+		results := make([][]valueObjects.MarketValueObjects, 20)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = sut.repo.Find(sut.ctx, sut.filter)
+			}(i)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for _, result := range results {
+			assert.Equal(t, sut.markets, result)
+		}
+	})
+
+	t.Run("should not fail the waiters when the request that started the query is canceled", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.cache = cache.NewMemoryCache()
+
+		started, release := make(chan struct{}), make(chan struct{})
+		sut.inner.On("Find", mock.Anything, sut.filter).Return(sut.markets, nil).Run(func(args mock.Arguments) {
+			assert.NoError(t, args.Get(0).(context.Context).Err())
+			close(started)
+			<-release
+		}).Once()
+
+		leaderCtx, cancel := context.WithCancel(sut.ctx)
+		leaderErr := make(chan error)
+		go func() {
+			_, err := sut.repo.Find(leaderCtx, sut.filter)
+			leaderErr <- err
+		}()
+		<-started
+
+		waiter := make(chan []valueObjects.MarketValueObjects)
+		go func() {
+			result, _ := sut.repo.Find(sut.ctx, sut.filter)
+			waiter <- result
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		cancel()
+		assert.Equal(t, context.Canceled, <-leaderErr)
+		close(release)
+
+		assert.Equal(t, sut.markets, <-waiter)
+		sut.inner.AssertExpectations(t)
+	})
+
+	t.Run("should give each caller its own copy of the shared result", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.ttls = map[string]time.Duration{}
+
+		sut.inner.On("Find", mock.Anything, sut.filter).Return(sut.markets, nil)
+
+		result, err := sut.repo.Find(sut.ctx, sut.filter)
+		result[0].Distrito = "changed"
+
+		assert.NoError(t, err)
+		assert.Equal(t, "distrito", sut.markets[0].Distrito)
+	})
+}
+
+func Test_CachedMarketRepo_FindOne(t *testing.T) {
+	t.Run("should return the cached market without querying the repository", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.ttls = map[string]time.Duration{"FindByID": 5 * time.Minute}
+
+		raw, _ := json.Marshal(sut.markets[0])
+		sut.cache.On("Get", mock.Anything, cacheKey("FindByID", 1)).Return(raw, true)
+
+		result, err := sut.repo.FindByID(sut.ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.markets[0], result)
+		sut.inner.AssertNotCalled(t, "FindByID", mock.Anything, 1)
+	})
+
+	t.Run("should store the market using the ttl configured for each method", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.ttls = map[string]time.Duration{"FindByID": 5 * time.Minute, "FindByRegistro": time.Hour}
+
+		raw, _ := json.Marshal(sut.markets[0])
+		sut.cache.On("Get", mock.Anything, mock.Anything).Return([]byte(nil), false)
+		sut.inner.On("FindByID", mock.Anything, 1).Return(sut.markets[0], nil)
+		sut.inner.On("FindByRegistro", mock.Anything, "4041-0").Return(sut.markets[0], nil)
+		sut.cache.On("Set", mock.Anything, cacheKey("FindByID", 1), raw, 5*time.Minute)
+		sut.cache.On("Set", mock.Anything, cacheKey("FindByRegistro", "4041-0"), raw, time.Hour)
+
+		sut.repo.FindByID(sut.ctx, 1)
+		sut.repo.FindByRegistro(sut.ctx, "4041-0")
+
+		sut.inner.AssertExpectations(t)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should expire each method at its own ttl", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.cache = cache.NewMemoryCache()
+		sut.repo.ttls = map[string]time.Duration{"FindByID": time.Nanosecond, "FindByRegistro": time.Hour}
+
+		sut.inner.On("FindByID", mock.Anything, 1).Return(sut.markets[0], nil).Twice()
+		sut.inner.On("FindByRegistro", mock.Anything, "4041-0").Return(sut.markets[0], nil).Once()
+
+		sut.repo.FindByID(sut.ctx, 1)
+		sut.repo.FindByRegistro(sut.ctx, "4041-0")
+		time.Sleep(time.Millisecond)
+		sut.repo.FindByID(sut.ctx, 1)
+		result, err := sut.repo.FindByRegistro(sut.ctx, "4041-0")
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.markets[0], result)
+		sut.inner.AssertExpectations(t)
+	})
+
+	t.Run("should not cache a market not found", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+		sut.repo.ttls = map[string]time.Duration{"FindByRegistro": time.Hour}
+
+		sut.cache.On("Get", mock.Anything, mock.Anything).Return([]byte(nil), false)
+		sut.inner.On("FindByRegistro", mock.Anything, "4041-0").Return(valueObjects.MarketValueObjects{}, errors.NewNotFoundError("not found"))
+
+		_, err := sut.repo.FindByRegistro(sut.ctx, "4041-0")
+
+		assert.IsType(t, errors.NotFoundError{}, err)
+		sut.cache.AssertNotCalled(t, "Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func Test_CachedMarketRepo_Writes(t *testing.T) {
+	t.Run("should clear the cache after a Create", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("Create", sut.ctx, sut.filter).Return(sut.filter, nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		_, err := sut.repo.Create(sut.ctx, sut.filter)
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after an Update", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("Update", sut.ctx, "registro", sut.filter).Return(sut.filter, nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		_, err := sut.repo.Update(sut.ctx, "registro", sut.filter)
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after a Delete", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("Delete", sut.ctx, "registro").Return(nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		err := sut.repo.Delete(sut.ctx, "registro")
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after an UpdateByID", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		market := valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"}
+		sut.inner.On("UpdateByID", sut.ctx, market).Return(market, nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		_, err := sut.repo.UpdateByID(sut.ctx, market)
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after a CreateMany", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		markets := []valueObjects.MarketValueObjects{{Registro: "4041-0"}}
+		sut.inner.On("CreateMany", sut.ctx, markets).Return(markets, nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		_, err := sut.repo.CreateMany(sut.ctx, markets)
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after an Upsert", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		market := valueObjects.MarketValueObjects{Registro: "4041-0"}
+		sut.inner.On("Upsert", sut.ctx, market).Return(market, false, nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		_, inserted, err := sut.repo.Upsert(sut.ctx, market)
+
+		assert.NoError(t, err)
+		assert.False(t, inserted)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after a DedupeByProximity", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("DedupeByProximity", sut.ctx, 10.0).Return(int64(2), nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		removed, err := sut.repo.DedupeByProximity(sut.ctx, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), removed)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after a committed WithTx", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("WithTx", sut.ctx).Return(nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		err := sut.repo.WithTx(sut.ctx, func(repo interfaces.IMarketRepository) error { return nil })
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should keep the cache when WithTx rolls back", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("WithTx", sut.ctx).Return(nil)
+
+		err := sut.repo.WithTx(sut.ctx, func(repo interfaces.IMarketRepository) error { return errors.NewInternalError("some error") })
+
+		assert.Error(t, err)
+		sut.cache.AssertNotCalled(t, "Clear", sut.ctx)
+	})
+
+	t.Run("should clear the cache after a Restore", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("Restore", sut.ctx, "4041-0").Return(valueObjects.MarketValueObjects{Registro: "4041-0"}, nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		_, err := sut.repo.Restore(sut.ctx, "4041-0")
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should clear the cache after a Touch", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("Touch", sut.ctx, 1).Return(nil)
+		sut.cache.On("Clear", sut.ctx)
+
+		err := sut.repo.Touch(sut.ctx, 1)
+
+		assert.NoError(t, err)
+		sut.cache.AssertExpectations(t)
+	})
+
+	t.Run("should keep the cache when the write fails", func(t *testing.T) {
+		sut := makeCachedMarketRepositorySut()
+
+		sut.inner.On("Delete", sut.ctx, "registro").Return(errors.NewInternalError("some error"))
+
+		err := sut.repo.Delete(sut.ctx, "registro")
+
+		assert.Error(t, err)
+		sut.cache.AssertNotCalled(t, "Clear", mock.Anything)
+	})
+}
+
+func Test_CacheTTLsFromEnv(t *testing.T) {
+	t.Run("should prefer the method ttl over the default one", func(t *testing.T) {
+		os.Setenv("CACHE_TTL", "10m")
+		os.Setenv("CACHE_TTL_FIND", "60s")
+		defer os.Unsetenv("CACHE_TTL")
+		defer os.Unsetenv("CACHE_TTL_FIND")
+
+		ttls := cacheTTLsFromEnv(logger.NewLoggerSpy())
+
+		assert.Equal(t, 60*time.Second, ttls["Find"])
+	})
+
+	t.Run("should read the ttl of FindByID and FindByRegistro", func(t *testing.T) {
+		os.Setenv("CACHE_TTL_FIND_BY_ID", "5m")
+		os.Setenv("CACHE_TTL_FIND_BY_REGISTRO", "1h")
+		defer os.Unsetenv("CACHE_TTL_FIND_BY_ID")
+		defer os.Unsetenv("CACHE_TTL_FIND_BY_REGISTRO")
+
+		ttls := cacheTTLsFromEnv(logger.NewLoggerSpy())
+
+		assert.Equal(t, 5*time.Minute, ttls["FindByID"])
+		assert.Equal(t, time.Hour, ttls["FindByRegistro"])
+	})
+
+	t.Run("should fallback to the default ttl when the method ttl is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("CACHE_TTL", "10m")
+		os.Setenv("CACHE_TTL_FIND", "wrong")
+		defer os.Unsetenv("CACHE_TTL")
+		defer os.Unsetenv("CACHE_TTL_FIND")
+		log.On("Warn", "[CachedMarketRepository] - invalid CACHE_TTL_FIND value: wrong", []zapcore.Field(nil))
+
+		ttls := cacheTTLsFromEnv(log)
+
+		assert.Equal(t, 10*time.Minute, ttls["Find"])
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_ToEnvName(t *testing.T) {
+	t.Run("should keep the acronyms whole", func(t *testing.T) {
+		assert.Equal(t, "FIND", toEnvName("Find"))
+		assert.Equal(t, "FIND_BY_ID", toEnvName("FindByID"))
+		assert.Equal(t, "FIND_BY_REGISTRO", toEnvName("FindByRegistro"))
+		assert.Equal(t, "FIND_BY_UUID", toEnvName("FindByUUID"))
+	})
+}
+
+type cachedMarketRepositorySutRtn struct {
+	ctx     context.Context
+	inner   *MarketRepositorySpy
+	cache   *cache.CacheSpy
+	repo    cachedMarketRepository
+	filter  valueObjects.MarketValueObjects
+	findKey string
+	markets []valueObjects.MarketValueObjects
+}
+
+func makeCachedMarketRepositorySut() cachedMarketRepositorySutRtn {
+	inner := NewMarketRepositorySpy()
+	cacheSpy := cache.NewCacheSpy()
+	repo := cachedMarketRepository{inner, logger.NewLoggerSpy(), cacheSpy, map[string]time.Duration{"Find": 60 * time.Second}, &singleflight.Group{},
+		sharedQueryTimeout}
+
+	filter := valueObjects.MarketValueObjects{Distrito: "distrito"}
+	markets := []valueObjects.MarketValueObjects{{ID: 1, Distrito: "distrito"}, {ID: 2, Distrito: "distrito"}}
+
+	return cachedMarketRepositorySutRtn{context.Background(), inner, cacheSpy, repo, filter, cacheKey("Find", filter), markets}
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern escapes the LIKE wildcards of term and wraps it for a "contains" match.
+// Queries using it must declare ESCAPE '\'.
+func containsPattern(term string) string {
+	return "%" + likeEscaper.Replace(strings.TrimSpace(term)) + "%"
+}
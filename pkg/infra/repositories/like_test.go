@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ContainsPattern(t *testing.T) {
+	t.Run("should wrap the term with wildcards", func(t *testing.T) {
+		assert.Equal(t, "%VL FORMOSA%", containsPattern(" VL FORMOSA "))
+	})
+
+	t.Run("should escape the like wildcards", func(t *testing.T) {
+		assert.Equal(t, `%50\% OFF\_A\\B%`, containsPattern(`50% OFF_A\B`))
+	})
+}
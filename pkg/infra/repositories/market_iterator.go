@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+// MarketIterator walks every active market in id order using keyset pagination. Each page starts
+// after the last id already returned, so rows inserted or deleted mid-export never shift a page
+// the way an OFFSET would. With DB_ID_TYPE=uuid the cursor is the last uuid.
+type MarketIterator struct {
+	repo     interfaces.IMarketRepository
+	pageSize int
+	lastID   int
+	lastUUID string
+	done     bool
+}
+
+// Next returns the following page and false once the markets are exhausted.
+func (pst *MarketIterator) Next(ctx context.Context) ([]valueObjects.MarketValueObjects, bool, error) {
+	if pst.done {
+		return nil, false, nil
+	}
+
+	var page []valueObjects.MarketValueObjects
+	var err error
+	if pst.lastUUID != "" {
+		page, err = pst.repo.FindAfterUUID(ctx, pst.lastUUID, pst.pageSize)
+	} else {
+		page, err = pst.repo.FindAfterID(ctx, pst.lastID, pst.pageSize)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(page) < pst.pageSize {
+		pst.done = true
+	}
+	if len(page) == 0 {
+		return nil, false, nil
+	}
+
+	pst.lastID, pst.lastUUID = page[len(page)-1].ID, page[len(page)-1].UUID
+
+	return page, true, nil
+}
+
+func NewMarketIterator(repo interfaces.IMarketRepository, pageSize int) *MarketIterator {
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+
+	return &MarketIterator{repo: repo, pageSize: pageSize}
+}
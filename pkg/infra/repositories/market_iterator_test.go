@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarketIterator(t *testing.T) {
+	t.Run("should iterate by the last seen id", func(t *testing.T) {
+		sut := makeMarketIteratorSut(2)
+
+		sut.repo.On("FindAfterID", sut.ctx, 0, 2).Return(markets(1, 2), nil)
+		sut.repo.On("FindAfterID", sut.ctx, 2, 2).Return(markets(3, 4), nil)
+		sut.repo.On("FindAfterID", sut.ctx, 4, 2).Return(markets(5), nil)
+
+		ids := sut.drain(t)
+
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should stay stable when markets are inserted and deleted between pages", func(t *testing.T) {
+		sut := makeMarketIteratorSut(2)
+
+		// id 2 is deleted and id 7 inserted after the first page was read; an OFFSET 2 would skip id 3.
+		sut.repo.On("FindAfterID", sut.ctx, 0, 2).Return(markets(1, 2), nil)
+		sut.repo.On("FindAfterID", sut.ctx, 2, 2).Return(markets(3, 4), nil)
+		sut.repo.On("FindAfterID", sut.ctx, 4, 2).Return(markets(5, 7), nil)
+		sut.repo.On("FindAfterID", sut.ctx, 7, 2).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		ids := sut.drain(t)
+
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 7}, ids)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should iterate by the last seen uuid with the uuid id type", func(t *testing.T) {
+		sut := makeMarketIteratorSut(2)
+
+		sut.repo.On("FindAfterID", sut.ctx, 0, 2).Return([]valueObjects.MarketValueObjects{{UUID: "1b"}, {UUID: "2c"}}, nil)
+		sut.repo.On("FindAfterUUID", sut.ctx, "2c", 2).Return([]valueObjects.MarketValueObjects{{UUID: "3d"}}, nil)
+
+		var uuids []string
+		for {
+			page, ok, err := sut.iterator.Next(sut.ctx)
+			assert.NoError(t, err)
+			if !ok {
+				break
+			}
+			for _, market := range page {
+				uuids = append(uuids, market.UUID)
+			}
+		}
+
+		assert.Equal(t, []string{"1b", "2c", "3d"}, uuids)
+		sut.repo.AssertExpectations(t)
+	})
+
+	t.Run("should stop on the repository error", func(t *testing.T) {
+		sut := makeMarketIteratorSut(2)
+
+		sut.repo.On("FindAfterID", sut.ctx, 0, 2).Return([]valueObjects.MarketValueObjects(nil), errors.NewInternalError("query execution error"))
+
+		_, ok, err := sut.iterator.Next(sut.ctx)
+
+		assert.False(t, ok)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fallback to the default page size", func(t *testing.T) {
+		sut := makeMarketIteratorSut(0)
+
+		assert.Equal(t, defaultPageSize, sut.iterator.pageSize)
+	})
+}
+
+type marketIteratorSutRtn struct {
+	ctx      context.Context
+	repo     *MarketRepositorySpy
+	iterator *MarketIterator
+}
+
+func makeMarketIteratorSut(pageSize int) marketIteratorSutRtn {
+	repo := NewMarketRepositorySpy()
+
+	return marketIteratorSutRtn{context.Background(), repo, NewMarketIterator(repo, pageSize)}
+}
+
+func (sut marketIteratorSutRtn) drain(t *testing.T) []int {
+	var ids []int
+	for {
+		page, ok, err := sut.iterator.Next(sut.ctx)
+		assert.NoError(t, err)
+		if !ok {
+			return ids
+		}
+		for _, market := range page {
+			ids = append(ids, market.ID)
+		}
+	}
+}
+
+func markets(ids ...int) []valueObjects.MarketValueObjects {
+	result := make([]valueObjects.MarketValueObjects, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, valueObjects.MarketValueObjects{ID: id})
+	}
+
+	return result
+}
@@ -4,169 +4,1795 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"os"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ralvescosta/base/pkg/app/errors"
 	"github.com/ralvescosta/base/pkg/app/interfaces"
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/database"
 	"github.com/ralvescosta/base/pkg/infra/database/models"
 
+	"github.com/lib/pq"
 	apm "go.elastic.co/apm/v2"
+	"go.uber.org/zap"
 )
 
 type marketRepository struct {
-	logger interfaces.ILogger
-	db     *sql.DB
+	logger     interfaces.ILogger
+	db         *sql.DB
+	tx         *sql.Tx
+	prepared   bool
+	maxResults int64
+	maxPage    int
+	timeout    time.Duration
+	collation  string
+	returning  bool
+	retries    retryPolicy
+	txLimit    txLimiter
+	uuidIDs    bool
 }
 
 var now = time.Now
 
+const defaultPageSize = 20
+
+const defaultMaxPageSize = 100
+
+var snapshotTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+var timelineGranularities = map[string]bool{"day": true, "month": true}
+
+var collationName = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+const selectMarkets = `SELECT
+		id AS ID,
+		long AS Long,
+		lat AS Lat,
+		setcens AS Setcens,
+		areap AS Areap,
+		coddist AS Coddist,
+		distrito AS Distrito,
+		codsubpref AS Codsubpref,
+		subpref AS Subpref,
+		regiao5 AS Regiao5,
+		regiao8 AS Regiao8,
+		nome_feira AS NomeFeira,
+		registro AS Registro,
+		logradouro AS Logradouro,
+		numero AS Numero,
+		bairro AS Bairro,
+		referencia AS Referencia,
+		criado_em AS CriadoEm,
+		atualizado_em AS AtualizadoEm,
+		deletado_em AS DeletadoEm
+	FROM feiras`
+
+const insertMarket = `
+		INSERT INTO feiras 
+			(long, lat, setcens, areap, coddist, distrito, codsubpref, subpref, regiao5, regiao8, nome_feira, registro, logradouro, numero, 
+				bairro, referencia, criado_em, atualizado_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`
+
 func (pst marketRepository) Create(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if err := market.Validate(); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::Create] - invalid market: %s", err.Error()))
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	var result valueObjects.MarketValueObjects
+	var err error
+	if pst.returning {
+		result, err = pst.insertReturning(ctx, market)
+	} else {
+		err = pst.withTx(ctx, "Create", func(txRepo marketRepository) error {
+			result, err = txRepo.insertThenSelect(ctx, market)
+			return err
+		})
+	}
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.audit("Create", models.MarketID{Int: result.ID, UUID: result.UUID}, result.Registro)
+
+	return result, nil
+}
+
+func (pst marketRepository) insertReturning(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	sql := insertMarket + " RETURNING *"
+
+	ctx, dispose := pst.instrument(ctx, "Create", "INSERT INTO feiras", sql)
+	defer dispose()
+
+	prepare, err := pst.conn().PrepareContext(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Create] Error in prepare statement")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	row, err := pst.queryRowWithRetry(ctx, "Create", prepare, insertArgs(market)...)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, pst.insertError(err)
+	}
+
+	result, err := pst.scan(row)
+	if isConstraintError(err) {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::Create] - constraint violation: %s", err.Error()))
+		return valueObjects.MarketValueObjects{}, err
+	}
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Create] - scanning the result failure")
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	return result, nil
+}
+
+// insertThenSelect is used with DB_INSERT_RETURNING=false, for drivers and poolers that do not support
+// RETURNING. It must run inside a transaction so lastval() reads the id of this INSERT. The uuid id type has
+// no sequence, so with DB_ID_TYPE=uuid the market is read back by its registro, unique among the active ones.
+func (pst marketRepository) insertThenSelect(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	ctx, dispose := pst.instrument(ctx, "Create", "INSERT INTO feiras", insertMarket)
+	defer dispose()
+
+	prepare, err := pst.conn().PrepareContext(ctx, insertMarket)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Create] Error in prepare statement")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	if _, err := prepare.ExecContext(ctx, insertArgs(market)...); err != nil {
+		return valueObjects.MarketValueObjects{}, pst.insertError(err)
+	}
+
+	var row *sql.Row
+	if pst.uuidIDs {
+		row = pst.conn().QueryRowContext(ctx, selectMarkets+" WHERE registro = $1 AND deletado_em IS NULL", market.Registro)
+	} else {
+		row = pst.conn().QueryRowContext(ctx, selectMarkets+" WHERE id = lastval()")
+	}
+	if err := row.Err(); err != nil {
+		pst.logger.Error("[MarketRepository::Create] query execution error")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error")
+	}
+
+	result, err := pst.scan(row)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Create] - scanning the result failure")
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	return result, nil
+}
+
+func (pst marketRepository) insertError(err error) error {
+	if mapped := constraintError(err); mapped != nil {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::Create] - constraint violation: %s", mapped.Error()))
+		return mapped
+	}
+
+	pst.logger.Error("[MarketRepository::Create] query execution error")
+	return errors.NewInternalError("query execution error")
+}
+
+func insertArgs(market valueObjects.MarketValueObjects) []interface{} {
+	return []interface{}{market.Long, market.Lat, market.Setcens, market.Areap, market.Coddist, market.Distrito, market.Codsubpref,
+		market.Subpref, market.Regiao5, market.Regiao8, market.NomeFeira, market.Registro, market.Logradouro, market.Numero, market.Bairro,
+		market.Referencia, now(), now()}
+}
+
+// Upsert creates the market or, when a market with the same registro is already stored, updates it and bumps
+// atualizado_em. It relies on the feiras_registro_key partial index and reports whether the row was inserted.
+func (pst marketRepository) Upsert(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, bool, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
 	sql := `
 		INSERT INTO feiras 
 			(long, lat, setcens, areap, coddist, distrito, codsubpref, subpref, regiao5, regiao8, nome_feira, registro, logradouro, numero, 
 				bairro, referencia, criado_em, atualizado_em)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
-		RETURNING *
+		ON CONFLICT (registro) WHERE deletado_em IS NULL DO UPDATE SET
+			long = EXCLUDED.long, lat = EXCLUDED.lat, setcens = EXCLUDED.setcens, areap = EXCLUDED.areap, coddist = EXCLUDED.coddist,
+			distrito = EXCLUDED.distrito, codsubpref = EXCLUDED.codsubpref, subpref = EXCLUDED.subpref, regiao5 = EXCLUDED.regiao5,
+			regiao8 = EXCLUDED.regiao8, nome_feira = EXCLUDED.nome_feira, logradouro = EXCLUDED.logradouro, numero = EXCLUDED.numero,
+			bairro = EXCLUDED.bairro, referencia = EXCLUDED.referencia, atualizado_em = EXCLUDED.atualizado_em
+		RETURNING *, (xmax = 0) AS inserted
 	`
-	dispose := instrument(ctx, "INSERT INTO feiras", sql)
+	if err := market.Validate(); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::Upsert] - invalid market: %s", err.Error()))
+		return valueObjects.MarketValueObjects{}, false, err
+	}
+
+	ctx, dispose := pst.instrument(ctx, "Upsert", "UPSERT feiras", sql)
+	defer dispose()
+
+	prepare, err := pst.conn().PrepareContext(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Upsert] Error in prepare statement")
+		return valueObjects.MarketValueObjects{}, false, errors.NewInternalError("error in prepare statement")
+	}
+
+	row := prepare.QueryRowContext(ctx, market.Long, market.Lat, market.Setcens, market.Areap, market.Coddist, market.Distrito, market.Codsubpref,
+		market.Subpref, market.Regiao5, market.Regiao8, market.NomeFeira, market.Registro, market.Logradouro, market.Numero, market.Bairro,
+		market.Referencia, now(), now())
+	if err := row.Err(); err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::Upsert] - constraint violation: %s", mapped.Error()))
+			return valueObjects.MarketValueObjects{}, false, mapped
+		}
+		pst.logger.Error("[MarketRepository::Upsert] query execution error")
+		return valueObjects.MarketValueObjects{}, false, errors.NewInternalError("query execution error")
+	}
+
+	var inserted bool
+	result, err := pst.scan(row, &inserted)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Upsert] - scanning the result failure")
+		return valueObjects.MarketValueObjects{}, false, err
+	}
+
+	if inserted {
+		pst.audit("Create", models.MarketID{Int: result.ID, UUID: result.UUID}, result.Registro)
+	} else {
+		pst.audit("Update", models.MarketID{Int: result.ID, UUID: result.UUID}, result.Registro)
+	}
+
+	return result, inserted, nil
+}
+
+// createManyChunk keeps each INSERT at 9000 parameters, well under the 65535 Postgres accepts.
+const createManyChunk = 500
+
+const insertMarketsColumns = `INSERT INTO feiras
+	(long, lat, setcens, areap, coddist, distrito, codsubpref, subpref, regiao5, regiao8, nome_feira, registro, logradouro, numero,
+		bairro, referencia, criado_em, atualizado_em)
+	VALUES `
+
+// CreateMany inserts the markets with one multi-row INSERT per chunk, all in a single transaction, so
+// either every market is created or none is.
+func (pst marketRepository) CreateMany(ctx context.Context, markets []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if len(markets) == 0 {
+		return []valueObjects.MarketValueObjects{}, nil
+	}
+
+	ctx, dispose := pst.instrument(ctx, "CreateMany", "INSERT INTO feiras MANY", insertMarketsColumns)
+	defer dispose()
+
+	var results []valueObjects.MarketValueObjects
+	err := pst.withTx(ctx, "CreateMany", func(txRepo marketRepository) error {
+		results = make([]valueObjects.MarketValueObjects, 0, len(markets))
+		for start := 0; start < len(markets); start += createManyChunk {
+			end := start + createManyChunk
+			if end > len(markets) {
+				end = len(markets)
+			}
+
+			created, err := txRepo.insertChunk(ctx, txRepo.tx, markets[start:end])
+			if err != nil {
+				return err
+			}
+			results = append(results, created...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pst.logger.Info(fmt.Sprintf("[MarketRepository::CreateMany] - %d markets created", len(results)))
+
+	return results, nil
+}
+
+// WithTx runs fn with a repository bound to a single transaction, committed when fn returns nil and
+// rolled back otherwise. Called on a repository already bound to a transaction, fn joins it. At most
+// DB_MAX_CONCURRENT_TX transactions are open at once, the others wait for a slot up to DB_TX_ACQUIRE_TIMEOUT.
+func (pst marketRepository) WithTx(ctx context.Context, fn func(repo interfaces.IMarketRepository) error) error {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	return pst.withTx(ctx, "WithTx", func(txRepo marketRepository) error {
+		return fn(txRepo)
+	})
+}
+
+func (pst marketRepository) withTx(ctx context.Context, method string, fn func(txRepo marketRepository) error) error {
+	return pst.withTxOptions(ctx, method, nil, fn)
+}
+
+func (pst marketRepository) withTxOptions(ctx context.Context, method string, opts *sql.TxOptions, fn func(txRepo marketRepository) error) error {
+	if pst.tx != nil {
+		return fn(pst)
+	}
+
+	release, err := pst.txLimit.acquire(ctx)
+	if err != nil {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::%s] - too many concurrent transactions: %s", method, err.Error()))
+		return errors.NewBackpressureError("too many concurrent transactions", pst.txLimit.timeout)
+	}
+	defer release()
+
+	tx, err := pst.db.BeginTx(ctx, opts)
+	if err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] Error in begin transaction", method))
+		return errors.NewInternalError("error in begin transaction")
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.Rollback()
+			panic(recovered)
+		}
+	}()
+
+	txRepo := pst
+	txRepo.tx = tx
+	if err := fn(txRepo); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] - commit failure: %s", method, err.Error()))
+		return errors.NewInternalError("error in commit transaction")
+	}
+
+	return nil
+}
+
+func (pst marketRepository) insertChunk(ctx context.Context, tx *sql.Tx, markets []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	values := make([]string, 0, len(markets))
+	args := make([]interface{}, 0, len(markets)*18)
+	for i, m := range markets {
+		placeholders := make([]string, 18)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*18+j+1)
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref, m.Subpref, m.Regiao5, m.Regiao8,
+			m.NomeFeira, m.Registro, m.Logradouro, m.Numero, m.Bairro, m.Referencia, now(), now())
+	}
+
+	rows, err := tx.QueryContext(ctx, insertMarketsColumns+strings.Join(values, ", ")+" RETURNING *", args...)
+	if err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::CreateMany] - constraint violation: %s", mapped.Error()))
+			return nil, mapped
+		}
+		pst.logger.Error("[MarketRepository::CreateMany] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	created := make([]valueObjects.MarketValueObjects, 0, len(markets))
+	for rows.Next() {
+		result, err := pst.scan(rows)
+		if err != nil {
+			pst.logger.Error("[MarketRepository::CreateMany] - scanning the result failure")
+			return nil, err
+		}
+		created = append(created, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::CreateMany] - constraint violation: %s", mapped.Error()))
+			return nil, mapped
+		}
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::CreateMany] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return created, nil
+}
+
+func (pst marketRepository) Find(ctx context.Context, market valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if pst.maxResults > 0 {
+		count, err := pst.Count(ctx, market)
+		if err != nil {
+			return nil, err
+		}
+		if count > pst.maxResults {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::Find] - %d results above the limit of %d", count, pst.maxResults))
+			return nil, errors.NewValidationError(fmt.Sprintf("the query matches %d markets, more than the limit of %d - narrow the filters or use the paginated endpoints", count, pst.maxResults))
+		}
+	}
+
+	sql := selectMarkets + " WHERE deletado_em IS NULL"
+
+	ctx, dispose := pst.instrument(ctx, "Find", "SELECT FROM feiras", sql)
+	defer dispose()
+
+	where, fields := buildQuery("AND", "", market)
+	sql += where
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Find] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, fields...)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Find] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("Find", rows)
+}
+
+func (pst marketRepository) FindByID(ctx context.Context, id int) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if err := pst.integerIDOnly("FindByID"); err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	markets, err := pst.findOne(ctx, "FindByID", "id", id)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+	if len(markets) == 0 {
+		return valueObjects.MarketValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Market with the ID: %d was not found", id))
+	}
+
+	return markets[0], nil
+}
+
+func (pst marketRepository) FindByRegistro(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	markets, err := pst.findOne(ctx, "FindByRegistro", "registro", registro)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+	if len(markets) == 0 {
+		return valueObjects.MarketValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Market with the RegisterCode: %s was not found", registro))
+	}
+
+	return markets[0], nil
+}
+
+// FindByUUID is FindByID for the feiras created with the uuid id type.
+func (pst marketRepository) FindByUUID(ctx context.Context, uuid string) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	markets, err := pst.findOne(ctx, "FindByUUID", "id", uuid)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+	if len(markets) == 0 {
+		return valueObjects.MarketValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Market with the ID: %s was not found", uuid))
+	}
+
+	return markets[0], nil
+}
+
+// findOne looks up the active feira by a unique column, which only ever comes from FindByID, FindByUUID and FindByRegistro.
+func (pst marketRepository) findOne(ctx context.Context, method, column string, value interface{}) ([]valueObjects.MarketValueObjects, error) {
+	sql := selectMarkets + " WHERE " + column + " = $1 AND deletado_em IS NULL LIMIT 1"
+
+	ctx, dispose := pst.instrument(ctx, method, "SELECT FROM feiras BY "+column, sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] Error in prepare statement", method))
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, value)
+	if err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] query execution error", method))
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets(method, rows)
+}
+
+// FindPage is the paginated Find: the limit defaults to defaultPageSize and is capped at MAX_PAGE_SIZE.
+// With pagination.Snapshot the count and the page run in a REPEATABLE READ transaction, unless the
+// repository is already bound to one.
+func (pst marketRepository) FindPage(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if !pagination.Snapshot {
+		return pst.findPage(ctx, filter, pagination)
+	}
+
+	var page valueObjects.MarketPage
+	err := pst.withTxOptions(ctx, "FindPage", snapshotTxOptions, func(txRepo marketRepository) error {
+		var err error
+		page, err = txRepo.findPage(ctx, filter, pagination)
+		return err
+	})
+
+	return page, err
+}
+
+func (pst marketRepository) findPage(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error) {
+	order, err := pst.pageOrder(pagination)
+	if err != nil {
+		return valueObjects.MarketPage{}, err
+	}
+
+	if pagination.Limit < 1 {
+		pagination.Limit = defaultPageSize
+	}
+	if pagination.Limit > pst.maxPage {
+		pagination.Limit = pst.maxPage
+	}
+	if pagination.Offset < 0 {
+		pagination.Offset = 0
+	}
+
+	total, err := pst.Count(ctx, filter)
+	if err != nil {
+		return valueObjects.MarketPage{}, err
+	}
+
+	page := valueObjects.MarketPage{Markets: []valueObjects.MarketValueObjects{}, Total: total, Limit: pagination.Limit, Offset: pagination.Offset}
+	if total == 0 {
+		return page, nil
+	}
+
+	sql := selectMarkets + " WHERE deletado_em IS NULL"
+
+	ctx, dispose := pst.instrument(ctx, "FindPage", "SELECT FROM feiras PAGED", sql)
+	defer dispose()
+
+	where, fields := buildQuery("AND", "", filter)
+	sql += where + fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", order, len(fields)+1, len(fields)+2)
+	fields = append(fields, pagination.Limit, pagination.Offset)
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindPage] Error in prepare statement")
+		return valueObjects.MarketPage{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, fields...)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindPage] query execution error")
+		return valueObjects.MarketPage{}, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	markets, err := pst.scanMarkets("FindPage", rows)
+	if err != nil {
+		return valueObjects.MarketPage{}, err
+	}
+	page.Markets = append(page.Markets, markets...)
+
+	return page, nil
+}
+
+// FindSummaries selects only the columns needed to pin the markets on a map.
+func (pst marketRepository) FindSummaries(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketSummary, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := "SELECT id, nome_feira, long, lat FROM feiras WHERE deletado_em IS NULL"
+
+	ctx, dispose := pst.instrument(ctx, "FindSummaries", "SELECT feiras summaries", sql)
+	defer dispose()
+
+	where, fields := buildQuery("AND", "", filter)
+	sql += where + " ORDER BY id ASC"
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindSummaries] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, fields...)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindSummaries] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	summaries := []valueObjects.MarketSummary{}
+	for rows.Next() {
+		var summary valueObjects.MarketSummary
+		var id models.MarketID
+		if err := rows.Scan(&id, &summary.NomeFeira, &summary.Long, &summary.Lat); err != nil {
+			pst.logger.Error("[MarketRepository::FindSummaries] - scanning the result failure")
+			return nil, errors.NewInternalError("error in scanning the results")
+		}
+		summary.ID, summary.UUID = id.Int, id.UUID
+
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::FindSummaries] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return summaries, nil
+}
+
+// FindForPublic is Find for public data sharing: logradouro and referencia are neither selected nor
+// accepted as filters, so they can not be probed through the filter either.
+func (pst marketRepository) FindForPublic(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `SELECT id, long, lat, setcens, areap, coddist, distrito, codsubpref, subpref, regiao5, regiao8, nome_feira, registro,
+		numero, bairro FROM feiras WHERE deletado_em IS NULL`
+
+	ctx, dispose := pst.instrument(ctx, "FindForPublic", "SELECT feiras for public", sql)
+	defer dispose()
+
+	filter.Logradouro, filter.LogradouroContains, filter.Referencia = "", "", ""
+	where, fields := buildQuery("AND", "", filter)
+	sql += where + " ORDER BY id ASC"
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindForPublic] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, fields...)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindForPublic] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	markets := []valueObjects.MarketValueObjects{}
+	for rows.Next() {
+		var m valueObjects.MarketValueObjects
+		var id models.MarketID
+		if err := rows.Scan(&id, &m.Long, &m.Lat, &m.Setcens, &m.Areap, &m.Coddist, &m.Distrito, &m.Codsubpref, &m.Subpref, &m.Regiao5,
+			&m.Regiao8, &m.NomeFeira, &m.Registro, &m.Numero, &m.Bairro); err != nil {
+			pst.logger.Error("[MarketRepository::FindForPublic] - scanning the result failure")
+			return nil, errors.NewInternalError("error in scanning the results")
+		}
+		m.ID, m.UUID = id.Int, id.UUID
+
+		markets = append(markets, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::FindForPublic] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return markets, nil
+}
+
+func (pst marketRepository) Count(ctx context.Context, market valueObjects.MarketValueObjects) (int64, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := "SELECT COUNT(*) FROM feiras WHERE deletado_em IS NULL"
+
+	ctx, dispose := pst.instrument(ctx, "Count", "COUNT feiras", sql)
+	defer dispose()
+
+	where, fields := buildQuery("AND", "", market)
+	sql += where
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Count] Error in prepare statement")
+		return 0, errors.NewInternalError("error in prepare statement")
+	}
+
+	var count int64
+	if err := prepare.QueryRowContext(ctx, fields...).Scan(&count); err != nil {
+		pst.logger.Error("[MarketRepository::Count] query execution error")
+		return 0, errors.NewInternalError("query execution error")
+	}
+
+	return count, nil
+}
+
+func (pst marketRepository) Update(ctx context.Context, registerCode string, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `UPDATE feiras  SET `
+
+	if err := market.ValidatePartial(); err != nil {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::Update] - invalid market: %s", err.Error()))
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	ctx, diapose := pst.instrument(ctx, "Update", "UPDATE feiras", sql)
+	defer diapose()
+
+	set, fields := buildQuery("", ",", market)
+	fields = append(fields, registerCode)
+	set = set[:len(set)-1]
+	set += fmt.Sprintf(" WHERE registro = $%v RETURNING feiras.*", len(fields))
+	sql += set
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Update] Error in prepare statement")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	row, err := pst.queryRowWithRetry(ctx, "Update", prepare, fields...)
+	if err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::Update] - constraint violation: %s", mapped.Error()))
+			return valueObjects.MarketValueObjects{}, mapped
+		}
+		pst.logger.Error("[MarketRepository::Update] query execution error")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error")
+	}
+
+	result, err := pst.scan(row)
+	if isConstraintError(err) {
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::Update] - constraint violation: %s", err.Error()))
+		return valueObjects.MarketValueObjects{}, err
+	}
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Update] - scanning the result failure")
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.audit("Update", models.MarketID{Int: result.ID, UUID: result.UUID}, result.Registro)
+
+	return result, nil
+}
+
+// UpdateByID writes the non-zero fields of market on the active feira with market.ID, or market.UUID with
+// DB_ID_TYPE=uuid, and refreshes atualizado_em.
+func (pst marketRepository) UpdateByID(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	var id interface{} = market.ID
+	if pst.uuidIDs {
+		if market.UUID == "" {
+			return valueObjects.MarketValueObjects{}, pst.integerIDOnly("UpdateByID")
+		}
+		id = market.UUID
+	}
+
+	sql := `UPDATE feiras SET`
+
+	ctx, dispose := pst.instrument(ctx, "UpdateByID", "UPDATE feiras BY id", sql)
+	defer dispose()
+
+	set, fields := buildQuery("", ",", market)
+	fields = append(fields, now(), id)
+	sql += fmt.Sprintf("%s atualizado_em = $%v WHERE id = $%v AND deletado_em IS NULL RETURNING feiras.*", set, len(fields)-1, len(fields))
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::UpdateByID] Error in prepare statement")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := pst.queryWithRetry(ctx, "UpdateByID", prepare, fields...)
+	if err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::UpdateByID] - constraint violation: %s", mapped.Error()))
+			return valueObjects.MarketValueObjects{}, mapped
+		}
+		pst.logger.Error("[MarketRepository::UpdateByID] query execution error")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			if mapped := constraintError(err); mapped != nil {
+				pst.logger.Warn(fmt.Sprintf("[MarketRepository::UpdateByID] - constraint violation: %s", mapped.Error()))
+				return valueObjects.MarketValueObjects{}, mapped
+			}
+			pst.logger.Error(fmt.Sprintf("[MarketRepository::UpdateByID] - reading the results failure: %s", err.Error()))
+			return valueObjects.MarketValueObjects{}, errors.NewInternalError("error while reading the results")
+		}
+		return valueObjects.MarketValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Market with the ID: %v was not found", id))
+	}
+
+	result, err := pst.scan(rows)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::UpdateByID] - scanning the result failure")
+		return valueObjects.MarketValueObjects{}, err
+	}
+
+	pst.audit("UpdateByID", models.MarketID{Int: result.ID, UUID: result.UUID}, result.Registro)
+
+	return result, nil
+}
+
+func (pst marketRepository) Delete(ctx context.Context, registerCode string) error {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `UPDATE feiras SET deletado_em = $1 WHERE registro = $2 AND deletado_em IS NULL RETURNING id`
+
+	ctx, dispose := pst.instrument(ctx, "Delete", "SOFTDELETE feiras", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Delete] Error in prepare statement")
+		return errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := pst.queryWithRetry(ctx, "Delete", prepare, now(), registerCode)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Delete] query execution error")
+		return errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	deleted := 0
+	for rows.Next() {
+		var id models.MarketID
+		if err := rows.Scan(&id); err != nil {
+			pst.logger.Error("[MarketRepository::Delete] - scanning the result failure")
+			return errors.NewInternalError("error in scanning the results")
+		}
+
+		pst.audit("Delete", id, registerCode)
+		deleted++
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::Delete] - reading the results failure: %s", err.Error()))
+		return errors.NewInternalError("error while reading the results")
+	}
+
+	if deleted == 0 {
+		return errors.ErrMarketNotFound
+	}
+
+	return nil
+}
+
+// Restore brings back the soft-deleted feira with the registro. A registro that is missing or already active
+// is not found.
+func (pst marketRepository) Restore(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `UPDATE feiras SET deletado_em = NULL, atualizado_em = $1 WHERE registro = $2 AND deletado_em IS NOT NULL RETURNING *`
+
+	ctx, dispose := pst.instrument(ctx, "Restore", "RESTORE feiras", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Restore] Error in prepare statement")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, now(), registro)
+	if err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			pst.logger.Warn(fmt.Sprintf("[MarketRepository::Restore] - constraint violation: %s", mapped.Error()))
+			return valueObjects.MarketValueObjects{}, mapped
+		}
+		pst.logger.Error("[MarketRepository::Restore] query execution error")
+		return valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	markets, err := pst.scanMarkets("Restore", rows)
+	if err != nil {
+		return valueObjects.MarketValueObjects{}, err
+	}
+	if len(markets) == 0 {
+		return valueObjects.MarketValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Deleted market with the RegisterCode: %s was not found", registro))
+	}
+
+	pst.audit("Restore", models.MarketID{Int: markets[0].ID, UUID: markets[0].UUID}, markets[0].Registro)
+
+	return markets[0], nil
+}
+
+// DedupeByProximity soft-deletes every active market with an older active market (by criado_em, then id)
+// within thresholdMeters, so of each cluster of near-duplicates only the earliest created is kept.
+func (pst marketRepository) DedupeByProximity(ctx context.Context, thresholdMeters float64) (int64, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if thresholdMeters <= 0 {
+		return 0, errors.NewValidationError("threshold must be greater than zero")
+	}
+
+	sql := `UPDATE feiras SET deletado_em = $1
+		WHERE deletado_em IS NULL AND EXISTS (
+			SELECT 1 FROM (
+				SELECT id AS target_id, long AS target_long, lat AS target_lat, criado_em AS target_criado_em
+				FROM feiras WHERE deletado_em IS NULL
+			) AS target
+			WHERE (target_criado_em, target_id) < (criado_em, id) AND ` + distanceFromTarget + ` <= $2
+		)
+		RETURNING id, registro`
+
+	ctx, dispose := pst.instrument(ctx, "DedupeByProximity", "SOFTDELETE feiras BY proximity", sql)
+	defer dispose()
+
+	type deletedMarket struct {
+		id       models.MarketID
+		registro string
+	}
+	var deleted []deletedMarket
+
+	err := pst.withTx(ctx, "DedupeByProximity", func(txRepo marketRepository) error {
+		prepare, err := txRepo.prepare(ctx, sql)
+		if err != nil {
+			pst.logger.Error("[MarketRepository::DedupeByProximity] Error in prepare statement")
+			return errors.NewInternalError("error in prepare statement")
+		}
+
+		rows, err := prepare.QueryContext(ctx, now(), thresholdMeters)
+		if err != nil {
+			pst.logger.Error("[MarketRepository::DedupeByProximity] query execution error")
+			return errors.NewInternalError("query execution error")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var market deletedMarket
+			if err := rows.Scan(&market.id, &market.registro); err != nil {
+				pst.logger.Error("[MarketRepository::DedupeByProximity] - scanning the result failure")
+				return errors.NewInternalError("error in scanning the results")
+			}
+			deleted = append(deleted, market)
+		}
+
+		if err := rows.Err(); err != nil {
+			pst.logger.Error(fmt.Sprintf("[MarketRepository::DedupeByProximity] - reading the results failure: %s", err.Error()))
+			return errors.NewInternalError("error while reading the results")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, market := range deleted {
+		pst.audit("DedupeByProximity", market.id, market.registro)
+	}
+
+	return int64(len(deleted)), nil
+}
+
+// audit logs the successful writes at info level, so operators keep a trail of them unless LOG_LEVEL is above info.
+// The id is logged as the uuid with DB_ID_TYPE=uuid.
+func (pst marketRepository) audit(operation string, id models.MarketID, registro string) {
+	idField := zap.Int("id", id.Int)
+	if id.UUID != "" {
+		idField = zap.String("id", id.UUID)
+	}
+
+	pst.logger.Info(fmt.Sprintf("[MarketRepository::%s] - success", operation),
+		zap.String("operation", strings.ToLower(operation)),
+		idField,
+		zap.String("registro", registro),
+	)
+}
+
+// integerIDOnly fails fast the methods keyed on the integer id with DB_ID_TYPE=uuid, where no integer
+// matches the id column. FindByUUID and FindAfterUUID are their uuid counterparts.
+func (pst marketRepository) integerIDOnly(method string) error {
+	if !pst.uuidIDs {
+		return nil
+	}
+
+	return errors.NewValidationError(fmt.Sprintf("%s looks markets up by the integer id, which DB_ID_TYPE=uuid does not have", method))
+}
+
+func (pst marketRepository) Touch(ctx context.Context, id int) error {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if err := pst.integerIDOnly("Touch"); err != nil {
+		return err
+	}
+
+	query := `UPDATE feiras SET atualizado_em = $1 WHERE id = $2 AND deletado_em IS NULL RETURNING id`
+
+	ctx, dispose := pst.instrument(ctx, "Touch", "TOUCH feiras", query)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, query)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Touch] Error in prepare statement")
+		return errors.NewInternalError("error in prepare statement")
+	}
+
+	var touched int
+	err = prepare.QueryRowContext(ctx, now(), id).Scan(&touched)
+	if err == sql.ErrNoRows {
+		return errors.NewNotFoundError(fmt.Sprintf("Market with the ID: %d was not found", id))
+	}
+	if err != nil {
+		pst.logger.Error("[MarketRepository::Touch] query execution error")
+		return errors.NewInternalError("query execution error")
+	}
+
+	return nil
+}
+
+func (pst marketRepository) FindByDistritoPaged(ctx context.Context, distrito string, page, pageSize int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := selectMarkets + `
+		WHERE deletado_em IS NULL AND distrito = $1
+		ORDER BY ` + pst.nameOrder() + `, id ASC
+		LIMIT $2 OFFSET $3`
+
+	ctx, dispose := pst.instrument(ctx, "FindByDistritoPaged", "SELECT FROM feiras BY distrito", sql)
+	defer dispose()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > pst.maxPage {
+		pageSize = pst.maxPage
+	}
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByDistritoPaged] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, distrito, pageSize, (page-1)*pageSize)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByDistritoPaged] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("FindByDistritoPaged", rows)
+}
+
+// FindByCoordinates compares the INT columns as float8 so a non-integral coordinate
+// does not match instead of failing the parameter cast.
+func (pst marketRepository) FindByCoordinates(ctx context.Context, long, lat float64) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := selectMarkets + `
+		WHERE deletado_em IS NULL AND long::float8 = $1 AND lat::float8 = $2
+		ORDER BY id ASC`
+
+	ctx, dispose := pst.instrument(ctx, "FindByCoordinates", "SELECT FROM feiras BY coordinates", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByCoordinates] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, long, lat)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByCoordinates] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("FindByCoordinates", rows)
+}
+
+// distanceFromTarget is the haversine distance in meters between a market and the target CTE. The
+// coordinates are stored as integer micro-degrees.
+const distanceFromTarget = `6371000 * 2 * asin(sqrt(
+		power(sin(radians((lat - target_lat) / 1000000.0) / 2), 2) +
+		cos(radians(target_lat / 1000000.0)) * cos(radians(lat / 1000000.0)) *
+		power(sin(radians((long - target_long) / 1000000.0) / 2), 2)))`
+
+func (pst marketRepository) FindWithNeighbors(ctx context.Context, id int, radiusMeters float64, limit int) (valueObjects.MarketNeighborsValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if err := pst.integerIDOnly("FindWithNeighbors"); err != nil {
+		return valueObjects.MarketNeighborsValueObjects{}, err
+	}
+
+	if radiusMeters <= 0 {
+		return valueObjects.MarketNeighborsValueObjects{}, errors.NewValidationError("radius must be greater than zero")
+	}
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	sql := `WITH target AS (SELECT long AS target_long, lat AS target_lat FROM feiras WHERE id = $1 AND deletado_em IS NULL) ` +
+		selectMarkets + `, target
+		WHERE deletado_em IS NULL AND (id = $1 OR ` + distanceFromTarget + ` <= $2)
+		ORDER BY id = $1 DESC, ` + distanceFromTarget + ` ASC, id ASC
+		LIMIT $3`
+
+	ctx, dispose := pst.instrument(ctx, "FindWithNeighbors", "SELECT FROM feiras WITH neighbors", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindWithNeighbors] Error in prepare statement")
+		return valueObjects.MarketNeighborsValueObjects{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, id, radiusMeters, limit+1)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindWithNeighbors] query execution error")
+		return valueObjects.MarketNeighborsValueObjects{}, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	markets, err := pst.scanMarkets("FindWithNeighbors", rows)
+	if err != nil {
+		return valueObjects.MarketNeighborsValueObjects{}, err
+	}
+
+	if len(markets) == 0 || markets[0].ID != id {
+		return valueObjects.MarketNeighborsValueObjects{}, errors.NewNotFoundError(fmt.Sprintf("Market with the ID: %d was not found", id))
+	}
+
+	return valueObjects.MarketNeighborsValueObjects{Market: markets[0], Neighbors: markets[1:]}, nil
+}
+
+// microDegreesPerKm is how many micro-degrees of latitude a kilometer spans on the haversine sphere.
+const microDegreesPerKm = 1000000 / (6371 * math.Pi / 180)
+
+// FindNearby lists the markets within radiusKm of the point, nearest first, with their distance. lat and long
+// are micro-degrees like the stored coordinates. The haversine formula assumes a spherical earth, so the
+// distances are off by up to 0.5% against the WGS84 ellipsoid, a few meters at city scale; PostGIS would be
+// exact but is not required. A bounding box around the point discards most rows before the formula runs.
+func (pst marketRepository) FindNearby(ctx context.Context, lat, long float64, radiusKm float64, limit int) ([]valueObjects.MarketDistance, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if radiusKm <= 0 {
+		return nil, errors.NewValidationError("radius must be greater than zero")
+	}
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	sql := `WITH target AS (SELECT $1::float8 AS target_lat, $2::float8 AS target_long) ` +
+		strings.Replace(selectMarkets, "deletado_em AS DeletadoEm", "deletado_em AS DeletadoEm,\n\t\t"+distanceFromTarget+" AS distance", 1) + `, target
+		WHERE deletado_em IS NULL AND lat BETWEEN $3 AND $4 AND long BETWEEN $5 AND $6 AND ` + distanceFromTarget + ` <= $7
+		ORDER BY distance ASC, id ASC
+		LIMIT $8`
+
+	ctx, dispose := pst.instrument(ctx, "FindNearby", "SELECT FROM feiras NEARBY", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindNearby] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	minLat, maxLat, minLong, maxLong := boundingBox(lat, long, radiusKm)
+	rows, err := prepare.QueryContext(ctx, lat, long, minLat, maxLat, minLong, maxLong, radiusKm*1000, limit)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindNearby] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	results := []valueObjects.MarketDistance{}
+	for rows.Next() {
+		var meters float64
+		market, err := pst.scan(rows, &meters)
+		if err != nil {
+			pst.logger.Error("[MarketRepository::FindNearby] - scanning the result failure")
+			return nil, err
+		}
+
+		results = append(results, valueObjects.MarketDistance{Market: market, DistanceKm: meters / 1000})
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::FindNearby] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return results, nil
+}
+
+// boundingBox is the smallest box, in micro-degrees, holding the circle of radiusKm around the point. Near the
+// poles, or across the antimeridian, it spans every longitude.
+func boundingBox(lat, long, radiusKm float64) (minLat, maxLat, minLong, maxLong float64) {
+	latDelta := radiusKm * microDegreesPerKm
+	minLat, maxLat = math.Max(lat-latDelta, -90000000), math.Min(lat+latDelta, 90000000)
+
+	cos := math.Cos(lat / 1000000 * math.Pi / 180)
+	if cos < 1e-6 || latDelta/cos >= 180000000 || long-latDelta/cos < -180000000 || long+latDelta/cos > 180000000 {
+		return minLat, maxLat, -180000000, 180000000
+	}
+
+	return minLat, maxLat, long - latDelta/cos, long + latDelta/cos
+}
+
+// FindSiblingsBySubpref returns the other active markets in the same subprefeitura as the market with the id.
+// Like FindWithNeighbors, the market itself is read first to tell a missing id from one without siblings.
+func (pst marketRepository) FindSiblingsBySubpref(ctx context.Context, id int, limit int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if err := pst.integerIDOnly("FindSiblingsBySubpref"); err != nil {
+		return nil, err
+	}
+
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	sql := `WITH target AS (SELECT codsubpref AS target_subpref FROM feiras WHERE id = $1 AND deletado_em IS NULL) ` +
+		selectMarkets + `, target
+		WHERE deletado_em IS NULL AND codsubpref = target_subpref
+		ORDER BY id = $1 DESC, ` + pst.nameOrder() + `, id ASC
+		LIMIT $2`
+
+	ctx, dispose := pst.instrument(ctx, "FindSiblingsBySubpref", "SELECT FROM feiras BY subpref", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindSiblingsBySubpref] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, id, limit+1)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindSiblingsBySubpref] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	markets, err := pst.scanMarkets("FindSiblingsBySubpref", rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(markets) == 0 || markets[0].ID != id {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("Market with the ID: %d was not found", id))
+	}
+
+	return markets[1:], nil
+}
+
+const maxRoutePoints = 25
+
+// FindNearestToPoints orders the markets by the distance to the closest of the points, breaking ties by
+// the sum of the distances to all of them, so the markets along a multi-stop route come first.
+func (pst marketRepository) FindNearestToPoints(ctx context.Context, points []valueObjects.Coordinate, limit int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if len(points) == 0 {
+		return nil, errors.NewValidationError("at least one point is required")
+	}
+	if len(points) > maxRoutePoints {
+		return nil, errors.NewValidationError(fmt.Sprintf("at most %d points are allowed", maxRoutePoints))
+	}
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	values := make([]string, 0, len(points))
+	args := make([]interface{}, 0, len(points)*2+1)
+	for i, point := range points {
+		values = append(values, fmt.Sprintf("($%d::float8, $%d::float8)", i*2+1, i*2+2))
+		args = append(args, point.Long, point.Lat)
+	}
+	args = append(args, limit)
+
+	sql := selectMarkets + `, LATERAL (
+			SELECT MIN(distance) AS nearest, SUM(distance) AS total FROM (
+				SELECT ` + distanceFromTarget + ` AS distance
+				FROM (VALUES ` + strings.Join(values, ", ") + `) AS target (target_long, target_lat)
+			) AS distances
+		) AS route
+		WHERE deletado_em IS NULL
+		ORDER BY route.nearest ASC, route.total ASC, id ASC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	ctx, dispose := pst.instrument(ctx, "FindNearestToPoints", "SELECT FROM feiras NEAREST TO points", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindNearestToPoints] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, args...)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindNearestToPoints] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("FindNearestToPoints", rows)
+}
+
+func (pst marketRepository) FindCoordinateCollisions(ctx context.Context) ([]valueObjects.CoordinateGroup, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `SELECT long, lat, COUNT(*), array_agg(registro ORDER BY registro)
+		FROM feiras
+		WHERE deletado_em IS NULL
+		GROUP BY long, lat
+		HAVING COUNT(*) > 1
+		ORDER BY long ASC, lat ASC`
+
+	ctx, dispose := pst.instrument(ctx, "FindCoordinateCollisions", "SELECT feiras coordinate collisions", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindCoordinateCollisions] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindCoordinateCollisions] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	groups := []valueObjects.CoordinateGroup{}
+	for rows.Next() {
+		var group valueObjects.CoordinateGroup
+		if err := rows.Scan(&group.Long, &group.Lat, &group.Count, pq.Array(&group.Registros)); err != nil {
+			pst.logger.Error("[MarketRepository::FindCoordinateCollisions] - scanning the result failure")
+			return nil, errors.NewInternalError("error in scanning the results")
+		}
+
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::FindCoordinateCollisions] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return groups, nil
+}
+
+// FindAfterID reads the active markets after the id, in id order. With DB_ID_TYPE=uuid only the first page,
+// lastID 0, is read here and the next ones with FindAfterUUID.
+func (pst marketRepository) FindAfterID(ctx context.Context, lastID, limit int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if pst.uuidIDs && lastID == 0 {
+		return pst.FindAfterUUID(ctx, "", limit)
+	}
+	if err := pst.integerIDOnly("FindAfterID"); err != nil {
+		return nil, err
+	}
+
+	sql := selectMarkets + `
+		WHERE deletado_em IS NULL AND id > $1
+		ORDER BY id ASC
+		LIMIT $2`
+
+	ctx, dispose := pst.instrument(ctx, "FindAfterID", "SELECT FROM feiras AFTER id", sql)
+	defer dispose()
+
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindAfterID] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, lastID, limit)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindAfterID] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("FindAfterID", rows)
+}
+
+// FindAfterUUID is FindAfterID for DB_ID_TYPE=uuid, an empty lastUUID reading the first page. The uuids are
+// random, so the id order is a stable cursor but not the creation order.
+func (pst marketRepository) FindAfterUUID(ctx context.Context, lastUUID string, limit int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if !pst.uuidIDs {
+		return nil, errors.NewValidationError("FindAfterUUID looks markets up by the uuid, which needs DB_ID_TYPE=uuid")
+	}
+
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	sql := selectMarkets + `
+		WHERE deletado_em IS NULL AND id > $1
+		ORDER BY id ASC
+		LIMIT $2`
+	args := []interface{}{lastUUID, limit}
+	if lastUUID == "" {
+		sql = selectMarkets + `
+		WHERE deletado_em IS NULL
+		ORDER BY id ASC
+		LIMIT $1`
+		args = args[1:]
+	}
+
+	ctx, dispose := pst.instrument(ctx, "FindAfterUUID", "SELECT FROM feiras AFTER id", sql)
 	defer dispose()
 
-	prepare, err := pst.db.PrepareContext(ctx, sql)
+	prepare, err := pst.prepare(ctx, sql)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Create] Error in prepare statement")
-		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+		pst.logger.Error("[MarketRepository::FindAfterUUID] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
 	}
 
-	row := prepare.QueryRowContext(ctx, market.Long, market.Lat, market.Setcens, market.Areap, market.Coddist, market.Distrito, market.Codsubpref,
-		market.Subpref, market.Regiao5, market.Regiao8, market.NomeFeira, market.Registro, market.Logradouro, market.Numero, market.Bairro,
-		market.Referencia, now(), now())
-	if row.Err() != nil {
-		pst.logger.Error("[MarketRepository::Create] query execution error")
-		return valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error")
+	rows, err := prepare.QueryContext(ctx, args...)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindAfterUUID] query execution error")
+		return nil, errors.NewInternalError("query execution error")
 	}
+	defer rows.Close()
 
-	result, err := pst.scan(row)
+	return pst.scanMarkets("FindAfterUUID", rows)
+}
+
+// FindMissingRegistro lists the active markets whose registro, the business key, is NULL or blank. The
+// NULLs are read as an empty registro.
+func (pst marketRepository) FindMissingRegistro(ctx context.Context, limit int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := strings.Replace(selectMarkets, "registro AS Registro", "COALESCE(registro, '') AS Registro", 1) + `
+		WHERE deletado_em IS NULL AND (registro IS NULL OR TRIM(registro) = '')
+		ORDER BY id ASC
+		LIMIT $1`
+
+	ctx, dispose := pst.instrument(ctx, "FindMissingRegistro", "SELECT FROM feiras WITHOUT registro", sql)
+	defer dispose()
+
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+
+	prepare, err := pst.prepare(ctx, sql)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Create] - scanning the result failure")
-		return valueObjects.MarketValueObjects{}, err
+		pst.logger.Error("[MarketRepository::FindMissingRegistro] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
 	}
 
-	return result, nil
+	rows, err := prepare.QueryContext(ctx, limit)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindMissingRegistro] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("FindMissingRegistro", rows)
 }
 
-func (pst marketRepository) Find(ctx context.Context, market valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
-	sql := `SELECT
-								id AS ID,
-								long AS Long,
-								lat AS Lat,
-								setcens AS Setcens,
-								areap AS Areap,
-								coddist AS Coddist,
-								distrito AS Distrito,
-								codsubpref AS Codsubpref,
-								subpref AS Subpref,
-								regiao5 AS Regiao5,
-								regiao8 AS Regiao8,
-								nome_feira AS NomeFeira,
-								registro AS Registro,
-								logradouro AS Logradouro,
-								numero AS Numero,
-								bairro AS Bairro,
-								referencia AS Referencia,
-								criado_em AS CriadoEm,
-								atualizado_em AS AtualizadoEm,
-								deletado_em AS DeletadoEm
-					FROM feiras
-					WHERE deletado_em IS NULL`
-
-	dispose := instrument(ctx, "SELECT FROM feiras", sql)
+func (pst marketRepository) FindCreatedBetween(ctx context.Context, from, to time.Time, limit, offset int) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if from.After(to) {
+		return nil, errors.NewValidationError("from must be before or equal to to")
+	}
+
+	sql := selectMarkets + `
+		WHERE deletado_em IS NULL AND criado_em BETWEEN $1 AND $2
+		ORDER BY criado_em ASC, id ASC
+		LIMIT $3 OFFSET $4`
+
+	ctx, dispose := pst.instrument(ctx, "FindCreatedBetween", "SELECT FROM feiras BY criado_em", sql)
 	defer dispose()
 
-	where, fields := buildQuery("AND", "", market)
-	sql += where
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
 
-	prepare, err := pst.db.PrepareContext(ctx, sql)
+	prepare, err := pst.prepare(ctx, sql)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Find] Error in prepare statement")
+		pst.logger.Error("[MarketRepository::FindCreatedBetween] Error in prepare statement")
 		return nil, errors.NewInternalError("error in prepare statement")
 	}
 
-	rows, err := prepare.QueryContext(ctx, fields...)
+	rows, err := prepare.QueryContext(ctx, from, to, limit, offset)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Find] query execution error")
+		pst.logger.Error("[MarketRepository::FindCreatedBetween] query execution error")
 		return nil, errors.NewInternalError("query execution error")
 	}
+	defer rows.Close()
 
-	var results []valueObjects.MarketValueObjects
+	return pst.scanMarkets("FindCreatedBetween", rows)
+}
+
+func (pst marketRepository) CountCreatedByPeriod(ctx context.Context, granularity string) ([]valueObjects.MarketTimelineValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if !timelineGranularities[granularity] {
+		return nil, errors.NewValidationError(fmt.Sprintf("granularity: %s not allowed", granularity))
+	}
+
+	sql := fmt.Sprintf(`SELECT date_trunc('%s', criado_em) AS period, COUNT(*)
+		FROM feiras
+		WHERE deletado_em IS NULL
+		GROUP BY period
+		ORDER BY period ASC`, granularity)
+
+	ctx, dispose := pst.instrument(ctx, "CountCreatedByPeriod", "COUNT feiras BY criado_em", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::CountCreatedByPeriod] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::CountCreatedByPeriod] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	results := []valueObjects.MarketTimelineValueObjects{}
 	for rows.Next() {
-		result, err := pst.scan(rows)
-		if err != nil {
-			pst.logger.Error("[MarketRepository::Find] - scanning the result failure")
-			return nil, err
+		result := valueObjects.MarketTimelineValueObjects{}
+		if err := rows.Scan(&result.Period, &result.Count); err != nil {
+			pst.logger.Error("[MarketRepository::CountCreatedByPeriod] - scanning the result failure")
+			return nil, errors.NewInternalError("error in scanning the results")
 		}
 
 		results = append(results, result)
 	}
 
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::CountCreatedByPeriod] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
 	return results, nil
 }
 
-func (pst marketRepository) Update(ctx context.Context, registerCode string, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
-	sql := `UPDATE feiras  SET `
+func (pst marketRepository) CountByDistrito(ctx context.Context, distrito string) (int64, error) {
+	pst.logger = pst.logger.WithContext(ctx)
 
-	diapose := instrument(ctx, "UPDATE feiras", sql)
-	defer diapose()
+	sql := `SELECT COUNT(*) FROM feiras WHERE deletado_em IS NULL AND distrito = $1`
 
-	set, fields := buildQuery("", ",", market)
-	fields = append(fields, registerCode)
-	set = set[:len(set)-1]
-	set += fmt.Sprintf(" WHERE registro = $%v RETURNING feiras.*", len(fields))
-	sql += set
+	ctx, dispose := pst.instrument(ctx, "CountByDistrito", "COUNT feiras BY distrito", sql)
+	defer dispose()
 
-	prepare, err := pst.db.PrepareContext(ctx, sql)
+	prepare, err := pst.prepare(ctx, sql)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Update] Error in prepare statement")
-		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in prepare statement")
+		pst.logger.Error("[MarketRepository::CountByDistrito] Error in prepare statement")
+		return 0, errors.NewInternalError("error in prepare statement")
 	}
 
-	row := prepare.QueryRowContext(ctx, fields...)
-	if row.Err() != nil {
-		pst.logger.Error("[MarketRepository::Update] query execution error")
-		return valueObjects.MarketValueObjects{}, errors.NewInternalError("query execution error")
+	var count int64
+	if err := prepare.QueryRowContext(ctx, distrito).Scan(&count); err != nil {
+		pst.logger.Error("[MarketRepository::CountByDistrito] query execution error")
+		return 0, errors.NewInternalError("query execution error")
 	}
 
-	result, err := pst.scan(row)
+	return count, nil
+}
+
+// FindByBairro matches the bairro exactly or, when fuzzy, case-insensitively by contained text so
+// spelling variants such as "VL FORMOSA" and "Vila Formosa" are still found by a partial term.
+func (pst marketRepository) FindByBairro(ctx context.Context, bairro string, fuzzy bool) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if strings.TrimSpace(bairro) == "" {
+		return nil, errors.NewValidationError("bairro is required")
+	}
+
+	sql := selectMarkets + " WHERE deletado_em IS NULL AND bairro = $1 ORDER BY id ASC"
+	arg := bairro
+	if fuzzy {
+		sql = selectMarkets + ` WHERE deletado_em IS NULL AND bairro ILIKE $1 ESCAPE '\' ORDER BY id ASC`
+		arg = containsPattern(bairro)
+	}
+
+	ctx, dispose := pst.instrument(ctx, "FindByBairro", "SELECT FROM feiras BY bairro", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Update] - scanning the result failure")
-		return valueObjects.MarketValueObjects{}, err
+		pst.logger.Error("[MarketRepository::FindByBairro] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, arg)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByBairro] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	return pst.scanMarkets("FindByBairro", rows)
+}
+
+func (pst marketRepository) FindByRegiao8(ctx context.Context, regiao8 string) ([]valueObjects.MarketValueObjects, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if regiao8 == "" {
+		return nil, errors.NewValidationError("regiao8 is required")
+	}
+
+	return pst.Find(ctx, valueObjects.MarketValueObjects{Regiao8: regiao8})
+}
+
+// FindByRegiao8Paged reads a page of the markets in regiao8 along with the regiao8 total, counted inline with a
+// window function so a dashboard widget needs a single round trip. A page past the end has no row to carry the
+// total, so only then it is counted apart.
+func (pst marketRepository) FindByRegiao8Paged(ctx context.Context, regiao8 string, page, pageSize int) (valueObjects.MarketPage, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	if regiao8 == "" {
+		return valueObjects.MarketPage{}, errors.NewValidationError("regiao8 is required")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > pst.maxPage {
+		pageSize = pst.maxPage
+	}
+
+	sql := strings.Replace(selectMarkets, "deletado_em AS DeletadoEm", "deletado_em AS DeletadoEm,\n\t\tCOUNT(*) OVER() AS total", 1) + `
+		WHERE deletado_em IS NULL AND regiao8 = $1
+		ORDER BY ` + pst.nameOrder() + `, id ASC
+		LIMIT $2 OFFSET $3`
+
+	ctx, dispose := pst.instrument(ctx, "FindByRegiao8Paged", "SELECT FROM feiras BY regiao8 PAGED", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByRegiao8Paged] Error in prepare statement")
+		return valueObjects.MarketPage{}, errors.NewInternalError("error in prepare statement")
+	}
+
+	offset := (page - 1) * pageSize
+	rows, err := prepare.QueryContext(ctx, regiao8, pageSize, offset)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::FindByRegiao8Paged] query execution error")
+		return valueObjects.MarketPage{}, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	result := valueObjects.MarketPage{Markets: []valueObjects.MarketValueObjects{}, Limit: pageSize, Offset: offset}
+	for rows.Next() {
+		market, err := pst.scan(rows, &result.Total)
+		if err != nil {
+			pst.logger.Error("[MarketRepository::FindByRegiao8Paged] - scanning the result failure")
+			return valueObjects.MarketPage{}, err
+		}
+
+		result.Markets = append(result.Markets, market)
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::FindByRegiao8Paged] - reading the results failure: %s", err.Error()))
+		return valueObjects.MarketPage{}, errors.NewInternalError("error while reading the results")
+	}
+
+	if len(result.Markets) == 0 && offset > 0 {
+		if result.Total, err = pst.Count(ctx, valueObjects.MarketValueObjects{Regiao8: regiao8}); err != nil {
+			return valueObjects.MarketPage{}, err
+		}
 	}
 
 	return result, nil
 }
 
-func (pst marketRepository) Delete(ctx context.Context, registerCode string) error {
-	sql := `UPDATE feiras SET deletado_em = $1 WHERE registro = $2`
+func (pst marketRepository) CountByRegiao8(ctx context.Context) (map[string]int64, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `SELECT regiao8, COUNT(*)
+		FROM feiras
+		WHERE deletado_em IS NULL
+		GROUP BY regiao8`
 
-	dispose := instrument(ctx, "SOFTDELETE feiras", sql)
+	ctx, dispose := pst.instrument(ctx, "CountByRegiao8", "COUNT feiras BY regiao8", sql)
 	defer dispose()
 
-	prepare, err := pst.db.PrepareContext(ctx, sql)
+	prepare, err := pst.prepare(ctx, sql)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Delete] Error in prepare statement")
-		return errors.NewInternalError("error in prepare statement")
+		pst.logger.Error("[MarketRepository::CountByRegiao8] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
 	}
 
-	_, err = prepare.QueryContext(ctx, now(), registerCode)
+	rows, err := prepare.QueryContext(ctx)
 	if err != nil {
-		pst.logger.Error("[MarketRepository::Delete] query execution error")
-		return errors.NewInternalError("query execution error")
+		pst.logger.Error("[MarketRepository::CountByRegiao8] query execution error")
+		return nil, errors.NewInternalError("query execution error")
 	}
+	defer rows.Close()
 
-	return nil
+	counts := map[string]int64{}
+	for rows.Next() {
+		var regiao8 string
+		var count int64
+		if err := rows.Scan(&regiao8, &count); err != nil {
+			pst.logger.Error("[MarketRepository::CountByRegiao8] - scanning the result failure")
+			return nil, errors.NewInternalError("error in scanning the results")
+		}
+
+		counts[regiao8] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::CountByRegiao8] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return counts, nil
 }
 
+func (pst marketRepository) CountByRegiao5InDistrito(ctx context.Context, distrito string) (map[string]int64, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `SELECT regiao5, COUNT(*)
+		FROM feiras
+		WHERE deletado_em IS NULL AND distrito = $1
+		GROUP BY regiao5`
+
+	ctx, dispose := pst.instrument(ctx, "CountByRegiao5InDistrito", "COUNT feiras BY regiao5 IN distrito", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::CountByRegiao5InDistrito] Error in prepare statement")
+		return nil, errors.NewInternalError("error in prepare statement")
+	}
+
+	rows, err := prepare.QueryContext(ctx, distrito)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::CountByRegiao5InDistrito] query execution error")
+		return nil, errors.NewInternalError("query execution error")
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var regiao5 string
+		var count int64
+		if err := rows.Scan(&regiao5, &count); err != nil {
+			pst.logger.Error("[MarketRepository::CountByRegiao5InDistrito] - scanning the result failure")
+			return nil, errors.NewInternalError("error in scanning the results")
+		}
+
+		counts[regiao5] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::CountByRegiao5InDistrito] - reading the results failure: %s", err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return counts, nil
+}
+
+// buildQuery adds one placeholder clause per non-zero field of market. Column names only come from the
+// maps below, never from the input, so fields outside them (ID, DeletadoEm) are ignored.
 func buildQuery(pre, pos string, market valueObjects.MarketValueObjects) (string, []interface{}) {
 	var mappingFields = map[string]string{
 		"Long": "long", "Lat": "lat", "Setcens": "setcens", "Areap": "areap", "Coddist": "coddist", "Distrito": "distrito", "Codsubpref": "codsubpref",
@@ -174,6 +1800,8 @@ func buildQuery(pre, pos string, market valueObjects.MarketValueObjects) (string
 		"Numero": "numero", "Bairro": "bairro", "Referencia": "referencia", "CriadoEm": "criado_em", "AtualizadoEm": "atualizado_em",
 	}
 
+	var likeFields = map[string]string{"LogradouroContains": "logradouro"}
+
 	vOf := reflect.ValueOf(market)
 
 	where := ""
@@ -183,8 +1811,15 @@ func buildQuery(pre, pos string, market valueObjects.MarketValueObjects) (string
 
 	for i := 0; i < vOf.NumField(); i++ {
 		field = vOf.Field(i)
-		fieldName := mappingFields[vOf.Type().Field(i).Name]
-		if !field.IsZero() {
+		if fieldName, like := likeFields[vOf.Type().Field(i).Name]; like && !field.IsZero() {
+			where += fmt.Sprintf(` %s %s ILIKE $%v ESCAPE '\'%s`, pre, fieldName, fieldCount, pos)
+			fields = append(fields, containsPattern(field.String()))
+			fieldCount++
+			continue
+		}
+
+		fieldName, mapped := mappingFields[vOf.Type().Field(i).Name]
+		if mapped && !field.IsZero() {
 			where += fmt.Sprintf(" %s %s = $%v%s", pre, fieldName, fieldCount, pos)
 			fields = append(fields, field.Interface())
 			fieldCount++
@@ -198,17 +1833,46 @@ type IRow interface {
 	Scan(dest ...interface{}) error
 }
 
-func (pst marketRepository) scan(row IRow) (valueObjects.MarketValueObjects, error) {
+// scan reads a feiras row, followed by the extra columns the query returns after it, if any.
+func (pst marketRepository) scan(row IRow, extra ...interface{}) (valueObjects.MarketValueObjects, error) {
 	model := models.MarketModel{}
-	if err := row.Scan(&model.ID, &model.Long, &model.Lat, &model.Setcens, &model.Areap, &model.Coddist, &model.Distrito, &model.Codsubpref,
-		&model.Subpref, &model.Regiao5, &model.Regiao8, &model.NomeFeira, &model.Registro, &model.Logradouro, &model.Numero, &model.Bairro,
-		&model.Referencia, &model.CriadoEm, &model.AtualizadoEm, &model.DeletadoEm); err != nil {
+	id := models.MarketID{}
+	dest := append([]interface{}{&id, &model.Long, &model.Lat, &model.Setcens, &model.Areap, &model.Coddist, &model.Distrito,
+		&model.Codsubpref, &model.Subpref, &model.Regiao5, &model.Regiao8, &model.NomeFeira, &model.Registro, &model.Logradouro, &model.Numero,
+		&model.Bairro, &model.Referencia, &model.CriadoEm, &model.AtualizadoEm, &model.DeletadoEm}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		if mapped := constraintError(err); mapped != nil {
+			return valueObjects.MarketValueObjects{}, mapped
+		}
 		return valueObjects.MarketValueObjects{}, errors.NewInternalError("error in scanning the results")
 	}
+	model.ID, model.UUID = id.Int, id.UUID
 	return model.ToValueObject(), nil
 }
 
-func instrument(ctx context.Context, name, query string) (dispose func()) {
+func (pst marketRepository) scanMarkets(method string, rows *sql.Rows) ([]valueObjects.MarketValueObjects, error) {
+	var results []valueObjects.MarketValueObjects
+	for rows.Next() {
+		result, err := pst.scan(rows)
+		if err != nil {
+			pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] - scanning the result failure", method))
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] - reading the results failure: %s", method, err.Error()))
+		return nil, errors.NewInternalError("error while reading the results")
+	}
+
+	return results, nil
+}
+
+// instrument opens the APM span of a query and bounds ctx by DB_QUERY_TIMEOUT. dispose logs the context
+// error, if the caller canceled or the timeout expired, under the prefix of method.
+func (pst marketRepository) instrument(ctx context.Context, method, name, query string) (context.Context, func()) {
 	span, _ := apm.StartSpan(ctx, name, "db.postgre.query")
 	span.Context.SetDatabase(apm.DatabaseSpanContext{
 		Instance:  "postgres",
@@ -217,11 +1881,163 @@ func instrument(ctx context.Context, name, query string) (dispose func()) {
 		User:      "project",
 	})
 
-	return func() {
+	cancel := func() {}
+	if pst.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, pst.timeout)
+	}
+
+	return ctx, func() {
+		if err := ctx.Err(); err != nil {
+			pst.logger.Error(fmt.Sprintf("[MarketRepository::%s] - context error: %s", method, err.Error()))
+		}
+		cancel()
 		span.End()
 	}
 }
 
 func NewMarketRepository(logger interfaces.ILogger, db *sql.DB) interfaces.IMarketRepository {
-	return marketRepository{logger, db}
+	return marketRepository{
+		logger:     logger,
+		db:         db,
+		prepared:   os.Getenv("DB_PREPARED_STATEMENTS") != "false",
+		maxResults: maxResultsFromEnv(logger),
+		maxPage:    MaxPageSizeFromEnv(logger),
+		timeout:    queryTimeoutFromEnv(logger),
+		collation:  collationFromEnv(logger),
+		returning:  os.Getenv("DB_INSERT_RETURNING") != "false",
+		retries:    retryPolicyFromEnv(logger),
+		txLimit:    txLimiterFromEnv(logger),
+		uuidIDs:    os.Getenv("DB_ID_TYPE") == database.IDTypeUUID,
+	}
+}
+
+// nameOrder sorts nome_feira with the DB_NAME_COLLATION collation, so accented names follow the locale
+// instead of the byte order, or with the column default when none is set.
+func (pst marketRepository) nameOrder() string {
+	if pst.collation == "" {
+		return "nome_feira ASC"
+	}
+
+	return fmt.Sprintf(`nome_feira COLLATE "%s" ASC`, pst.collation)
+}
+
+// sortableColumns are the only columns a page may be ordered by, since the column is written into the query.
+var sortableColumns = []string{"nome_feira", "distrito", "bairro", "criado_em"}
+
+// pageOrder builds the ORDER BY of FindPage, nome_feira ASC by default. The id breaks ties so the rows do
+// not move between pages.
+func (pst marketRepository) pageOrder(pagination valueObjects.Pagination) (string, error) {
+	column := pagination.OrderBy
+	if column == "" {
+		column = "nome_feira"
+	}
+
+	sortable := false
+	for _, c := range sortableColumns {
+		sortable = sortable || c == column
+	}
+	if !sortable {
+		return "", errors.NewValidationError(fmt.Sprintf("order_by must be one of %s", strings.Join(sortableColumns, ", ")))
+	}
+
+	if column == "nome_feira" && pst.collation != "" {
+		column = fmt.Sprintf(`nome_feira COLLATE "%s"`, pst.collation)
+	}
+
+	direction := "ASC"
+	if pagination.Descending {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("%s %s, id ASC", column, direction), nil
+}
+
+// collationFromEnv reads DB_NAME_COLLATION, a collation installed in the database such as pt_BR or pt-BR-x-icu.
+// Names with other characters are ignored since the collation is written into the query.
+func collationFromEnv(logger interfaces.ILogger) string {
+	raw := os.Getenv("DB_NAME_COLLATION")
+	if raw == "" {
+		return ""
+	}
+
+	if !collationName.MatchString(raw) {
+		logger.Warn(fmt.Sprintf("[MarketRepository] - invalid DB_NAME_COLLATION: %s", raw))
+		return ""
+	}
+
+	return raw
+}
+
+// queryTimeoutFromEnv reads DB_QUERY_TIMEOUT, the deadline of each repository call. Zero, the default, leaves
+// the caller's context alone.
+func queryTimeoutFromEnv(logger interfaces.ILogger) time.Duration {
+	raw := os.Getenv("DB_QUERY_TIMEOUT")
+	if raw == "" {
+		return 0
+	}
+
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout < 0 {
+		logger.Warn(fmt.Sprintf("[MarketRepository] - invalid DB_QUERY_TIMEOUT: %s", raw))
+		return 0
+	}
+
+	return timeout
+}
+
+// maxResultsFromEnv reads MAX_LIST_RESULTS, the most rows Find may load at once. Zero, the default,
+// disables the guard.
+func maxResultsFromEnv(logger interfaces.ILogger) int64 {
+	raw := os.Getenv("MAX_LIST_RESULTS")
+	if raw == "" {
+		return 0
+	}
+
+	max, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || max < 0 {
+		logger.Warn(fmt.Sprintf("[MarketRepository] - invalid MAX_LIST_RESULTS: %s", raw))
+		return 0
+	}
+
+	return max
+}
+
+// MaxPageSizeFromEnv reads MAX_PAGE_SIZE, the largest limit FindPage and the paged listings accept. The HTTP
+// handlers get the same value to reject a larger limit up front.
+func MaxPageSizeFromEnv(logger interfaces.ILogger) int {
+	raw := os.Getenv("MAX_PAGE_SIZE")
+	if raw == "" {
+		return defaultMaxPageSize
+	}
+
+	max, err := strconv.Atoi(raw)
+	if err != nil || max < 1 {
+		logger.Warn(fmt.Sprintf("[MarketRepository] - invalid MAX_PAGE_SIZE: %s", raw))
+		return defaultMaxPageSize
+	}
+
+	return max
+}
+
+func (pst marketRepository) CountDistinctRegistros(ctx context.Context) (int64, error) {
+	pst.logger = pst.logger.WithContext(ctx)
+
+	sql := `SELECT COUNT(DISTINCT registro) FROM feiras WHERE deletado_em IS NULL`
+
+	ctx, dispose := pst.instrument(ctx, "CountDistinctRegistros", "COUNT DISTINCT registro", sql)
+	defer dispose()
+
+	prepare, err := pst.prepare(ctx, sql)
+	if err != nil {
+		pst.logger.Error("[MarketRepository::CountDistinctRegistros] Error in prepare statement")
+		return 0, errors.NewInternalError("error in prepare statement")
+	}
+
+	var count int64
+	if err := prepare.QueryRowContext(ctx).Scan(&count); err != nil {
+		pst.logger.Error("[MarketRepository::CountDistinctRegistros] query execution error")
+		return 0, errors.NewInternalError("query execution error")
+	}
+
+	return count, nil
 }
@@ -3,37 +3,2887 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/ralvescosta/base/pkg/app/errors"
 	"github.com/ralvescosta/base/pkg/app/interfaces"
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
 	"github.com/ralvescosta/base/pkg/infra/database/models"
 	"github.com/ralvescosta/base/pkg/infra/logger"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 func Test_MarketRepo_Create(t *testing.T) {
+	t.Run("should log the created market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Info", "[MarketRepository::Create] - success", []zapcore.Field{
+			zap.String("operation", "create"), zap.Int("id", 1), zap.String("registro", "registro"),
+		})
+		sut.sqlMockForCreateSuccessfully()
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMockForCreateSuccessfully()
+
+		result, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.marketMocked, result)
+	})
+
+	t.Run("should return every invalid field before touching the database", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Warn", "[MarketRepository::Create] - invalid market: Long must be between -180000000 and 180000000; Registro is required",
+			[]zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), valueObjects.MarketValueObjects{Long: 200000000, Distrito: "distrito", NomeFeira: "nomefeira"})
+
+		assert.Equal(t, valueObjects.MarketValidationError{Fields: []valueObjects.ValidateResult{
+			{Field: "Long", Message: "Long must be between -180000000 and 180000000"},
+			{Field: "Registro", Message: "Registro is required"},
+		}}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Create] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::Create] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrMarketAlreadyExists on unique violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "23505", Constraint: "feiras_registro_key"})
+		sut.logger.On("Warn", "[MarketRepository::Create] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrMarketAlreadyExists on unique violation while reading the row", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		rows := sut.marketRows(1).RowError(0, &pq.Error{Code: "23505", Constraint: "feiras_registro_key"})
+		prepare.ExpectQuery().WillReturnRows(rows)
+		sut.logger.On("Warn", "[MarketRepository::Create] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrMarketAlreadyExists on pgx unique violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "feiras_registro_key"})
+		sut.logger.On("Warn", "[MarketRepository::Create] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return validation error on not null violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "23502", Column: "nome_feira"})
+		sut.logger.On("Warn", "[MarketRepository::Create] - constraint violation: nome_feira is required", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Equal(t, errors.NewValidationError("nome_feira is required"), err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return internal error on other pq errors", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "22003"})
+		sut.logger.On("Error", "[MarketRepository::Create] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when scanning failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		row := sut.sqlMock.NewRows([]string{""})
+		prepare.ExpectQuery().WithArgs().WillReturnRows(row)
+		sut.logger.On("Error", "[MarketRepository::Create] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_CreateWithoutReturning(t *testing.T) {
+	t.Run("should use RETURNING by default", func(t *testing.T) {
+		os.Unsetenv("DB_INSERT_RETURNING")
+
+		repo := NewMarketRepository(nil, nil).(marketRepository)
+
+		assert.True(t, repo.returning)
+	})
+
+	t.Run("should insert then select when DB_INSERT_RETURNING is false", func(t *testing.T) {
+		os.Setenv("DB_INSERT_RETURNING", "false")
+		defer os.Unsetenv("DB_INSERT_RETURNING")
+
+		repo := NewMarketRepository(nil, nil).(marketRepository)
+
+		assert.False(t, repo.returning)
+	})
+
+	t.Run("should select the inserted market by lastval in the same transaction", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.returning = false
+
+		sut.sqlMock.ExpectBegin()
+		prepare := sut.sqlMock.ExpectPrepare(`INSERT INTO feiras (.+) VALUES \((.+)\)$`)
+		prepare.ExpectExec().WithArgs(sut.marketMocked.Long, sut.marketMocked.Lat, sut.marketMocked.Setcens, sut.marketMocked.Areap,
+			sut.marketMocked.Coddist, sut.marketMocked.Distrito, sut.marketMocked.Codsubpref, sut.marketMocked.Subpref, sut.marketMocked.Regiao5,
+			sut.marketMocked.Regiao8, sut.marketMocked.NomeFeira, sut.marketMocked.Registro, sut.marketMocked.Logradouro, sut.marketMocked.Numero,
+			sut.marketMocked.Bairro, sut.marketMocked.Referencia, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sut.sqlMock.ExpectQuery(`SELECT (.+) FROM feiras WHERE id = lastval\(\)`).WillReturnRows(sut.marketRows(1))
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+
+		result, err := repo.Create(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.marketMocked, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should select the inserted market by registro with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.returning = false
+		repo.uuidIDs = true
+
+		sut.sqlMock.ExpectBegin()
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+		sut.sqlMock.ExpectQuery(`SELECT (.+) FROM feiras WHERE registro = \$1 AND deletado_em IS NULL`).WithArgs(sut.marketMocked.Registro).
+			WillReturnRows(sut.uuidMarketRows())
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::Create] - success", []zapcore.Field{
+			zap.String("operation", "create"), zap.String("id", uuidMocked), zap.String("registro", "registro"),
+		})
+
+		result, err := repo.Create(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uuidMocked, result.UUID)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should roll back and return ErrMarketAlreadyExists on unique violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.returning = false
+
+		sut.sqlMock.ExpectBegin()
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectExec().WillReturnError(&pq.Error{Code: "23505", Constraint: "feiras_registro_key"})
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Warn", "[MarketRepository::Create] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should roll back if selecting the inserted market fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.returning = false
+
+		sut.sqlMock.ExpectBegin()
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+		sut.sqlMock.ExpectQuery("WHERE id = lastval").WillReturnError(fmt.Errorf("lastval is not yet defined in this session"))
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Error", "[MarketRepository::Create] query execution error", []zapcore.Field(nil))
+
+		_, err := repo.Create(context.Background(), sut.marketMocked)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Find(t *testing.T) {
 	t.Run("should execute correctly", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.sqlMockForCreateSuccessfully()
+		sut.sqlMockForFindSuccessfully()
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Long: sut.marketMocked.Long})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return every row matching the filter", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.marketRows(3))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 3)
+		for i, market := range result {
+			assert.Equal(t, i+1, market.ID)
+		}
+	})
+
+	t.Run("should combine every filter with AND", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := `SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \$1 AND regiao5 = \$2 AND nome_feira = \$3 AND bairro = \$4$`
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("VILA FORMOSA", "Leste", "VILA FORMOSA", "VL FORMOSA").WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{
+			Distrito: "VILA FORMOSA", Regiao5: "Leste", NomeFeira: "VILA FORMOSA", Bairro: "VL FORMOSA",
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return every active market when no filter is given", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL$")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(sut.marketRows(3))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 3)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should match logradouro by contains with ILIKE", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := `SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \$1 AND logradouro ILIKE \$2 ESCAPE '\\'$`
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("distrito", "%RUA MARAGOJIPE%").WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito", LogradouroContains: " RUA MARAGOJIPE "})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should escape the wildcards of the logradouro filter", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("logradouro ILIKE")
+		prepare.ExpectQuery().WithArgs(`%100\%\_RUA%`).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{LogradouroContains: "100%_RUA"})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not filter by DeletadoEm", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1$")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.marketRows(1))
+
+		deletedAt := time.Now()
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito", DeletadoEm: &deletedAt})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should close the rows even when the scan fails in the middle", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		rows := sut.marketRows(1).AddRow(2, "wrong", -100, "setcens", "areap", 10, "distrito", 10, "subpref", "regiao5", "regiao8", "nomefeira",
+			"registro", "logradouro", "numero", "bairro", "referencia", sut.modelMocked.CriadoEm, sut.modelMocked.AtualizadoEm, nil)
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(rows).RowsWillBeClosed()
+		sut.logger.On("Error", "[MarketRepository::Find] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.Error(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err if reading the rows fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.marketRows(2).RowError(1, fmt.Errorf("connection reset")))
+		sut.logger.On("Error", "[MarketRepository::Find] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Find] Error in prepare statement", []zapcore.Field(nil))
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Long: sut.marketMocked.Long})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::Find] query execution error", []zapcore.Field(nil))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Long: sut.marketMocked.Long})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindMaxResults(t *testing.T) {
+	t.Run("should abort when the count is above the threshold", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sut.repo = marketRepository{logger: sut.logger, db: sut.db, prepared: true, maxResults: 2}
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(3))
+		sut.logger.On("Warn", "[MarketRepository::Find] - 3 results above the limit of 2", []zapcore.Field(nil))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.Nil(t, result)
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should list when the count is within the threshold", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sut.repo = marketRepository{logger: sut.logger, db: sut.db, prepared: true, maxResults: 2}
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(2))
+		find := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1")
+		find.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when the count fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sut.repo = marketRepository{logger: sut.logger, db: sut.db, prepared: true, maxResults: 2}
+
+		sut.logger.On("Error", "[MarketRepository::Count] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.IsType(t, errors.InternalError{}, err)
+	})
+
+	t.Run("should read the threshold from env", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("MAX_LIST_RESULTS", "500")
+		defer os.Unsetenv("MAX_LIST_RESULTS")
+
+		assert.Equal(t, int64(500), maxResultsFromEnv(log))
+	})
+
+	t.Run("should disable the guard when the threshold is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("MAX_LIST_RESULTS", "wrong")
+		defer os.Unsetenv("MAX_LIST_RESULTS")
+		log.On("Warn", "[MarketRepository] - invalid MAX_LIST_RESULTS: wrong", []zapcore.Field(nil))
+
+		assert.Equal(t, int64(0), maxResultsFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Count(t *testing.T) {
+	t.Run("should count the active markets matching the filter", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(7))
+
+		count, err := sut.repo.Count(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), count)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should count every active market without filters", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL$")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(880))
+
+		count, err := sut.repo.Count(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(880), count)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should apply the same filters as Find", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := `SELECT COUNT\(\*\) FROM feiras WHERE deletado_em IS NULL AND regiao5 = \$1 AND logradouro ILIKE \$2 ESCAPE '\\'$`
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("Leste", "%MARAGOJIPE%").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(2))
+
+		count, err := sut.repo.Count(context.Background(), valueObjects.MarketValueObjects{Regiao5: "Leste", LogradouroContains: "MARAGOJIPE"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Count] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.Count(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::Count] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.Count(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Update(t *testing.T) {
+	t.Run("should reject coordinates out of range before touching the database", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Warn", "[MarketRepository::Update] - invalid market: Lat must be between -90000000 and 90000000", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(context.Background(), "registro", valueObjects.MarketValueObjects{Lat: -100000000})
+
+		assert.IsType(t, valueObjects.MarketValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should log the updated market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Info", "[MarketRepository::Update] - success", []zapcore.Field{
+			zap.String("operation", "update"), zap.Int("id", 1), zap.String("registro", "registro"),
+		})
+		sut.sqlMockForUpdateSuccessfully()
+		sut.marketMocked.Registro = ""
+		sut.marketMocked.ID = 0
+
+		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+
+		assert.NoError(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMockForUpdateSuccessfully()
+		sut.marketMocked.Registro = ""
+		sut.marketMocked.ID = 0
+
+		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Update] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::Update] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when scanning failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		row := sut.sqlMock.NewRows([]string{""})
+		prepare.ExpectQuery().WithArgs().WillReturnRows(row)
+		sut.logger.On("Error", "[MarketRepository::Update] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return conflict error on foreign key violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras")
+		prepare.ExpectQuery().WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "feiras_distrito_fkey"})
+		sut.logger.On("Warn", "[MarketRepository::Update] - constraint violation: feiras_distrito_fkey constraint violated", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(context.Background(), "registro", valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.Equal(t, errors.NewConflictError("feiras_distrito_fkey constraint violated"), err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return validation error on check violation while reading the row", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras")
+		rows := sut.marketRows(1).RowError(0, &pq.Error{Code: "23514", Constraint: "feiras_lat_check"})
+		prepare.ExpectQuery().WillReturnRows(rows)
+		sut.logger.On("Warn", "[MarketRepository::Update] - constraint violation: feiras_lat_check constraint violated", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(context.Background(), "registro", valueObjects.MarketValueObjects{Lat: 1})
+
+		assert.Equal(t, errors.NewValidationError("feiras_lat_check constraint violated"), err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Delete(t *testing.T) {
+	t.Run("should log the deleted market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Info", "[MarketRepository::Delete] - success", []zapcore.Field{
+			zap.String("operation", "delete"), zap.Int("id", 1), zap.String("registro", "registro"),
+		})
+		sut.sqlMockForDeleteSuccessfully()
+
+		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+
+		assert.NoError(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should log the uuid of the deleted market with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Info", "[MarketRepository::Delete] - success", []zapcore.Field{
+			zap.String("operation", "delete"), zap.String("id", uuidMocked), zap.String("registro", "registro"),
+		})
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WithArgs(now(), "registro").WillReturnRows(sut.sqlMock.NewRows([]string{"id"}).AddRow([]byte(uuidMocked)))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.NoError(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrMarketNotFound without logging when no active market matches", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em = \\$1 WHERE registro = \\$2 AND deletado_em IS NULL")
+		prepare.ExpectQuery().WithArgs(now(), "registro").WillReturnRows(sut.sqlMock.NewRows([]string{"id"}))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.Equal(t, errors.ErrMarketNotFound, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when reading the results failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WithArgs(now(), "registro").WillReturnRows(
+			sut.sqlMock.NewRows([]string{"id"}).AddRow(1).RowError(0, fmt.Errorf("connection reset")),
+		)
+		sut.logger.On("Error", "[MarketRepository::Delete] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err instead of not found when scanning the deleted id fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WithArgs(now(), "registro").WillReturnRows(sut.sqlMock.NewRows([]string{"id"}).AddRow(true))
+		sut.logger.On("Error", "[MarketRepository::Delete] - scanning the result failure", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMockForDeleteSuccessfully()
+
+		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Delete] Error in prepare statement", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::Delete] query execution error", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_UpdateByID(t *testing.T) {
+	t.Run("should update the active market by id refreshing atualizado_em", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "UPDATE feiras SET  numero = \\$1,  bairro = \\$2, atualizado_em = \\$3 WHERE id = \\$4 AND deletado_em IS NULL RETURNING feiras.\\*"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("10", "bairro", now(), 1).WillReturnRows(sut.marketRows(1))
+		sut.logger.On("Info", "[MarketRepository::UpdateByID] - success", mock.Anything)
+
+		result, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Numero: "10", Bairro: "bairro"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.ID)
+		assert.Equal(t, sut.modelMocked.Registro, result.Registro)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should update the active market by uuid with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET  bairro = \\$1, atualizado_em = \\$2 WHERE id = \\$3 AND deletado_em IS NULL")
+		prepare.ExpectQuery().WithArgs("bairro", now(), uuidMocked).WillReturnRows(sut.uuidMarketRows())
+		sut.logger.On("Info", "[MarketRepository::UpdateByID] - success", []zapcore.Field{
+			zap.String("operation", "updatebyid"), zap.String("id", uuidMocked), zap.String("registro", "registro"),
+		})
+
+		result, err := repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{UUID: uuidMocked, Bairro: "bairro"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uuidMocked, result.UUID)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should fail fast without the uuid with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		_, err := repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"})
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when no active market has the id", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET")
+		prepare.ExpectQuery().WithArgs("bairro", now(), 1).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"})
+
+		assert.Equal(t, errors.NewNotFoundError("Market with the ID: 1 was not found"), err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::UpdateByID] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::UpdateByID] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return conflict error on foreign key violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras")
+		prepare.ExpectQuery().WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "feiras_distrito_fkey"})
+		sut.logger.On("Warn", "[MarketRepository::UpdateByID] - constraint violation: feiras_distrito_fkey constraint violated", []zapcore.Field(nil))
+
+		_, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Distrito: "distrito"})
+
+		assert.Equal(t, errors.NewConflictError("feiras_distrito_fkey constraint violated"), err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when scanning the result failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"id"}).AddRow(1))
+		sut.logger.On("Error", "[MarketRepository::UpdateByID] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Restore(t *testing.T) {
+	t.Run("should clear deletado_em of the soft-deleted market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare(
+			"UPDATE feiras SET deletado_em = NULL, atualizado_em = \\$1 WHERE registro = \\$2 AND deletado_em IS NOT NULL RETURNING \\*")
+		prepare.ExpectQuery().WithArgs(now(), "4041-0").WillReturnRows(sut.marketRows(1))
+		sut.logger.On("Info", "[MarketRepository::Restore] - success", []zapcore.Field{
+			zap.String("operation", "restore"), zap.Int("id", 1), zap.String("registro", "registro"),
+		})
+
+		result, err := sut.repo.Restore(context.Background(), "4041-0")
+
+		assert.NoError(t, err)
+		assert.True(t, result.IsActive())
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return not found when the registro is missing or already active", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em = NULL")
+		prepare.ExpectQuery().WithArgs(now(), "4041-0").WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.Restore(context.Background(), "4041-0")
+
+		assert.Equal(t, errors.NewNotFoundError("Deleted market with the RegisterCode: 4041-0 was not found"), err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return ErrMarketAlreadyExists when the registro is active again", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em = NULL")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "23505", Constraint: "feiras_registro_key"})
+		sut.logger.On("Warn", "[MarketRepository::Restore] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := sut.repo.Restore(context.Background(), "4041-0")
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Restore] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.Restore(context.Background(), "4041-0")
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Touch(t *testing.T) {
+	t.Run("should update only atualizado_em of the active market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET atualizado_em = \\$1 WHERE id = \\$2 AND deletado_em IS NULL RETURNING id")
+		prepare.ExpectQuery().WithArgs(now(), 1).WillReturnRows(sut.sqlMock.NewRows([]string{"id"}).AddRow(1))
+
+		err := sut.repo.Touch(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fail fast with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		err := repo.Touch(context.Background(), 1)
+
+		assert.Equal(t, errors.NewValidationError("Touch looks markets up by the integer id, which DB_ID_TYPE=uuid does not have"), err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when the market is missing or deleted", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET atualizado_em")
+		prepare.ExpectQuery().WithArgs(now(), 1).WillReturnRows(sut.sqlMock.NewRows([]string{"id"}))
+
+		err := sut.repo.Touch(context.Background(), 1)
+
+		assert.IsType(t, errors.NotFoundError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Touch] Error in prepare statement", []zapcore.Field(nil))
+
+		err := sut.repo.Touch(context.Background(), 1)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::Touch] query execution error", []zapcore.Field(nil))
+
+		err := sut.repo.Touch(context.Background(), 1)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindAfterID(t *testing.T) {
+	t.Run("should return the page after the last seen id", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND id > \\$1 ORDER BY id ASC LIMIT \\$2")
+		prepare.ExpectQuery().WithArgs(40, 2).WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.FindAfterID(context.Background(), 40, 2)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fallback to the default page size", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs(0, defaultPageSize).WillReturnRows(sut.marketRows(1))
+
+		_, err := sut.repo.FindAfterID(context.Background(), 0, 0)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindAfterID] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindAfterID(context.Background(), 0, 10)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should read the first page by uuid with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL ORDER BY id ASC LIMIT \\$1")
+		prepare.ExpectQuery().WithArgs(2).WillReturnRows(sut.uuidMarketRows())
+
+		result, err := repo.FindAfterID(context.Background(), 0, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uuidMocked, result[0].UUID)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fail fast after an integer id with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		_, err := repo.FindAfterID(context.Background(), 40, 2)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_MarketRepo_FindAfterUUID(t *testing.T) {
+	t.Run("should return the page after the last seen uuid", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND id > \\$1 ORDER BY id ASC LIMIT \\$2")
+		prepare.ExpectQuery().WithArgs(uuidMocked, 2).WillReturnRows(sut.uuidMarketRows())
+
+		result, err := repo.FindAfterUUID(context.Background(), uuidMocked, 2)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should read the first page without a cursor", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL ORDER BY id ASC LIMIT \\$1")
+		prepare.ExpectQuery().WithArgs(defaultPageSize).WillReturnRows(sut.uuidMarketRows())
+
+		_, err := repo.FindAfterUUID(context.Background(), "", 0)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fail fast with the serial id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindAfterUUID(context.Background(), uuidMocked, 2)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindAfterUUID] query execution error", []zapcore.Field(nil))
+
+		_, err := repo.FindAfterUUID(context.Background(), uuidMocked, 2)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindMissingRegistro(t *testing.T) {
+	t.Run("should select the null and blank registros as empty", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare(
+			"SELECT (.+) COALESCE\\(registro, ''\\) AS Registro, (.+) FROM feiras " +
+				"WHERE deletado_em IS NULL AND \\(registro IS NULL OR TRIM\\(registro\\) = ''\\) ORDER BY id ASC LIMIT \\$1",
+		)
+		m := sut.modelMocked
+		rows := sut.sqlMock.NewRows(marketColumns).
+			AddRow(3, m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref, m.Subpref, m.Regiao5, m.Regiao8, m.NomeFeira,
+				"", m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm, m.AtualizadoEm, m.DeletadoEm).
+			AddRow(7, m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref, m.Subpref, m.Regiao5, m.Regiao8, m.NomeFeira,
+				"  ", m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm, m.AtualizadoEm, m.DeletadoEm)
+		prepare.ExpectQuery().WithArgs(50).WillReturnRows(rows)
+
+		result, err := sut.repo.FindMissingRegistro(context.Background(), 50)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, 3, result[0].ID)
+		assert.Equal(t, "", result[0].Registro)
+		assert.Equal(t, "  ", result[1].Registro)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fallback to the default page size", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs(defaultPageSize).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		result, err := sut.repo.FindMissingRegistro(context.Background(), 0)
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindMissingRegistro] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindMissingRegistro(context.Background(), 10)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindMissingRegistro] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindMissingRegistro(context.Background(), 10)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_NameCollation(t *testing.T) {
+	t.Run("should keep the column default when no collation is set", func(t *testing.T) {
+		assert.Equal(t, "nome_feira ASC", marketRepository{}.nameOrder())
+	})
+
+	t.Run("should quote the configured collation", func(t *testing.T) {
+		assert.Equal(t, `nome_feira COLLATE "pt_BR" ASC`, marketRepository{collation: "pt_BR"}.nameOrder())
+	})
+
+	t.Run("should read the collation from env", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_NAME_COLLATION", "pt-BR-x-icu")
+		defer os.Unsetenv("DB_NAME_COLLATION")
+
+		assert.Equal(t, "pt-BR-x-icu", collationFromEnv(log))
+	})
+
+	t.Run("should ignore a collation that could break the query", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_NAME_COLLATION", `pt_BR" ASC; DROP TABLE feiras; --`)
+		defer os.Unsetenv("DB_NAME_COLLATION")
+		log.On("Warn", `[MarketRepository] - invalid DB_NAME_COLLATION: pt_BR" ASC; DROP TABLE feiras; --`, []zapcore.Field(nil))
+
+		assert.Equal(t, "", collationFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindByDistritoPaged(t *testing.T) {
+	t.Run("should filter by distrito sorted by name and paginated", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY nome_feira ASC, id ASC LIMIT \\$2 OFFSET \\$3"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("distrito", 10, 20).WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 3, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should sort the names with DB_NAME_COLLATION", func(t *testing.T) {
+		os.Setenv("DB_NAME_COLLATION", "pt-BR-x-icu")
+		defer os.Unsetenv("DB_NAME_COLLATION")
+		sut := makeMarketRepositorySut()
+
+		query := "SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY nome_feira COLLATE \"pt-BR-x-icu\" ASC, id ASC LIMIT"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("distrito", 10, 0).WillReturnRows(sut.marketRows(2))
+
+		_, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 1, 10)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fallback to the first page and the default page size", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito", defaultPageSize, 0).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 0, 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should cap the page size at MAX_PAGE_SIZE", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito", defaultMaxPageSize, defaultMaxPageSize).WillReturnRows(sut.marketRows(1))
+
+		_, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 2, 1000000)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should close the rows even when the scan fails in the middle", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		rows := sut.marketRows(1).AddRow(2, "wrong", -100, "setcens", "areap", 10, "distrito", 10, "subpref", "regiao5", "regiao8", "nomefeira",
+			"registro", "logradouro", "numero", "bairro", "referencia", sut.modelMocked.CriadoEm, sut.modelMocked.AtualizadoEm, nil)
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito", 10, 0).WillReturnRows(rows).RowsWillBeClosed()
+		sut.logger.On("Error", "[MarketRepository::FindByDistritoPaged] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 1, 10)
+
+		assert.Error(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err if reading the rows fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito", 10, 0).WillReturnRows(sut.marketRows(2).RowError(1, fmt.Errorf("connection reset")))
+		sut.logger.On("Error", "[MarketRepository::FindByDistritoPaged] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		result, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 1, 10)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindByDistritoPaged] Error in prepare statement", []zapcore.Field(nil))
+
+		result, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 1, 10)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::FindByDistritoPaged] query execution error", []zapcore.Field(nil))
+
+		result, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 1, 10)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindByCoordinates(t *testing.T) {
+	t.Run("should return every active market at the same coordinates", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND long::float8 = \\$1 AND lat::float8 = \\$2 ORDER BY id ASC"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs(float64(-100), float64(-100)).WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.FindByCoordinates(context.Background(), -100, -100)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindByCoordinates] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByCoordinates(context.Background(), -100, -100)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindByCoordinates] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByCoordinates(context.Background(), -100, -100)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_Upsert(t *testing.T) {
+	t.Run("should insert a new market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare(
+			"INSERT INTO feiras (.+) VALUES (.+) ON CONFLICT \\(registro\\) WHERE deletado_em IS NULL DO UPDATE SET (.+) " +
+				"atualizado_em = EXCLUDED.atualizado_em RETURNING \\*, \\(xmax = 0\\) AS inserted")
+		prepare.ExpectQuery().WillReturnRows(sut.upsertRows(true))
+		sut.logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+
+		result, inserted, err := sut.repo.Upsert(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.True(t, inserted)
+		assert.Equal(t, sut.marketMocked.Registro, result.Registro)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should update the market already stored", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectPrepare("ON CONFLICT").ExpectQuery().WillReturnRows(sut.upsertRows(false))
+		sut.logger.On("Info", "[MarketRepository::Update] - success", mock.Anything)
+
+		_, inserted, err := sut.repo.Upsert(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.False(t, inserted)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Upsert] Error in prepare statement", []zapcore.Field(nil))
+
+		_, _, err := sut.repo.Upsert(context.Background(), sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectPrepare("ON CONFLICT").ExpectQuery().WillReturnError(fmt.Errorf("connection reset"))
+		sut.logger.On("Error", "[MarketRepository::Upsert] query execution error", []zapcore.Field(nil))
+
+		_, _, err := sut.repo.Upsert(context.Background(), sut.marketMocked)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_CreateMany(t *testing.T) {
+	t.Run("should insert every market with a single multi-row INSERT", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		markets := []valueObjects.MarketValueObjects{sut.marketMocked, sut.marketMocked}
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectQuery("INSERT INTO feiras \\(long, (.+), atualizado_em\\) VALUES \\(\\$1, (.+), \\$18\\), \\(\\$19, (.+), \\$36\\) RETURNING \\*$").
+			WillReturnRows(sut.marketRows(2))
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::CreateMany] - 2 markets created", []zapcore.Field(nil))
+
+		result, err := sut.repo.CreateMany(context.Background(), markets)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should split large slices in chunks", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		markets := make([]valueObjects.MarketValueObjects, createManyChunk+1)
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectQuery("\\(\\$8983, (.+), \\$9000\\) RETURNING \\*$").WillReturnRows(sut.marketRows(createManyChunk))
+		sut.sqlMock.ExpectQuery("VALUES \\(\\$1, (.+), \\$18\\) RETURNING \\*$").WillReturnRows(sut.marketRows(1))
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::CreateMany] - 501 markets created", []zapcore.Field(nil))
+
+		result, err := sut.repo.CreateMany(context.Background(), markets)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, createManyChunk+1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not touch the database without markets", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		result, err := sut.repo.CreateMany(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back every chunk when one fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		markets := make([]valueObjects.MarketValueObjects, createManyChunk+1)
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectQuery("INSERT INTO feiras").WillReturnRows(sut.marketRows(createManyChunk))
+		sut.sqlMock.ExpectQuery("INSERT INTO feiras").WillReturnError(fmt.Errorf("connection reset"))
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Error", "[MarketRepository::CreateMany] query execution error", []zapcore.Field(nil))
+
+		result, err := sut.repo.CreateMany(context.Background(), markets)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Nil(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return ErrMarketAlreadyExists on unique violation", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectQuery("INSERT INTO feiras").WillReturnError(&pq.Error{Code: "23505", Constraint: "feiras_registro_key"})
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Warn", "[MarketRepository::CreateMany] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := sut.repo.CreateMany(context.Background(), []valueObjects.MarketValueObjects{sut.marketMocked})
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when the commit fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectQuery("INSERT INTO feiras").WillReturnRows(sut.marketRows(1))
+		sut.sqlMock.ExpectCommit().WillReturnError(fmt.Errorf("connection reset"))
+		sut.logger.On("Error", "[MarketRepository::CreateMany] - commit failure: connection reset", []zapcore.Field(nil))
+
+		_, err := sut.repo.CreateMany(context.Background(), []valueObjects.MarketValueObjects{sut.marketMocked})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when the transaction can not begin", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin().WillReturnError(fmt.Errorf("too many connections"))
+		sut.logger.On("Error", "[MarketRepository::CreateMany] Error in begin transaction", []zapcore.Field(nil))
+
+		_, err := sut.repo.CreateMany(context.Background(), []valueObjects.MarketValueObjects{sut.marketMocked})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_QueryTimeout(t *testing.T) {
+	t.Run("should return promptly and log the context error when the context is already canceled", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		sut.logger.On("Error", "[MarketRepository::Find] Error in prepare statement", []zapcore.Field(nil))
+		sut.logger.On("Error", "[MarketRepository::Find] - context error: context canceled", []zapcore.Field(nil))
+
+		result, err := sut.repo.Find(ctx, valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should cancel the query once DB_QUERY_TIMEOUT expires", func(t *testing.T) {
+		os.Setenv("DB_QUERY_TIMEOUT", "20ms")
+		defer os.Unsetenv("DB_QUERY_TIMEOUT")
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito", 10, 0).WillDelayFor(time.Second).WillReturnRows(sut.marketRows(1))
+		sut.logger.On("Error", "[MarketRepository::FindByDistritoPaged] query execution error", []zapcore.Field(nil))
+		sut.logger.On("Error", "[MarketRepository::FindByDistritoPaged] - context error: context deadline exceeded", []zapcore.Field(nil))
+
+		started := time.Now()
+		_, err := sut.repo.FindByDistritoPaged(context.Background(), "distrito", 1, 10)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Less(t, int64(time.Since(started)), int64(500*time.Millisecond))
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not log a context error on success", func(t *testing.T) {
+		os.Setenv("DB_QUERY_TIMEOUT", "1s")
+		defer os.Unsetenv("DB_QUERY_TIMEOUT")
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.marketRows(1))
+
+		_, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		sut.logger.AssertNotCalled(t, "Error", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should read the timeout from env", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_QUERY_TIMEOUT", "2s")
+		defer os.Unsetenv("DB_QUERY_TIMEOUT")
+
+		assert.Equal(t, 2*time.Second, queryTimeoutFromEnv(log))
+	})
+
+	t.Run("should disable the timeout when the env is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_QUERY_TIMEOUT", "soon")
+		defer os.Unsetenv("DB_QUERY_TIMEOUT")
+		log.On("Warn", "[MarketRepository] - invalid DB_QUERY_TIMEOUT: soon", []zapcore.Field(nil))
+
+		assert.Equal(t, time.Duration(0), queryTimeoutFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_DedupeByProximity(t *testing.T) {
+	t.Run("should soft-delete the markets with an older one within the threshold", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := `UPDATE feiras SET deletado_em = \$1 WHERE deletado_em IS NULL AND EXISTS \( SELECT 1 FROM \( ` +
+			`SELECT id AS target_id, long AS target_long, lat AS target_lat, criado_em AS target_criado_em FROM feiras WHERE deletado_em IS NULL \) AS target ` +
+			`WHERE \(target_criado_em, target_id\) < \(criado_em, id\) AND 6371000 \* 2 \* asin\(sqrt\((.+)\)\) <= \$2 \) RETURNING id, registro$`
+		sut.sqlMock.ExpectBegin()
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs(sqlmock.AnyArg(), 15.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "registro"}).AddRow(4, "4045-2").AddRow(9, "4041-1"))
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::DedupeByProximity] - success", []zapcore.Field{
+			zap.String("operation", "dedupebyproximity"), zap.Int("id", 4), zap.String("registro", "4045-2"),
+		})
+		sut.logger.On("Info", "[MarketRepository::DedupeByProximity] - success", []zapcore.Field{
+			zap.String("operation", "dedupebyproximity"), zap.Int("id", 9), zap.String("registro", "4041-1"),
+		})
+
+		removed, err := sut.repo.DedupeByProximity(context.Background(), 15)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), removed)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should remove nothing when no markets are close", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectPrepare("UPDATE feiras").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "registro"}))
+		sut.sqlMock.ExpectCommit()
+
+		removed, err := sut.repo.DedupeByProximity(context.Background(), 15)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), removed)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back when reading the results fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectPrepare("UPDATE feiras").ExpectQuery().
+			WillReturnRows(sqlmock.NewRows([]string{"id", "registro"}).AddRow(4, "4045-2").RowError(0, fmt.Errorf("connection reset")))
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Error", "[MarketRepository::DedupeByProximity] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		removed, err := sut.repo.DedupeByProximity(context.Background(), 15)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Equal(t, int64(0), removed)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertNotCalled(t, "Info", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return validation error when the threshold is not positive", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.DedupeByProximity(context.Background(), 0)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectPrepare("UPDATE feiras").ExpectQuery().WillReturnError(fmt.Errorf("deadlock detected"))
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Error", "[MarketRepository::DedupeByProximity] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.DedupeByProximity(context.Background(), 15)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_MarketRepo_WithTx(t *testing.T) {
+	t.Run("should run every write on the same transaction and commit", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnRows(sut.marketRows(1))
+		sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+		sut.logger.On("Info", "[MarketRepository::Delete] - success", mock.Anything)
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+			if _, err := repo.Create(context.Background(), sut.marketMocked); err != nil {
+				return err
+			}
+			return repo.Delete(context.Background(), "registro")
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back, leaving the table unchanged, when fn fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectPrepare("INSERT INTO feiras").ExpectQuery().WillReturnRows(sut.marketRows(1))
+		sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+			if _, err := repo.Create(context.Background(), sut.marketMocked); err != nil {
+				return err
+			}
+			return repo.Delete(context.Background(), "missing")
+		})
+
+		assert.Equal(t, errors.ErrMarketNotFound, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should join the transaction when nested", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectQuery("INSERT INTO feiras").WillReturnRows(sut.marketRows(1))
+		sut.sqlMock.ExpectCommit()
+		sut.logger.On("Info", "[MarketRepository::CreateMany] - 1 markets created", []zapcore.Field(nil))
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+			_, err := repo.CreateMany(context.Background(), []valueObjects.MarketValueObjects{sut.marketMocked})
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back when fn panics", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectRollback()
+
+		assert.Panics(t, func() {
+			sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+				panic("boom")
+			})
+		})
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when the transaction can not begin", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin().WillReturnError(fmt.Errorf("too many connections"))
+		sut.logger.On("Error", "[MarketRepository::WithTx] Error in begin transaction", []zapcore.Field(nil))
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+			t.Fatal("fn should not run")
+			return nil
+		})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindForPublic(t *testing.T) {
+	publicColumns := []string{"id", "long", "lat", "setcens", "areap", "coddist", "distrito", "codsubpref", "subpref", "regiao5", "regiao8",
+		"nome_feira", "registro", "numero", "bairro"}
+
+	t.Run("should select every column but logradouro and referencia", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "SELECT id, long, lat, setcens, areap, coddist, distrito, codsubpref, subpref, regiao5, regiao8, nome_feira, registro, " +
+			"numero, bairro FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY id ASC$"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sqlmock.NewRows(publicColumns).
+			AddRow(1, -46550164, -23558733, "setcens", "areap", 87, "distrito", 26, "subpref", "Leste", "Leste 1", "VILA FORMOSA", "4041-0", "S/N", "VL FORMOSA"))
+
+		result, err := sut.repo.FindForPublic(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []valueObjects.MarketValueObjects{{
+			ID: 1, Long: -46550164, Lat: -23558733, Setcens: "setcens", Areap: "areap", Coddist: 87, Distrito: "distrito", Codsubpref: 26,
+			Subpref: "subpref", Regiao5: "Leste", Regiao8: "Leste 1", NomeFeira: "VILA FORMOSA", Registro: "4041-0", Numero: "S/N", Bairro: "VL FORMOSA",
+		}}, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should ignore filters on the redacted columns", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY id ASC$")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sqlmock.NewRows(publicColumns))
+
+		result, err := sut.repo.FindForPublic(context.Background(), valueObjects.MarketValueObjects{
+			Distrito: "distrito", Logradouro: "RUA MARAGOJIPE", LogradouroContains: "MARAGOJIPE", Referencia: "TV RUA PRETORIA",
+		})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Empty(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindForPublic] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindForPublic(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when scanning fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("FROM feiras")
+		prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		sut.logger.On("Error", "[MarketRepository::FindForPublic] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindForPublic(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindByID(t *testing.T) {
+	t.Run("should return the active market with the id", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE id = \\$1 AND deletado_em IS NULL LIMIT 1$")
+		prepare.ExpectQuery().WithArgs(1).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.ID)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when the market is missing or deleted", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WHERE id = ")
+		prepare.ExpectQuery().WithArgs(10).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.FindByID(context.Background(), 10)
+
+		assert.Equal(t, errors.NewNotFoundError("Market with the ID: 10 was not found"), err)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindByID] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByID(context.Background(), 1)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindByID] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByID(context.Background(), 1)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_UUIDIDType(t *testing.T) {
+	t.Run("should read the id type from DB_ID_TYPE", func(t *testing.T) {
+		os.Setenv("DB_ID_TYPE", "uuid")
+		defer os.Unsetenv("DB_ID_TYPE")
+
+		repo := NewMarketRepository(nil, nil).(marketRepository)
+
+		assert.True(t, repo.uuidIDs)
+	})
+
+	t.Run("should fail fast FindByID with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		_, err := repo.FindByID(context.Background(), 1)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_MarketRepo_FindByUUID(t *testing.T) {
+	t.Run("should return the active market with the uuid", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE id = \\$1 AND deletado_em IS NULL LIMIT 1$")
+		prepare.ExpectQuery().WithArgs(uuidMocked).WillReturnRows(sut.uuidMarketRows())
+
+		result, err := sut.repo.FindByUUID(context.Background(), uuidMocked)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uuidMocked, result.UUID)
+		assert.Equal(t, 0, result.ID)
+		assert.Equal(t, sut.marketMocked.Registro, result.Registro)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when the market is missing or deleted", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WHERE id = ")
+		prepare.ExpectQuery().WithArgs(uuidMocked).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.FindByUUID(context.Background(), uuidMocked)
+
+		assert.Equal(t, errors.NewNotFoundError("Market with the ID: "+uuidMocked+" was not found"), err)
+	})
+
+	t.Run("should read uuid ids in the other queries", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WillReturnRows(sut.uuidMarketRows())
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uuidMocked, result[0].UUID)
+	})
+}
+
+func Test_MarketRepo_FindByRegistro(t *testing.T) {
+	t.Run("should return the active market with the registro", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE registro = \\$1 AND deletado_em IS NULL LIMIT 1$")
+		prepare.ExpectQuery().WithArgs("registro").WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindByRegistro(context.Background(), "registro")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "registro", result.Registro)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return not found when the market is missing or deleted", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WHERE registro = ")
+		prepare.ExpectQuery().WithArgs("4041-0").WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.FindByRegistro(context.Background(), "4041-0")
+
+		assert.Equal(t, errors.NewNotFoundError("Market with the RegisterCode: 4041-0 was not found"), err)
+	})
+
+	t.Run("should return err if reading the rows fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WHERE registro = ")
+		prepare.ExpectQuery().WillReturnRows(sut.marketRows(1).RowError(0, fmt.Errorf("connection reset")))
+		sut.logger.On("Error", "[MarketRepository::FindByRegistro] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByRegistro(context.Background(), "registro")
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindPage(t *testing.T) {
+	t.Run("should return the page and the total matching the filter", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1$")
+		count.ExpectQuery().WithArgs("distrito").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(45))
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY nome_feira ASC, id ASC LIMIT \\$2 OFFSET \\$3$")
+		prepare.ExpectQuery().WithArgs("distrito", 10, 40).WillReturnRows(sut.marketRows(5))
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"}, valueObjects.Pagination{Limit: 10, Offset: 40})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(45), page.Total)
+		assert.Equal(t, 10, page.Limit)
+		assert.Equal(t, 40, page.Offset)
+		assert.Len(t, page.Markets, 5)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should default the limit and clamp a negative offset", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		prepare := sut.sqlMock.ExpectPrepare("LIMIT \\$1 OFFSET \\$2$")
+		prepare.ExpectQuery().WithArgs(defaultPageSize, 0).WillReturnRows(sut.marketRows(3))
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Offset: -5})
+
+		assert.NoError(t, err)
+		assert.Equal(t, defaultPageSize, page.Limit)
+		assert.Equal(t, 0, page.Offset)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should cap the limit at MAX_PAGE_SIZE", func(t *testing.T) {
+		os.Setenv("MAX_PAGE_SIZE", "50")
+		defer os.Unsetenv("MAX_PAGE_SIZE")
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1000))
+		prepare := sut.sqlMock.ExpectPrepare("LIMIT \\$1 OFFSET \\$2$")
+		prepare.ExpectQuery().WithArgs(50, 0).WillReturnRows(sut.marketRows(1))
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Limit: 5000})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 50, page.Limit)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should skip the page query when nothing matches", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WithArgs("distrito").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"}, valueObjects.Pagination{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), page.Total)
+		assert.NotNil(t, page.Markets)
+		assert.Empty(t, page.Markets)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an empty page past the last one", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		prepare := sut.sqlMock.ExpectPrepare("LIMIT")
+		prepare.ExpectQuery().WithArgs(defaultPageSize, 100).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Offset: 100})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), page.Total)
+		assert.NotNil(t, page.Markets)
+		assert.Empty(t, page.Markets)
+	})
+
+	t.Run("should order by the requested column and direction", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL ORDER BY criado_em DESC, id ASC LIMIT \\$1 OFFSET \\$2$")
+		prepare.ExpectQuery().WithArgs(defaultPageSize, 0).WillReturnRows(sut.marketRows(3))
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{OrderBy: "criado_em", Descending: true})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should order nome_feira with DB_NAME_COLLATION", func(t *testing.T) {
+		os.Setenv("DB_NAME_COLLATION", "pt-BR-x-icu")
+		defer os.Unsetenv("DB_NAME_COLLATION")
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		prepare := sut.sqlMock.ExpectPrepare(`ORDER BY nome_feira COLLATE "pt-BR-x-icu" DESC, id ASC LIMIT`)
+		prepare.ExpectQuery().WithArgs(defaultPageSize, 0).WillReturnRows(sut.marketRows(3))
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Descending: true})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject a column out of the whitelist before querying", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{OrderBy: "id; DROP TABLE feiras"})
+
+		assert.Equal(t, errors.NewValidationError("order_by must be one of nome_feira, distrito, bairro, criado_em"), err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err if the count fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::Count] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		prepare := sut.sqlMock.ExpectPrepare("LIMIT")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindPage] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should count and list in the same repeatable read snapshot", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1$")
+		count.ExpectQuery().WithArgs("distrito").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY nome_feira ASC, id ASC LIMIT \\$2 OFFSET \\$3$")
+		prepare.ExpectQuery().WithArgs("distrito", 10, 10).WillReturnRows(sut.marketRows(2))
+		sut.sqlMock.ExpectCommit()
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"},
+			valueObjects.Pagination{Limit: 10, Offset: 10, Snapshot: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), page.Total)
+		assert.Len(t, page.Markets, 2)
+		assert.Equal(t, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, snapshotTxOptions)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should commit the snapshot when nothing matches", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		sut.sqlMock.ExpectCommit()
+
+		page, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Snapshot: true})
+
+		assert.NoError(t, err)
+		assert.Empty(t, page.Markets)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should roll back the snapshot if the page query fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin()
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT")
+		count.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		prepare := sut.sqlMock.ExpectPrepare("LIMIT")
+		prepare.ExpectQuery().WillReturnError(fmt.Errorf("could not serialize access"))
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Error", "[MarketRepository::FindPage] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Snapshot: true})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if the snapshot can not begin", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.sqlMock.ExpectBegin().WillReturnError(fmt.Errorf("too many connections"))
+		sut.logger.On("Error", "[MarketRepository::FindPage] Error in begin transaction", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindPage(context.Background(), valueObjects.MarketValueObjects{}, valueObjects.Pagination{Snapshot: true})
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should fall back to the default max page size when the env is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("MAX_PAGE_SIZE", "0")
+		defer os.Unsetenv("MAX_PAGE_SIZE")
+		log.On("Warn", "[MarketRepository] - invalid MAX_PAGE_SIZE: 0", []zapcore.Field(nil))
+
+		assert.Equal(t, defaultMaxPageSize, MaxPageSizeFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindNearestToPoints(t *testing.T) {
+	points := []valueObjects.Coordinate{{Long: -46550164, Lat: -23558733}, {Long: -46574716, Lat: -23584852}}
+
+	t.Run("should order the markets by the nearest and then the total distance to the points", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := `SELECT (.+) FROM feiras, LATERAL \( SELECT MIN\(distance\) AS nearest, SUM\(distance\) AS total FROM \( ` +
+			`SELECT 6371000 \* 2 \* asin\(sqrt\( power\(sin\(radians\(\(lat - target_lat\) / 1000000.0\) / 2\), 2\) (.+)\)\) AS distance ` +
+			`FROM \(VALUES \(\$1::float8, \$2::float8\), \(\$3::float8, \$4::float8\)\) AS target \(target_long, target_lat\) ` +
+			`\) AS distances \) AS route WHERE deletado_em IS NULL ORDER BY route.nearest ASC, route.total ASC, id ASC LIMIT \$5$`
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs(-46550164.0, -23558733.0, -46574716.0, -23584852.0, 3).
+			WillReturnRows(sut.marketRows(3))
+
+		result, err := sut.repo.FindNearestToPoints(context.Background(), points, 3)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 3)
+		for i, market := range result {
+			assert.Equal(t, i+1, market.ID)
+		}
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should use the default limit", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare(`FROM \(VALUES \(\$1::float8, \$2::float8\)\) AS target (.+) LIMIT \$3$`)
+		prepare.ExpectQuery().WithArgs(-46550164.0, -23558733.0, defaultPageSize).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindNearestToPoints(context.Background(), points[:1], 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return validation error without points", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindNearestToPoints(context.Background(), nil, 3)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+	})
+
+	t.Run("should return validation error above the points limit", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindNearestToPoints(context.Background(), make([]valueObjects.Coordinate, maxRoutePoints+1), 3)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindNearestToPoints] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindNearestToPoints(context.Background(), points, 3)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindNearestToPoints] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindNearestToPoints(context.Background(), points, 3)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindSiblingsBySubpref(t *testing.T) {
+	t.Run("should fail fast with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		_, err := repo.FindSiblingsBySubpref(context.Background(), 1, 3)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should match the subprefeitura of the market and exclude it", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "WITH target AS \\(SELECT codsubpref AS target_subpref FROM feiras WHERE id = \\$1 AND deletado_em IS NULL\\) " +
+			"SELECT (.+) FROM feiras, target WHERE deletado_em IS NULL AND codsubpref = target_subpref " +
+			"ORDER BY id = \\$1 DESC, nome_feira ASC, id ASC LIMIT \\$2"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs(1, 4).WillReturnRows(sut.marketRows(3))
+
+		result, err := sut.repo.FindSiblingsBySubpref(context.Background(), 1, 3)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, 2, result[0].ID)
+		assert.Equal(t, 3, result[1].ID)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an empty list when the market is alone in the subprefeitura", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WITH target")
+		prepare.ExpectQuery().WithArgs(1, defaultPageSize+1).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindSiblingsBySubpref(context.Background(), 1, 0)
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("should return not found when the market is missing or deleted", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WITH target")
+		prepare.ExpectQuery().WithArgs(10, 6).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.FindSiblingsBySubpref(context.Background(), 10, 5)
+
+		assert.Equal(t, errors.NewNotFoundError("Market with the ID: 10 was not found"), err)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindSiblingsBySubpref] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindSiblingsBySubpref(context.Background(), 1, 5)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+// nearbyFixtures are markets around the Se cathedral, at -23550520, -46633308, with their haversine distance.
+var nearbyFixtures = []struct {
+	lat, long, meters float64
+}{
+	{-23546023, -46633308, 500.0435851200793},
+	{-23550520, -46622308, 1121.266219332834},
+	{-23561000, -46656000, 2589.9507322205345},
+}
+
+func Test_MarketRepo_FindNearby(t *testing.T) {
+	t.Run("should return the markets nearest first with their distance in km", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		minLat, maxLat, minLong, maxLong := boundingBox(-23550520, -46633308, 3)
+		prepare := sut.sqlMock.ExpectPrepare(
+			`WITH target AS \(SELECT \$1::float8 AS target_lat, \$2::float8 AS target_long\) SELECT (.+) deletado_em AS DeletadoEm,\s+6371000 (.+) AS distance\s+FROM feiras, target\s+` +
+				`WHERE deletado_em IS NULL AND lat BETWEEN \$3 AND \$4 AND long BETWEEN \$5 AND \$6 AND 6371000 (.+) <= \$7\s+ORDER BY distance ASC, id ASC\s+LIMIT \$8$`)
+		rows := sut.sqlMock.NewRows(append(marketColumns, "distance"))
+		for i, fixture := range nearbyFixtures {
+			m := sut.modelMocked
+			rows.AddRow(i+1, int(fixture.long), int(fixture.lat), m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref, m.Subpref, m.Regiao5, m.Regiao8,
+				m.NomeFeira, m.Registro, m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm, m.AtualizadoEm, m.DeletadoEm, fixture.meters)
+		}
+		prepare.ExpectQuery().WithArgs(-23550520.0, -46633308.0, minLat, maxLat, minLong, maxLong, 3000.0, 5).WillReturnRows(rows)
+
+		result, err := sut.repo.FindNearby(context.Background(), -23550520, -46633308, 3, 5)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 3)
+		for i, fixture := range nearbyFixtures {
+			assert.Equal(t, i+1, result[i].Market.ID)
+			assert.InDelta(t, fixture.meters/1000, result[i].DistanceKm, 1e-9)
+		}
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should default the limit and return an empty slice when nothing is near", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("AS distance")
+		prepare.ExpectQuery().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			500.0, defaultPageSize).WillReturnRows(sut.sqlMock.NewRows(append(marketColumns, "distance")))
+
+		result, err := sut.repo.FindNearby(context.Background(), -23550520, -46633308, 0.5, 0)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Empty(t, result)
+	})
+
+	t.Run("should reject a zero or negative radius", func(t *testing.T) {
+		for _, radius := range []float64{0, -1} {
+			sut := makeMarketRepositorySut()
+
+			_, err := sut.repo.FindNearby(context.Background(), -23550520, -46633308, radius, 5)
+
+			assert.Equal(t, errors.NewValidationError("radius must be greater than zero"), err)
+			assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("AS distance")
+		prepare.ExpectQuery().WillReturnError(fmt.Errorf("timeout"))
+		sut.logger.On("Error", "[MarketRepository::FindNearby] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindNearby(context.Background(), -23550520, -46633308, 1, 5)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_BoundingBox(t *testing.T) {
+	inside := func(lat, long, radiusKm float64) bool {
+		minLat, maxLat, minLong, maxLong := boundingBox(-23550520, -46633308, radiusKm)
+		return lat >= minLat && lat <= maxLat && long >= minLong && long <= maxLong
+	}
+
+	t.Run("should hold the fixtures within the radius", func(t *testing.T) {
+		assert.True(t, inside(nearbyFixtures[0].lat, nearbyFixtures[0].long, 1))
+		assert.False(t, inside(nearbyFixtures[1].lat, nearbyFixtures[1].long, 1))
+		assert.False(t, inside(nearbyFixtures[2].lat, nearbyFixtures[2].long, 1))
+
+		for _, fixture := range nearbyFixtures {
+			assert.True(t, inside(fixture.lat, fixture.long, 3))
+		}
+	})
+
+	t.Run("should span one km of latitude with about 8993 micro-degrees", func(t *testing.T) {
+		minLat, maxLat, _, _ := boundingBox(0, 0, 1)
+
+		assert.InDelta(t, 8993.2, maxLat, 0.1)
+		assert.InDelta(t, -8993.2, minLat, 0.1)
+	})
+
+	t.Run("should span every longitude near the poles and across the antimeridian", func(t *testing.T) {
+		_, maxLat, minLong, maxLong := boundingBox(89999000, 0, 10)
+		assert.Equal(t, 90000000.0, maxLat)
+		assert.Equal(t, [2]float64{-180000000, 180000000}, [2]float64{minLong, maxLong})
+
+		_, _, minLong, maxLong = boundingBox(0, 179999000, 10)
+		assert.Equal(t, [2]float64{-180000000, 180000000}, [2]float64{minLong, maxLong})
+	})
+}
+
+func Test_MarketRepo_FindWithNeighbors(t *testing.T) {
+	t.Run("should fail fast with the uuid id type", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		repo := sut.repo.(marketRepository)
+		repo.uuidIDs = true
+
+		_, err := repo.FindWithNeighbors(context.Background(), 1, 500, 3)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return the target first and the neighbors within the radius", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "WITH target AS \\(SELECT long AS target_long, lat AS target_lat FROM feiras WHERE id = \\$1 AND deletado_em IS NULL\\) SELECT (.+) FROM feiras, target WHERE deletado_em IS NULL AND \\(id = \\$1 OR (.+) <= \\$2\\) ORDER BY id = \\$1 DESC, (.+) LIMIT \\$3"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs(1, 500.0, 4).WillReturnRows(sut.marketRows(3))
+
+		result, err := sut.repo.FindWithNeighbors(context.Background(), 1, 500, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Market.ID)
+		assert.Len(t, result.Neighbors, 2)
+		assert.Equal(t, 2, result.Neighbors[0].ID)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an empty neighbor list when the target is alone", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WITH target")
+		prepare.ExpectQuery().WithArgs(1, 500.0, defaultPageSize+1).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindWithNeighbors(context.Background(), 1, 500, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Market.ID)
+		assert.Empty(t, result.Neighbors)
+	})
+
+	t.Run("should return not found when the target is missing or deleted", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("WITH target")
+		prepare.ExpectQuery().WithArgs(10, 500.0, 6).WillReturnRows(sut.sqlMock.NewRows(marketColumns))
+
+		_, err := sut.repo.FindWithNeighbors(context.Background(), 10, 500, 5)
+
+		assert.IsType(t, errors.NotFoundError{}, err)
+	})
+
+	t.Run("should return validation error when the radius is not positive", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindWithNeighbors(context.Background(), 1, 0, 5)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindWithNeighbors] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindWithNeighbors(context.Background(), 1, 500, 5)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindWithNeighbors] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindWithNeighbors(context.Background(), 1, 500, 5)
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindSummaries(t *testing.T) {
+	t.Run("should select only the summary columns", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "SELECT id, nome_feira, long, lat FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 ORDER BY id ASC"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs("VILA FORMOSA").WillReturnRows(
+			sut.sqlMock.NewRows([]string{"id", "nome_feira", "long", "lat"}).
+				AddRow(1, "VILA FORMOSA", -46550164, -23558733).
+				AddRow(2, "PRACA SANTA HELENA", -46574716, -23584852),
+		)
+
+		result, err := sut.repo.FindSummaries(context.Background(), valueObjects.MarketValueObjects{Distrito: "VILA FORMOSA"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []valueObjects.MarketSummary{
+			{ID: 1, NomeFeira: "VILA FORMOSA", Long: -46550164, Lat: -23558733},
+			{ID: 2, NomeFeira: "PRACA SANTA HELENA", Long: -46574716, Lat: -23584852},
+		}, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should read uuid ids", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT id, nome_feira, long, lat FROM feiras")
+		prepare.ExpectQuery().WillReturnRows(
+			sut.sqlMock.NewRows([]string{"id", "nome_feira", "long", "lat"}).AddRow([]byte(uuidMocked), "VILA FORMOSA", -46550164, -23558733),
+		)
+
+		result, err := sut.repo.FindSummaries(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []valueObjects.MarketSummary{{UUID: uuidMocked, NomeFeira: "VILA FORMOSA", Long: -46550164, Lat: -23558733}}, result)
+	})
+
+	t.Run("should return an empty slice when no market matches", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT id, nome_feira, long, lat FROM feiras WHERE deletado_em IS NULL ORDER BY id ASC")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(sut.sqlMock.NewRows([]string{"id", "nome_feira", "long", "lat"}))
+
+		result, err := sut.repo.FindSummaries(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Empty(t, result)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindSummaries] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindSummaries(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindSummaries] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindSummaries(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when scanning the result failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT id, nome_feira, long, lat")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(
+			sut.sqlMock.NewRows([]string{"id", "nome_feira", "long", "lat"}).AddRow(1, "VILA FORMOSA", "wrong", -23558733),
+		)
+		sut.logger.On("Error", "[MarketRepository::FindSummaries] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindSummaries(context.Background(), valueObjects.MarketValueObjects{})
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindCoordinateCollisions(t *testing.T) {
+	t.Run("should group the active markets sharing the same coordinates", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		query := "SELECT long, lat, COUNT\\(\\*\\), array_agg\\(registro ORDER BY registro\\) FROM feiras WHERE deletado_em IS NULL GROUP BY long, lat HAVING COUNT\\(\\*\\) > 1"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs().WillReturnRows(
+			sut.sqlMock.NewRows([]string{"long", "lat", "count", "registros"}).
+				AddRow(-46550000, -23550000, 2, "{1001-1,1002-2}").
+				AddRow(-46560000, -23560000, 3, `{2001-1,"2002,2",2003-3}`),
+		)
+
+		result, err := sut.repo.FindCoordinateCollisions(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []valueObjects.CoordinateGroup{
+			{Long: -46550000, Lat: -23550000, Count: 2, Registros: []string{"1001-1", "1002-2"}},
+			{Long: -46560000, Lat: -23560000, Count: 3, Registros: []string{"2001-1", "2002,2", "2003-3"}},
+		}, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an empty slice when there is no collision", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT long, lat")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(sut.sqlMock.NewRows([]string{"long", "lat", "count", "registros"}))
+
+		result, err := sut.repo.FindCoordinateCollisions(context.Background())
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		assert.NotNil(t, result)
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindCoordinateCollisions] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindCoordinateCollisions(context.Background())
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindCoordinateCollisions] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindCoordinateCollisions(context.Background())
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when scanning the result failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT long, lat")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(
+			sut.sqlMock.NewRows([]string{"long", "lat", "count", "registros"}).AddRow("wrong", -23550000, 2, "{1001-1,1002-2}"),
+		)
+		sut.logger.On("Error", "[MarketRepository::FindCoordinateCollisions] - scanning the result failure", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindCoordinateCollisions(context.Background())
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_FindCreatedBetween(t *testing.T) {
+	t.Run("should filter by the criado_em range paginated", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+		query := "SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND criado_em BETWEEN \\$1 AND \\$2 ORDER BY criado_em ASC, id ASC LIMIT \\$3 OFFSET \\$4"
+		prepare := sut.sqlMock.ExpectPrepare(query)
+		prepare.ExpectQuery().WithArgs(from, to, 10, 20).WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.FindCreatedBetween(context.Background(), from, to, 10, 20)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fallback to the default limit and the first offset", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs(from, from, defaultPageSize, 0).WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindCreatedBetween(context.Background(), from, from, 0, -1)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return validation error if from is after to", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		to := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		from := to.Add(time.Hour)
+
+		result, err := sut.repo.FindCreatedBetween(context.Background(), from, to, 10, 0)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.Nil(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindCreatedBetween] Error in prepare statement", []zapcore.Field(nil))
+
+		result, err := sut.repo.FindCreatedBetween(context.Background(), time.Now(), time.Now(), 10, 0)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::FindCreatedBetween] query execution error", []zapcore.Field(nil))
+
+		now := time.Now()
+		result, err := sut.repo.FindCreatedBetween(context.Background(), now, now, 10, 0)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_CountCreatedByPeriod(t *testing.T) {
+	for _, granularity := range []string{"day", "month"} {
+		t.Run("should group the created markets by "+granularity, func(t *testing.T) {
+			sut := makeMarketRepositorySut()
+
+			period := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+			query := "SELECT date_trunc\\('" + granularity + "', criado_em\\) AS period, COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL GROUP BY period ORDER BY period ASC"
+			prepare := sut.sqlMock.ExpectPrepare(query)
+			prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"period", "count"}).AddRow(period, 3).AddRow(period.AddDate(0, 1, 0), 2))
+
+			result, err := sut.repo.CountCreatedByPeriod(context.Background(), granularity)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []valueObjects.MarketTimelineValueObjects{{Period: period, Count: 3}, {Period: period.AddDate(0, 1, 0), Count: 2}}, result)
+			assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("should return an empty slice when there is no market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT date_trunc")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"period", "count"}))
+
+		result, err := sut.repo.CountCreatedByPeriod(context.Background(), "day")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Len(t, result, 0)
+	})
+
+	t.Run("should return validation error if granularity is not allowed", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		result, err := sut.repo.CountCreatedByPeriod(context.Background(), "year'); DROP TABLE feiras; --")
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.Nil(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::CountCreatedByPeriod] Error in prepare statement", []zapcore.Field(nil))
+
+		result, err := sut.repo.CountCreatedByPeriod(context.Background(), "day")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WithArgs()
+		sut.logger.On("Error", "[MarketRepository::CountCreatedByPeriod] query execution error", []zapcore.Field(nil))
+
+		result, err := sut.repo.CountCreatedByPeriod(context.Background(), "day")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if scan failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT date_trunc")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"period", "count"}).AddRow("wrong", "wrong"))
+		sut.logger.On("Error", "[MarketRepository::CountCreatedByPeriod] - scanning the result failure", []zapcore.Field(nil))
 
-		result, err := sut.repo.Create(context.Background(), sut.marketMocked)
+		result, err := sut.repo.CountCreatedByPeriod(context.Background(), "day")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if reading the rows fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT date_trunc")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"period", "count"}).AddRow(time.Now(), 1).RowError(0, fmt.Errorf("connection reset")))
+		sut.logger.On("Error", "[MarketRepository::CountCreatedByPeriod] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		result, err := sut.repo.CountCreatedByPeriod(context.Background(), "day")
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_CountByDistrito(t *testing.T) {
+	t.Run("should count the markets of the distrito", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(42))
+
+		count, err := sut.repo.CountByDistrito(context.Background(), "distrito")
 
 		assert.NoError(t, err)
-		assert.Equal(t, sut.marketMocked, result)
+		assert.Equal(t, int64(42), count)
 	})
 
 	t.Run("should return err when prepare statement failure", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.logger.On("Error", "[MarketRepository::Create] Error in prepare statement", []zapcore.Field(nil))
+		sut.logger.On("Error", "[MarketRepository::CountByDistrito] Error in prepare statement", []zapcore.Field(nil))
 
-		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+		_, err := sut.repo.CountByDistrito(context.Background(), "distrito")
 
 		assert.Error(t, err)
 		sut.logger.AssertExpectations(t)
@@ -44,47 +2894,232 @@ func Test_MarketRepo_Create(t *testing.T) {
 
 		prepare := sut.sqlMock.ExpectPrepare("")
 		prepare.ExpectQuery().WithArgs()
-		sut.logger.On("Error", "[MarketRepository::Create] query execution error", []zapcore.Field(nil))
+		sut.logger.On("Error", "[MarketRepository::CountByDistrito] query execution error", []zapcore.Field(nil))
 
-		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+		_, err := sut.repo.CountByDistrito(context.Background(), "distrito")
 
 		assert.Error(t, err)
 		sut.logger.AssertExpectations(t)
 	})
+}
 
-	t.Run("should return err when scanning failure", func(t *testing.T) {
+func Test_MarketRepo_FindByBairro(t *testing.T) {
+	t.Run("should match the bairro exactly", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND bairro = \\$1 ORDER BY id ASC")
+		prepare.ExpectQuery().WithArgs("VL FORMOSA").WillReturnRows(sut.marketRows(1))
+
+		result, err := sut.repo.FindByBairro(context.Background(), "VL FORMOSA", false)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should match the bairro fuzzily with the wildcards escaped", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND bairro ILIKE \\$1 ESCAPE '\\\\' ORDER BY id ASC")
+		prepare.ExpectQuery().WithArgs(`%formosa\_%`).WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.FindByBairro(context.Background(), " formosa_ ", true)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return validation error when the bairro is empty", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindByBairro(context.Background(), " ", true)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
 		prepare := sut.sqlMock.ExpectPrepare("")
-		row := sut.sqlMock.NewRows([]string{""})
-		prepare.ExpectQuery().WithArgs().WillReturnRows(row)
-		sut.logger.On("Error", "[MarketRepository::Create] - scanning the result failure", []zapcore.Field(nil))
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::FindByBairro] query execution error", []zapcore.Field(nil))
 
-		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+		_, err := sut.repo.FindByBairro(context.Background(), "bairro", false)
 
 		assert.Error(t, err)
 		sut.logger.AssertExpectations(t)
 	})
 }
 
-func Test_MarketRepo_Find(t *testing.T) {
-	t.Run("should execute correctly", func(t *testing.T) {
+func Test_MarketRepo_FindByRegiao8(t *testing.T) {
+	t.Run("should filter by regiao8", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.sqlMockForFindSuccessfully()
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras WHERE deletado_em IS NULL AND regiao8 = \\$1$")
+		prepare.ExpectQuery().WithArgs("Leste 1").WillReturnRows(sut.marketRows(2))
 
-		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Long: sut.marketMocked.Long})
+		result, err := sut.repo.FindByRegiao8(context.Background(), "Leste 1")
 
 		assert.NoError(t, err)
-		assert.NotNil(t, result)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return validation error if regiao8 is empty", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		result, err := sut.repo.FindByRegiao8(context.Background(), "")
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.Nil(t, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_MarketRepo_FindByRegiao8Paged(t *testing.T) {
+	regiao8Rows := func(sut marketRepositorySutRtn, total int64, ids ...int) *sqlmock.Rows {
+		rows := sut.sqlMock.NewRows(append(marketColumns, "total"))
+		for _, id := range ids {
+			m := sut.modelMocked
+			rows.AddRow(id, m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref, m.Subpref, m.Regiao5, "Leste 1", m.NomeFeira,
+				m.Registro, m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm, m.AtualizadoEm, m.DeletadoEm, total)
+		}
+
+		return rows
+	}
+
+	t.Run("should read the page and the regiao8 total in one query", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare(
+			"SELECT (.+) deletado_em AS DeletadoEm,\\s+COUNT\\(\\*\\) OVER\\(\\) AS total\\s+FROM feiras\\s+" +
+				"WHERE deletado_em IS NULL AND regiao8 = \\$1\\s+ORDER BY nome_feira ASC, id ASC\\s+LIMIT \\$2 OFFSET \\$3$")
+		prepare.ExpectQuery().WithArgs("Leste 1", 2, 2).WillReturnRows(regiao8Rows(sut, 5, 3, 4))
+
+		result, err := sut.repo.FindByRegiao8Paged(context.Background(), "Leste 1", 2, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), result.Total)
+		assert.Equal(t, 2, result.Limit)
+		assert.Equal(t, 2, result.Offset)
+		assert.Len(t, result.Markets, 2)
+		assert.Equal(t, 3, result.Markets[0].ID)
+		assert.Equal(t, "Leste 1", result.Markets[1].Regiao8)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should default the page and cap the page size", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("COUNT\\(\\*\\) OVER\\(\\)")
+		prepare.ExpectQuery().WithArgs("Leste 1", defaultMaxPageSize, 0).WillReturnRows(regiao8Rows(sut, 1, 1))
+
+		result, err := sut.repo.FindByRegiao8Paged(context.Background(), "Leste 1", 0, defaultMaxPageSize+1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), result.Total)
+		assert.Equal(t, defaultMaxPageSize, result.Limit)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should count the regiao8 apart when the page is past the end", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("COUNT\\(\\*\\) OVER\\(\\)")
+		prepare.ExpectQuery().WithArgs("Leste 1", 10, 90).WillReturnRows(regiao8Rows(sut, 0))
+		count := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND regiao8 = \\$1$")
+		count.ExpectQuery().WithArgs("Leste 1").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(12))
+
+		result, err := sut.repo.FindByRegiao8Paged(context.Background(), "Leste 1", 10, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), result.Total)
+		assert.NotNil(t, result.Markets)
+		assert.Empty(t, result.Markets)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not count apart when the first page is empty", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("COUNT\\(\\*\\) OVER\\(\\)")
+		prepare.ExpectQuery().WithArgs("Norte 1", 10, 0).WillReturnRows(regiao8Rows(sut, 0))
+
+		result, err := sut.repo.FindByRegiao8Paged(context.Background(), "Norte 1", 1, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), result.Total)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return validation error if regiao8 is empty", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		_, err := sut.repo.FindByRegiao8Paged(context.Background(), "", 1, 10)
+
+		assert.IsType(t, errors.ValidationError{}, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return err when prepare statement failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		sut.logger.On("Error", "[MarketRepository::FindByRegiao8Paged] Error in prepare statement", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByRegiao8Paged(context.Background(), "Leste 1", 1, 10)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if query failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WillReturnError(fmt.Errorf("timeout"))
+		sut.logger.On("Error", "[MarketRepository::FindByRegiao8Paged] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByRegiao8Paged(context.Background(), "Leste 1", 1, 10)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when reading the results failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("")
+		prepare.ExpectQuery().WillReturnRows(regiao8Rows(sut, 5, 1).RowError(0, fmt.Errorf("connection reset")))
+		sut.logger.On("Error", "[MarketRepository::FindByRegiao8Paged] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		_, err := sut.repo.FindByRegiao8Paged(context.Background(), "Leste 1", 1, 10)
+
+		assert.IsType(t, errors.InternalError{}, err)
 		sut.logger.AssertExpectations(t)
 	})
+}
+
+func Test_MarketRepo_CountByRegiao8(t *testing.T) {
+	t.Run("should group the markets by regiao8", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT regiao8, COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL GROUP BY regiao8")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"regiao8", "count"}).AddRow("Leste 1", 3).AddRow("Sul 2", 5))
+
+		result, err := sut.repo.CountByRegiao8(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"Leste 1": 3, "Sul 2": 5}, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
 
 	t.Run("should return err when prepare statement failure", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.logger.On("Error", "[MarketRepository::Find] Error in prepare statement", []zapcore.Field(nil))
-		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Long: sut.marketMocked.Long})
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao8] Error in prepare statement", []zapcore.Field(nil))
+
+		result, err := sut.repo.CountByRegiao8(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -95,10 +3130,38 @@ func Test_MarketRepo_Find(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
 		prepare := sut.sqlMock.ExpectPrepare("")
-		prepare.ExpectQuery().WithArgs()
-		sut.logger.On("Error", "[MarketRepository::Find] query execution error", []zapcore.Field(nil))
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao8] query execution error", []zapcore.Field(nil))
 
-		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Long: sut.marketMocked.Long})
+		result, err := sut.repo.CountByRegiao8(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if scan failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT regiao8")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"regiao8", "count"}).AddRow("Leste 1", "wrong"))
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao8] - scanning the result failure", []zapcore.Field(nil))
+
+		result, err := sut.repo.CountByRegiao8(context.Background())
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err if reading the rows fails", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT regiao8")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"regiao8", "count"}).AddRow("Leste 1", 1).RowError(0, fmt.Errorf("connection reset")))
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao8] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		result, err := sut.repo.CountByRegiao8(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -106,27 +3169,41 @@ func Test_MarketRepo_Find(t *testing.T) {
 	})
 }
 
-func Test_MarketRepo_Update(t *testing.T) {
-	t.Run("should execute correctly", func(t *testing.T) {
+func Test_MarketRepo_CountByRegiao5InDistrito(t *testing.T) {
+	t.Run("should group the markets of the distrito by regiao5", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.sqlMockForUpdateSuccessfully()
-		sut.marketMocked.Registro = ""
-		sut.marketMocked.ID = 0
+		prepare := sut.sqlMock.ExpectPrepare("SELECT regiao5, COUNT\\(\\*\\) FROM feiras WHERE deletado_em IS NULL AND distrito = \\$1 GROUP BY regiao5")
+		prepare.ExpectQuery().WithArgs("VILA FORMOSA").WillReturnRows(sut.sqlMock.NewRows([]string{"regiao5", "count"}).AddRow("Leste", 4))
 
-		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+		result, err := sut.repo.CountByRegiao5InDistrito(context.Background(), "VILA FORMOSA")
 
 		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"Leste": 4}, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an empty map for a distrito without markets", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT regiao5")
+		prepare.ExpectQuery().WithArgs("unknown").WillReturnRows(sut.sqlMock.NewRows([]string{"regiao5", "count"}))
+
+		result, err := sut.repo.CountByRegiao5InDistrito(context.Background(), "unknown")
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{}, result)
 	})
 
 	t.Run("should return err when prepare statement failure", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.logger.On("Error", "[MarketRepository::Update] Error in prepare statement", []zapcore.Field(nil))
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao5InDistrito] Error in prepare statement", []zapcore.Field(nil))
 
-		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+		result, err := sut.repo.CountByRegiao5InDistrito(context.Background(), "VILA FORMOSA")
 
 		assert.Error(t, err)
+		assert.Nil(t, result)
 		sut.logger.AssertExpectations(t)
 	})
 
@@ -134,47 +3211,51 @@ func Test_MarketRepo_Update(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
 		prepare := sut.sqlMock.ExpectPrepare("")
-		prepare.ExpectQuery().WithArgs()
-		sut.logger.On("Error", "[MarketRepository::Update] query execution error", []zapcore.Field(nil))
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao5InDistrito] query execution error", []zapcore.Field(nil))
 
-		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+		result, err := sut.repo.CountByRegiao5InDistrito(context.Background(), "VILA FORMOSA")
 
 		assert.Error(t, err)
+		assert.Nil(t, result)
 		sut.logger.AssertExpectations(t)
 	})
 
-	t.Run("should return err when scanning failure", func(t *testing.T) {
+	t.Run("should return err if scan failure", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		prepare := sut.sqlMock.ExpectPrepare("")
-		row := sut.sqlMock.NewRows([]string{""})
-		prepare.ExpectQuery().WithArgs().WillReturnRows(row)
-		sut.logger.On("Error", "[MarketRepository::Update] - scanning the result failure", []zapcore.Field(nil))
+		prepare := sut.sqlMock.ExpectPrepare("SELECT regiao5")
+		prepare.ExpectQuery().WillReturnRows(sut.sqlMock.NewRows([]string{"regiao5", "count"}).AddRow("Leste", "wrong"))
+		sut.logger.On("Error", "[MarketRepository::CountByRegiao5InDistrito] - scanning the result failure", []zapcore.Field(nil))
 
-		_, err := sut.repo.Update(context.Background(), "registro", sut.marketMocked)
+		result, err := sut.repo.CountByRegiao5InDistrito(context.Background(), "VILA FORMOSA")
 
 		assert.Error(t, err)
+		assert.Nil(t, result)
 		sut.logger.AssertExpectations(t)
 	})
 }
 
-func Test_MarketRepo_Delete(t *testing.T) {
-	t.Run("should execute correctly", func(t *testing.T) {
+func Test_MarketRepo_CountDistinctRegistros(t *testing.T) {
+	t.Run("should count the distinct registros of the active markets", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.sqlMockForDeleteSuccessfully()
+		prepare := sut.sqlMock.ExpectPrepare("SELECT COUNT\\(DISTINCT registro\\) FROM feiras WHERE deletado_em IS NULL")
+		prepare.ExpectQuery().WithArgs().WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(880))
 
-		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+		count, err := sut.repo.CountDistinctRegistros(context.Background())
 
 		assert.NoError(t, err)
+		assert.Equal(t, int64(880), count)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
 	})
 
 	t.Run("should return err when prepare statement failure", func(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
-		sut.logger.On("Error", "[MarketRepository::Delete] Error in prepare statement", []zapcore.Field(nil))
+		sut.logger.On("Error", "[MarketRepository::CountDistinctRegistros] Error in prepare statement", []zapcore.Field(nil))
 
-		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+		_, err := sut.repo.CountDistinctRegistros(context.Background())
 
 		assert.Error(t, err)
 		sut.logger.AssertExpectations(t)
@@ -184,16 +3265,131 @@ func Test_MarketRepo_Delete(t *testing.T) {
 		sut := makeMarketRepositorySut()
 
 		prepare := sut.sqlMock.ExpectPrepare("")
-		prepare.ExpectQuery().WithArgs()
-		sut.logger.On("Error", "[MarketRepository::Delete] query execution error", []zapcore.Field(nil))
+		prepare.ExpectQuery().WithArgs("wrong")
+		sut.logger.On("Error", "[MarketRepository::CountDistinctRegistros] query execution error", []zapcore.Field(nil))
 
-		err := sut.repo.Delete(context.Background(), sut.marketMocked.Registro)
+		_, err := sut.repo.CountDistinctRegistros(context.Background())
+
+		assert.Error(t, err)
+		sut.logger.AssertExpectations(t)
+	})
+}
+
+func Test_MarketRepo_BuildQuery(t *testing.T) {
+	t.Run("should only use whitelisted column names", func(t *testing.T) {
+		deletedAt := time.Now()
+		where, fields := buildQuery("AND", "", valueObjects.MarketValueObjects{
+			ID: 10, Distrito: "x'; DROP TABLE feiras; --", DeletadoEm: &deletedAt,
+		})
+
+		assert.Equal(t, " AND distrito = $1", where)
+		assert.Equal(t, []interface{}{"x'; DROP TABLE feiras; --"}, fields)
+	})
+}
+
+func Test_MarketRepo_ScanMarkets(t *testing.T) {
+	t.Run("should return no markets when there are no rows", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		result, err := sut.scanMarkets(sut.marketRows(0))
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("should return a single market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		result, err := sut.scanMarkets(sut.marketRows(1))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []valueObjects.MarketValueObjects{sut.marketMocked}, result)
+	})
+
+	t.Run("should return every market", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		result, err := sut.scanMarkets(sut.marketRows(5))
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 5)
+	})
+
+	t.Run("should return err when a row in the middle can not be scanned", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		rows := sut.marketRows(1)
+		rows.AddRow(2, "wrong", -100, "setcens", "areap", 10, "distrito", 10, "subpref", "regiao5", "regiao8", "nomefeira", "registro",
+			"logradouro", "numero", "bairro", "referencia", sut.modelMocked.CriadoEm, sut.modelMocked.AtualizadoEm, nil)
+		rows.AddRow(3, -100, -100, "setcens", "areap", 10, "distrito", 10, "subpref", "regiao5", "regiao8", "nomefeira", "registro",
+			"logradouro", "numero", "bairro", "referencia", sut.modelMocked.CriadoEm, sut.modelMocked.AtualizadoEm, nil)
+
+		sut.logger.On("Error", "[MarketRepository::Test] - scanning the result failure", []zapcore.Field(nil))
+
+		result, err := sut.scanMarkets(rows)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return err when reading the rows fails after the last one", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		rows := sut.marketRows(3).RowError(2, fmt.Errorf("connection reset"))
+		sut.logger.On("Error", "[MarketRepository::Test] - reading the results failure: connection reset", []zapcore.Field(nil))
+
+		result, err := sut.scanMarkets(rows)
 
 		assert.Error(t, err)
+		assert.Nil(t, result)
 		sut.logger.AssertExpectations(t)
 	})
 }
 
+var marketColumns = []string{"id", "long", "lat", "setcens", "areap", "coddist", "distrito", "codsubpref", "subpref", "regiao5", "regiao8", "nome_feira",
+	"registro", "logradouro", "numero", "bairro", "referencia", "criado_em", "atualizado_em", "deletado_em"}
+
+func (pst marketRepositorySutRtn) marketRows(amount int) *sqlmock.Rows {
+	rows := pst.sqlMock.NewRows(marketColumns)
+	for i := 1; i <= amount; i++ {
+		m := pst.modelMocked
+		rows.AddRow(i, m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref, m.Subpref, m.Regiao5, m.Regiao8, m.NomeFeira,
+			m.Registro, m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm, m.AtualizadoEm, m.DeletadoEm)
+	}
+
+	return rows
+}
+
+func (pst marketRepositorySutRtn) upsertRows(inserted bool) *sqlmock.Rows {
+	m := pst.modelMocked
+	return pst.sqlMock.NewRows(append(marketColumns, "inserted")).AddRow(m.ID, m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito,
+		m.Codsubpref, m.Subpref, m.Regiao5, m.Regiao8, m.NomeFeira, m.Registro, m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm,
+		m.AtualizadoEm, m.DeletadoEm, inserted)
+}
+
+const uuidMocked = "4b0e8ac4-2a0a-4c9f-9a4e-58d0c1e0b6f1"
+
+// uuidMarketRows is a feiras row created with the uuid id type, its id sent as text the way lib/pq does.
+func (pst marketRepositorySutRtn) uuidMarketRows() *sqlmock.Rows {
+	m := pst.modelMocked
+	return pst.sqlMock.NewRows(marketColumns).AddRow([]byte(uuidMocked), m.Long, m.Lat, m.Setcens, m.Areap, m.Coddist, m.Distrito, m.Codsubpref,
+		m.Subpref, m.Regiao5, m.Regiao8, m.NomeFeira, m.Registro, m.Logradouro, m.Numero, m.Bairro, m.Referencia, m.CriadoEm, m.AtualizadoEm,
+		m.DeletadoEm)
+}
+
+// scanMarkets runs a query returning the given rows and scans them with the repository helper.
+func (pst marketRepositorySutRtn) scanMarkets(rows *sqlmock.Rows) ([]valueObjects.MarketValueObjects, error) {
+	pst.sqlMock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	result, err := pst.db.Query("SELECT")
+	if err != nil {
+		return nil, err
+	}
+
+	return pst.repo.(marketRepository).scanMarkets("Test", result)
+}
+
 type marketRepositorySutRtn struct {
 	logger       *logger.LoggerSpy
 	db           *sql.DB
@@ -204,6 +3400,7 @@ type marketRepositorySutRtn struct {
 }
 
 func (pst marketRepositorySutRtn) sqlMockForCreateSuccessfully() {
+	pst.logger.On("Info", "[MarketRepository::Create] - success", mock.Anything).Maybe()
 	query :=
 		"INSERT INTO feiras \\(long, lat, setcens, areap, coddist, distrito, codsubpref, subpref, regiao5, regiao8, nome_feira, registro, logradouro, numero, bairro, referencia, criado_em, atualizado_em\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7, \\$8, \\$9, \\$10, \\$11, \\$12, \\$13, \\$14, \\$15, \\$16, \\$17, \\$18\\) RETURNING \\*"
 	rows := pst.sqlMock.NewRows(
@@ -292,6 +3489,7 @@ func (pst marketRepositorySutRtn) sqlMockForFindSuccessfully() {
 }
 
 func (pst marketRepositorySutRtn) sqlMockForUpdateSuccessfully() {
+	pst.logger.On("Info", "[MarketRepository::Update] - success", mock.Anything).Maybe()
 	query :=
 		"UPDATE feiras  SET   long = \\$1,  lat = \\$2,  setcens = \\$3,  areap = \\$4,  coddist = \\$5,  distrito = \\$6,  codsubpref = \\$7,  subpref = \\$8,  regiao5 = \\$9,  regiao8 = \\$10,  nome_feira = \\$11,  logradouro = \\$12,  numero = \\$13,  bairro = \\$14,  referencia = \\$15 WHERE registro = \\$16 RETURNING feiras.\\*"
 	rows := pst.sqlMock.NewRows(
@@ -343,15 +3541,16 @@ func (pst marketRepositorySutRtn) sqlMockForUpdateSuccessfully() {
 }
 
 func (pst marketRepositorySutRtn) sqlMockForDeleteSuccessfully() {
-	query := "UPDATE feiras SET deletado_em = \\$1 WHERE registro = \\$2"
-	rows := pst.sqlMock.NewRows([]string{})
+	pst.logger.On("Info", "[MarketRepository::Delete] - success", mock.Anything).Maybe()
+	query := "UPDATE feiras SET deletado_em = \\$1 WHERE registro = \\$2 AND deletado_em IS NULL RETURNING id"
+	rows := pst.sqlMock.NewRows([]string{"id"}).AddRow(pst.modelMocked.ID)
 
 	prepare := pst.sqlMock.ExpectPrepare(query)
 
 	prepare.ExpectQuery().WithArgs(
 		pst.modelMocked.CriadoEm,
 		pst.modelMocked.Registro,
-	).WillReturnRows(rows)
+	).WillReturnRows(rows).RowsWillBeClosed()
 }
 
 func makeMarketRepositorySut() marketRepositorySutRtn {
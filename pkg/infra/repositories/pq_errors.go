@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+)
+
+const (
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+)
+
+type pgError struct {
+	code       string
+	column     string
+	constraint string
+}
+
+// toPgError unwraps err, so a driver error wrapped with %w is classified as the bare one.
+func toPgError(err error) (pgError, bool) {
+	var pqErr *pq.Error
+	if stderrors.As(err, &pqErr) {
+		return pgError{string(pqErr.Code), pqErr.Column, pqErr.Constraint}, true
+	}
+
+	var pgxErr *pgconn.PgError
+	if stderrors.As(err, &pgxErr) {
+		return pgError{pgxErr.Code, pgxErr.ColumnName, pgxErr.ConstraintName}, true
+	}
+
+	return pgError{}, false
+}
+
+// constraintError translates integrity constraint violations reported by either driver into domain errors.
+// It returns nil for any other error so the caller keeps its own handling.
+func constraintError(err error) error {
+	pgErr, ok := toPgError(err)
+	if !ok {
+		return nil
+	}
+
+	switch pgErr.code {
+	case uniqueViolation:
+		return errors.ErrMarketAlreadyExists
+	case notNullViolation:
+		return errors.NewValidationError(fmt.Sprintf("%s is required", pgErr.column))
+	case checkViolation:
+		return errors.NewValidationError(fmt.Sprintf("%s constraint violated", pgErr.constraint))
+	case foreignKeyViolation:
+		return errors.NewConflictError(fmt.Sprintf("%s constraint violated", pgErr.constraint))
+	default:
+		return nil
+	}
+}
+
+func isConstraintError(err error) bool {
+	switch err.(type) {
+	case errors.ConflictError, errors.ValidationError:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConstraintError(t *testing.T) {
+	cases := []struct {
+		name     string
+		pq       *pq.Error
+		pgx      *pgconn.PgError
+		expected error
+	}{
+		{
+			name:     "unique violation",
+			pq:       &pq.Error{Code: "23505", Constraint: "feiras_registro_key"},
+			pgx:      &pgconn.PgError{Code: "23505", ConstraintName: "feiras_registro_key"},
+			expected: errors.ErrMarketAlreadyExists,
+		},
+		{
+			name:     "not null violation",
+			pq:       &pq.Error{Code: "23502", Column: "nome_feira"},
+			pgx:      &pgconn.PgError{Code: "23502", ColumnName: "nome_feira"},
+			expected: errors.NewValidationError("nome_feira is required"),
+		},
+		{
+			name:     "check violation",
+			pq:       &pq.Error{Code: "23514", Constraint: "feiras_lat_check"},
+			pgx:      &pgconn.PgError{Code: "23514", ConstraintName: "feiras_lat_check"},
+			expected: errors.NewValidationError("feiras_lat_check constraint violated"),
+		},
+		{
+			name:     "foreign key violation",
+			pq:       &pq.Error{Code: "23503", Constraint: "feiras_distrito_fkey"},
+			pgx:      &pgconn.PgError{Code: "23503", ConstraintName: "feiras_distrito_fkey"},
+			expected: errors.NewConflictError("feiras_distrito_fkey constraint violated"),
+		},
+		{
+			name:     "unmapped code",
+			pq:       &pq.Error{Code: "40001"},
+			pgx:      &pgconn.PgError{Code: "40001"},
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run("should map the lib/pq "+c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, constraintError(c.pq))
+		})
+
+		t.Run("should map the pgx "+c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, constraintError(c.pgx))
+		})
+	}
+
+	t.Run("should map a wrapped driver error", func(t *testing.T) {
+		assert.Equal(t, errors.ErrMarketAlreadyExists, constraintError(fmt.Errorf("insert: %w", &pq.Error{Code: "23505"})))
+		assert.Equal(t, errors.NewConflictError("feiras_distrito_fkey constraint violated"),
+			constraintError(fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23503", ConstraintName: "feiras_distrito_fkey"})))
+	})
+
+	t.Run("should ignore errors that do not come from postgres", func(t *testing.T) {
+		assert.Nil(t, constraintError(fmt.Errorf("connection reset")))
+		assert.Nil(t, constraintError(nil))
+	})
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+// readOnlyMarketRepository rejects every write with errors.ErrReadOnly and delegates the reads.
+// It backs mirror deployments (READ_ONLY=true) even if a write route is left enabled.
+type readOnlyMarketRepository struct {
+	interfaces.IMarketRepository
+	logger interfaces.ILogger
+}
+
+func (pst readOnlyMarketRepository) Create(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	return valueObjects.MarketValueObjects{}, pst.reject("Create")
+}
+
+func (pst readOnlyMarketRepository) Upsert(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, bool, error) {
+	return valueObjects.MarketValueObjects{}, false, pst.reject("Upsert")
+}
+
+func (pst readOnlyMarketRepository) CreateMany(ctx context.Context, markets []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	return nil, pst.reject("CreateMany")
+}
+
+func (pst readOnlyMarketRepository) Update(ctx context.Context, registerCode string, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	return valueObjects.MarketValueObjects{}, pst.reject("Update")
+}
+
+func (pst readOnlyMarketRepository) UpdateByID(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	return valueObjects.MarketValueObjects{}, pst.reject("UpdateByID")
+}
+
+func (pst readOnlyMarketRepository) Delete(ctx context.Context, registerCode string) error {
+	return pst.reject("Delete")
+}
+
+func (pst readOnlyMarketRepository) DedupeByProximity(ctx context.Context, thresholdMeters float64) (int64, error) {
+	return 0, pst.reject("DedupeByProximity")
+}
+
+func (pst readOnlyMarketRepository) Restore(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	return valueObjects.MarketValueObjects{}, pst.reject("Restore")
+}
+
+func (pst readOnlyMarketRepository) Touch(ctx context.Context, id int) error {
+	return pst.reject("Touch")
+}
+
+// WithTx keeps the repository handed to fn read-only.
+func (pst readOnlyMarketRepository) WithTx(ctx context.Context, fn func(repo interfaces.IMarketRepository) error) error {
+	return pst.IMarketRepository.WithTx(ctx, func(repo interfaces.IMarketRepository) error {
+		return fn(readOnlyMarketRepository{repo, pst.logger})
+	})
+}
+
+func (pst readOnlyMarketRepository) reject(method string) error {
+	pst.logger.Warn(fmt.Sprintf("[MarketRepository::%s] - rejected in read-only mode", method))
+	return errors.ErrReadOnly
+}
+
+func NewReadOnlyMarketRepository(logger interfaces.ILogger, repo interfaces.IMarketRepository) interfaces.IMarketRepository {
+	return readOnlyMarketRepository{repo, logger}
+}
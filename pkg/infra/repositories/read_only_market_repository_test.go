@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_ReadOnlyMarketRepo_Writes(t *testing.T) {
+	t.Run("should reject Create", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::Create] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(sut.ctx, valueObjects.MarketValueObjects{Registro: "registro"})
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject Update", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::Update] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, err := sut.repo.Update(sut.ctx, "registro", valueObjects.MarketValueObjects{Bairro: "bairro"})
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject CreateMany", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::CreateMany] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, err := sut.repo.CreateMany(sut.ctx, []valueObjects.MarketValueObjects{{Registro: "4041-0"}})
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject Upsert", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::Upsert] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, _, err := sut.repo.Upsert(sut.ctx, valueObjects.MarketValueObjects{Registro: "4041-0"})
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should keep the repository read-only inside WithTx", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.inner.On("WithTx", sut.ctx).Return(nil)
+		sut.logger.On("Warn", "[MarketRepository::Create] - rejected in read-only mode", []zapcore.Field(nil))
+
+		err := sut.repo.WithTx(sut.ctx, func(repo interfaces.IMarketRepository) error {
+			_, err := repo.Create(sut.ctx, valueObjects.MarketValueObjects{Registro: "4041-0"})
+			return err
+		})
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject UpdateByID", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::UpdateByID] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, err := sut.repo.UpdateByID(sut.ctx, valueObjects.MarketValueObjects{ID: 1, Bairro: "bairro"})
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject Delete", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::Delete] - rejected in read-only mode", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(sut.ctx, "registro")
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject DedupeByProximity", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::DedupeByProximity] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, err := sut.repo.DedupeByProximity(sut.ctx, 10)
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "DedupeByProximity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject Restore", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::Restore] - rejected in read-only mode", []zapcore.Field(nil))
+
+		_, err := sut.repo.Restore(sut.ctx, "4041-0")
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should reject Touch", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.logger.On("Warn", "[MarketRepository::Touch] - rejected in read-only mode", []zapcore.Field(nil))
+
+		err := sut.repo.Touch(sut.ctx, 1)
+
+		assert.Equal(t, errors.ErrReadOnly, err)
+		sut.inner.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything)
+	})
+}
+
+func Test_ReadOnlyMarketRepo_Reads(t *testing.T) {
+	t.Run("should delegate Find", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		filter := valueObjects.MarketValueObjects{Distrito: "distrito"}
+		sut.inner.On("Find", sut.ctx, filter).Return([]valueObjects.MarketValueObjects{{ID: 1}}, nil)
+
+		result, err := sut.repo.Find(sut.ctx, filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		sut.inner.AssertExpectations(t)
+	})
+
+	t.Run("should delegate CountByDistrito", func(t *testing.T) {
+		sut := makeReadOnlyMarketRepositorySut()
+		sut.inner.On("CountByDistrito", sut.ctx, "distrito").Return(int64(2), nil)
+
+		count, err := sut.repo.CountByDistrito(sut.ctx, "distrito")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		sut.inner.AssertExpectations(t)
+	})
+}
+
+type readOnlyMarketRepositorySutRtn struct {
+	ctx    context.Context
+	logger *logger.LoggerSpy
+	inner  *MarketRepositorySpy
+	repo   interfaces.IMarketRepository
+}
+
+func makeReadOnlyMarketRepositorySut() readOnlyMarketRepositorySutRtn {
+	logger := logger.NewLoggerSpy()
+	inner := NewMarketRepositorySpy()
+	repo := NewReadOnlyMarketRepository(logger, inner)
+
+	return readOnlyMarketRepositorySutRtn{context.Background(), logger, inner, repo}
+}
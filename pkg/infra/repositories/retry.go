@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	// connectionException is the class of 08000 connection_exception, 08006 connection_failure and friends.
+	connectionException = "08"
+)
+
+const (
+	defaultRetryAttempts = 2
+	defaultRetryBackoff  = 50 * time.Millisecond
+	maxRetryBackoff      = time.Second
+)
+
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+var sleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var jitter = func(d time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// retry runs fn again while it fails with an error retryable tells worth retrying, up to DB_RETRY_ATTEMPTS more
+// times, waiting an exponential backoff with jitter in between. Inside a transaction fn runs once, since
+// Postgres aborts the whole transaction on the first error.
+func (pst marketRepository) retry(ctx context.Context, method string, retryable func(error) bool, fn func() error) error {
+	if pst.tx != nil {
+		return fn()
+	}
+
+	backoff := pst.retries.backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > pst.retries.attempts || !retryable(err) {
+			return err
+		}
+
+		wait := backoff/2 + jitter(backoff/2)
+		pst.logger.Warn(fmt.Sprintf("[MarketRepository::%s] - transient error on attempt %d, retrying in %s: %s", method, attempt, wait, err.Error()))
+		if sleep(ctx, wait) != nil {
+			return err
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// queryRowWithRetry and queryWithRetry run the writes, so they only retry the errors isRetryableWrite accepts.
+func (pst marketRepository) queryRowWithRetry(ctx context.Context, method string, stmt IStatement, args ...interface{}) (*sql.Row, error) {
+	var row *sql.Row
+	err := pst.retry(ctx, method, isRetryableWrite, func() error {
+		row = stmt.QueryRowContext(ctx, args...)
+		return row.Err()
+	})
+
+	return row, err
+}
+
+func (pst marketRepository) queryWithRetry(ctx context.Context, method string, stmt IStatement, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := pst.retry(ctx, method, isRetryableWrite, func() (err error) {
+		rows, err = stmt.QueryContext(ctx, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+// isTransient tells the errors worth retrying a read on: serialization failures, deadlocks and lost connections.
+func isTransient(err error) bool {
+	if pgErr, ok := toPgError(err); ok {
+		return pgErr.code == serializationFailure || pgErr.code == deadlockDetected || strings.HasPrefix(pgErr.code, connectionException)
+	}
+
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE)
+}
+
+// isRetryableWrite tells the errors a write is resent on: the ones Postgres rolls the statement back on and
+// driver.ErrBadConn, returned before the statement was sent. A connection lost mid-statement may come after
+// the commit, and resending it would answer a successful create as a conflict or a delete as not found.
+func isRetryableWrite(err error) bool {
+	if pgErr, ok := toPgError(err); ok {
+		return pgErr.code == serializationFailure || pgErr.code == deadlockDetected
+	}
+
+	return errors.Is(err, driver.ErrBadConn)
+}
+
+// retryPolicyFromEnv reads DB_RETRY_ATTEMPTS, how many times a transient failure is retried (zero disables
+// it), and DB_RETRY_BACKOFF, the wait before the first retry, doubled on each attempt up to one second.
+func retryPolicyFromEnv(logger interfaces.ILogger) retryPolicy {
+	policy := retryPolicy{attempts: defaultRetryAttempts, backoff: defaultRetryBackoff}
+
+	if raw := os.Getenv("DB_RETRY_ATTEMPTS"); raw != "" {
+		if attempts, err := strconv.Atoi(raw); err != nil || attempts < 0 {
+			logger.Warn(fmt.Sprintf("[MarketRepository] - invalid DB_RETRY_ATTEMPTS: %s", raw))
+		} else {
+			policy.attempts = attempts
+		}
+	}
+
+	if raw := os.Getenv("DB_RETRY_BACKOFF"); raw != "" {
+		if backoff, err := time.ParseDuration(raw); err != nil || backoff <= 0 {
+			logger.Warn(fmt.Sprintf("[MarketRepository] - invalid DB_RETRY_BACKOFF: %s", raw))
+		} else {
+			policy.backoff = backoff
+		}
+	}
+
+	return policy
+}
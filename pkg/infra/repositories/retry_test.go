@@ -0,0 +1,308 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_MarketRepo_Retry(t *testing.T) {
+	t.Run("should create after two deadlocks", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		waits := stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01"})
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01"})
+		prepare.ExpectQuery().WillReturnRows(sut.marketRows(1))
+		sut.logger.On("Warn", mock.Anything, []zapcore.Field(nil)).Twice()
+		sut.logger.On("Info", "[MarketRepository::Create] - success", mock.Anything)
+
+		result, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.marketMocked, result)
+		assert.Equal(t, []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}, *waits)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should update after two deadlocks", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01", Message: "deadlock detected"})
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01", Message: "deadlock detected"})
+		prepare.ExpectQuery().WillReturnRows(sut.marketRows(1))
+		sut.logger.On("Warn", "[MarketRepository::Update] - transient error on attempt 1, retrying in 50ms: pq: deadlock detected", []zapcore.Field(nil))
+		sut.logger.On("Warn", "[MarketRepository::Update] - transient error on attempt 2, retrying in 100ms: pq: deadlock detected", []zapcore.Field(nil))
+		sut.logger.On("Info", "[MarketRepository::Update] - success", mock.Anything)
+
+		_, err := sut.repo.Update(context.Background(), "registro", valueObjects.MarketValueObjects{NomeFeira: "nome"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should update by id after two serialization failures", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET")
+		prepare.ExpectQuery().WillReturnError(&pgconn.PgError{Code: "40001"})
+		prepare.ExpectQuery().WillReturnError(&pgconn.PgError{Code: "40001"})
+		prepare.ExpectQuery().WillReturnRows(sut.marketRows(1))
+		sut.logger.On("Warn", mock.Anything, []zapcore.Field(nil)).Twice()
+		sut.logger.On("Info", "[MarketRepository::UpdateByID] - success", mock.Anything)
+
+		_, err := sut.repo.UpdateByID(context.Background(), valueObjects.MarketValueObjects{ID: 1, NomeFeira: "nome"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should delete after a deadlock and a serialization failure", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01"})
+		prepare.ExpectQuery().WillReturnError(&pgconn.PgError{Code: "40001"})
+		prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		sut.logger.On("Warn", mock.Anything, []zapcore.Field(nil)).Twice()
+		sut.logger.On("Info", "[MarketRepository::Delete] - success", mock.Anything)
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not retry a non transient error", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		waits := stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "23505", Constraint: "feiras_registro_key"})
+		sut.logger.On("Warn", "[MarketRepository::Create] - constraint violation: market already exists", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.Equal(t, errors.ErrMarketAlreadyExists, err)
+		assert.Empty(t, *waits)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not resend a create when the connection resets after it was sent", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		waits := stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("INSERT INTO feiras")
+		prepare.ExpectQuery().WillReturnError(fmt.Errorf("read tcp: %w", syscall.ECONNRESET))
+		sut.logger.On("Error", "[MarketRepository::Create] query execution error", []zapcore.Field(nil))
+
+		_, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Empty(t, *waits)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not resend a delete when the connection is lost after it was sent", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		waits := stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WillReturnError(io.ErrUnexpectedEOF)
+		sut.logger.On("Error", "[MarketRepository::Delete] query execution error", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Empty(t, *waits)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should give up after DB_RETRY_ATTEMPTS retries", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		waits := stubRetryWaits(t)
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		for i := 0; i < 3; i++ {
+			prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01"})
+		}
+		sut.logger.On("Warn", mock.Anything, []zapcore.Field(nil)).Twice()
+		sut.logger.On("Error", "[MarketRepository::Delete] query execution error", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.IsType(t, errors.InternalError{}, err)
+		assert.Len(t, *waits, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should not retry inside a transaction", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		waits := stubRetryWaits(t)
+
+		sut.sqlMock.ExpectBegin()
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01"})
+		sut.sqlMock.ExpectRollback()
+		sut.logger.On("Error", "[MarketRepository::Delete] query execution error", []zapcore.Field(nil))
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+			return repo.Delete(context.Background(), "registro")
+		})
+
+		assert.Error(t, err)
+		assert.Empty(t, *waits)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should stop retrying when the context is done", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sleep = func(ctx context.Context, d time.Duration) error { return context.Canceled }
+		jitter = func(d time.Duration) time.Duration { return d }
+		t.Cleanup(restoreRetryWaits)
+
+		prepare := sut.sqlMock.ExpectPrepare("UPDATE feiras SET deletado_em")
+		prepare.ExpectQuery().WillReturnError(&pq.Error{Code: "40P01"})
+		sut.logger.On("Warn", mock.Anything, []zapcore.Field(nil)).Once()
+		sut.logger.On("Error", "[MarketRepository::Delete] query execution error", []zapcore.Field(nil))
+
+		err := sut.repo.Delete(context.Background(), "registro")
+
+		assert.Error(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should cap the backoff", func(t *testing.T) {
+		waits := stubRetryWaits(t)
+		repo := marketRepository{logger: logger.NewLoggerSpy(), retries: retryPolicy{attempts: 6, backoff: 400 * time.Millisecond}}
+		repo.logger.(*logger.LoggerSpy).On("Warn", mock.Anything, []zapcore.Field(nil))
+
+		repo.retry(context.Background(), "Create", isRetryableWrite, func() error { return &pq.Error{Code: "40001"} })
+
+		assert.Equal(t, []time.Duration{400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second, time.Second, time.Second}, *waits)
+	})
+}
+
+func Test_IsTransient(t *testing.T) {
+	cases := []struct {
+		err       error
+		transient bool
+	}{
+		{&pq.Error{Code: "40001"}, true},
+		{&pq.Error{Code: "40P01"}, true},
+		{&pq.Error{Code: "08006"}, true},
+		{&pgconn.PgError{Code: "08003"}, true},
+		{fmt.Errorf("update: %w", &pq.Error{Code: "40P01"}), true},
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{&pq.Error{Code: "23505"}, false},
+		{&pq.Error{Code: "57014"}, false},
+		{context.DeadlineExceeded, false},
+		{fmt.Errorf("syntax error"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.err.Error(), func(t *testing.T) {
+			assert.Equal(t, c.transient, isTransient(c.err))
+		})
+	}
+}
+
+func Test_IsRetryableWrite(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{&pq.Error{Code: "40001"}, true},
+		{&pgconn.PgError{Code: "40P01"}, true},
+		{fmt.Errorf("update: %w", &pq.Error{Code: "40001"}), true},
+		{driver.ErrBadConn, true},
+		{&pq.Error{Code: "08006"}, false},
+		{io.ErrUnexpectedEOF, false},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), false},
+		{fmt.Errorf("write: %w", syscall.EPIPE), false},
+		{&pq.Error{Code: "23505"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.err.Error(), func(t *testing.T) {
+			assert.Equal(t, c.retryable, isRetryableWrite(c.err))
+		})
+	}
+}
+
+func Test_RetryPolicyFromEnv(t *testing.T) {
+	t.Run("should use the defaults", func(t *testing.T) {
+		assert.Equal(t, retryPolicy{attempts: 2, backoff: 50 * time.Millisecond}, retryPolicyFromEnv(logger.NewLoggerSpy()))
+	})
+
+	t.Run("should read the policy from env", func(t *testing.T) {
+		os.Setenv("DB_RETRY_ATTEMPTS", "0")
+		os.Setenv("DB_RETRY_BACKOFF", "200ms")
+		defer os.Unsetenv("DB_RETRY_ATTEMPTS")
+		defer os.Unsetenv("DB_RETRY_BACKOFF")
+
+		assert.Equal(t, retryPolicy{attempts: 0, backoff: 200 * time.Millisecond}, retryPolicyFromEnv(logger.NewLoggerSpy()))
+	})
+
+	t.Run("should fall back to the defaults when the env is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_RETRY_ATTEMPTS", "-1")
+		os.Setenv("DB_RETRY_BACKOFF", "0s")
+		defer os.Unsetenv("DB_RETRY_ATTEMPTS")
+		defer os.Unsetenv("DB_RETRY_BACKOFF")
+		log.On("Warn", "[MarketRepository] - invalid DB_RETRY_ATTEMPTS: -1", []zapcore.Field(nil))
+		log.On("Warn", "[MarketRepository] - invalid DB_RETRY_BACKOFF: 0s", []zapcore.Field(nil))
+
+		assert.Equal(t, retryPolicy{attempts: 2, backoff: 50 * time.Millisecond}, retryPolicyFromEnv(log))
+		log.AssertExpectations(t)
+	})
+}
+
+// stubRetryWaits records the waits instead of sleeping, without jitter so a wait is the whole backoff.
+func stubRetryWaits(t *testing.T) *[]time.Duration {
+	waits := []time.Duration{}
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	jitter = func(d time.Duration) time.Duration { return d }
+	t.Cleanup(restoreRetryWaits)
+
+	return &waits
+}
+
+var defaultSleep, defaultJitter = sleep, jitter
+
+func restoreRetryWaits() {
+	sleep, jitter = defaultSleep, defaultJitter
+}
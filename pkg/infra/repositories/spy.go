@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"time"
 
+	"github.com/ralvescosta/base/pkg/app/interfaces"
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
 
 	"github.com/stretchr/testify/mock"
@@ -24,18 +26,220 @@ func (pst MarketRepositorySpy) Find(ctx context.Context, market valueObjects.Mar
 	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
 }
 
+func (pst MarketRepositorySpy) Count(ctx context.Context, market valueObjects.MarketValueObjects) (int64, error) {
+	args := pst.Called(ctx, market)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (pst MarketRepositorySpy) Update(ctx context.Context, registerCode string, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
 	args := pst.Called(ctx, registerCode, market)
 
 	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
 }
 
+func (pst MarketRepositorySpy) UpdateByID(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, market)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
+}
+
 func (pst MarketRepositorySpy) Delete(ctx context.Context, registerCode string) error {
 	args := pst.Called(ctx, registerCode)
 
 	return args.Error(0)
 }
 
+func (pst MarketRepositorySpy) Restore(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, registro)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) Touch(ctx context.Context, id int) error {
+	args := pst.Called(ctx, id)
+
+	return args.Error(0)
+}
+
+func (pst MarketRepositorySpy) FindAfterID(ctx context.Context, lastID, limit int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, lastID, limit)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindAfterUUID(ctx context.Context, lastUUID string, limit int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, lastUUID, limit)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindMissingRegistro(ctx context.Context, limit int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, limit)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByDistritoPaged(ctx context.Context, distrito string, page, pageSize int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, distrito, page, pageSize)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindCreatedBetween(ctx context.Context, from, to time.Time, limit, offset int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, from, to, limit, offset)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) CountCreatedByPeriod(ctx context.Context, granularity string) ([]valueObjects.MarketTimelineValueObjects, error) {
+	args := pst.Called(ctx, granularity)
+
+	return args.Get(0).([]valueObjects.MarketTimelineValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByCoordinates(ctx context.Context, long, lat float64) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, long, lat)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindWithNeighbors(ctx context.Context, id int, radiusMeters float64, limit int) (valueObjects.MarketNeighborsValueObjects, error) {
+	args := pst.Called(ctx, id, radiusMeters, limit)
+
+	return args.Get(0).(valueObjects.MarketNeighborsValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindNearby(ctx context.Context, lat, long float64, radiusKm float64, limit int) ([]valueObjects.MarketDistance, error) {
+	args := pst.Called(ctx, lat, long, radiusKm, limit)
+
+	return args.Get(0).([]valueObjects.MarketDistance), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindSiblingsBySubpref(ctx context.Context, id int, limit int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, id, limit)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindNearestToPoints(ctx context.Context, points []valueObjects.Coordinate, limit int) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, points, limit)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByID(ctx context.Context, id int) (valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, id)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByUUID(ctx context.Context, uuid string) (valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, uuid)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByRegistro(ctx context.Context, registro string) (valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, registro)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindPage(ctx context.Context, filter valueObjects.MarketValueObjects, pagination valueObjects.Pagination) (valueObjects.MarketPage, error) {
+	args := pst.Called(ctx, filter, pagination)
+
+	return args.Get(0).(valueObjects.MarketPage), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) Upsert(ctx context.Context, market valueObjects.MarketValueObjects) (valueObjects.MarketValueObjects, bool, error) {
+	args := pst.Called(ctx, market)
+
+	return args.Get(0).(valueObjects.MarketValueObjects), args.Bool(1), args.Error(2)
+}
+
+func (pst MarketRepositorySpy) CreateMany(ctx context.Context, markets []valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, markets)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindForPublic(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, filter)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindSummaries(ctx context.Context, filter valueObjects.MarketValueObjects) ([]valueObjects.MarketSummary, error) {
+	args := pst.Called(ctx, filter)
+
+	return args.Get(0).([]valueObjects.MarketSummary), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindCoordinateCollisions(ctx context.Context) ([]valueObjects.CoordinateGroup, error) {
+	args := pst.Called(ctx)
+
+	return args.Get(0).([]valueObjects.CoordinateGroup), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) CountByDistrito(ctx context.Context, distrito string) (int64, error) {
+	args := pst.Called(ctx, distrito)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByBairro(ctx context.Context, bairro string, fuzzy bool) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, bairro, fuzzy)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByRegiao8(ctx context.Context, regiao8 string) ([]valueObjects.MarketValueObjects, error) {
+	args := pst.Called(ctx, regiao8)
+
+	return args.Get(0).([]valueObjects.MarketValueObjects), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) FindByRegiao8Paged(ctx context.Context, regiao8 string, page, pageSize int) (valueObjects.MarketPage, error) {
+	args := pst.Called(ctx, regiao8, page, pageSize)
+
+	return args.Get(0).(valueObjects.MarketPage), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) CountByRegiao8(ctx context.Context) (map[string]int64, error) {
+	args := pst.Called(ctx)
+
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) CountByRegiao5InDistrito(ctx context.Context, distrito string) (map[string]int64, error) {
+	args := pst.Called(ctx, distrito)
+
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (pst MarketRepositorySpy) CountDistinctRegistros(ctx context.Context) (int64, error) {
+	args := pst.Called(ctx)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func NewMarketRepositorySpy() *MarketRepositorySpy {
 	return new(MarketRepositorySpy)
 }
+
+func (pst MarketRepositorySpy) DedupeByProximity(ctx context.Context, thresholdMeters float64) (int64, error) {
+	args := pst.Called(ctx, thresholdMeters)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// WithTx runs fn against the spy itself unless an error is configured for the transaction.
+func (pst MarketRepositorySpy) WithTx(ctx context.Context, fn func(repo interfaces.IMarketRepository) error) error {
+	args := pst.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+
+	return fn(&pst)
+}
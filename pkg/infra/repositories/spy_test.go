@@ -2,9 +2,14 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/ralvescosta/base/pkg/app/interfaces"
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_Create(t *testing.T) {
@@ -61,3 +66,460 @@ func Test_Update(t *testing.T) {
 		sut.AssertExpectations(t)
 	})
 }
+
+func Test_UpdateByID(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		market := valueObjects.MarketValueObjects{ID: 1}
+		sut.On("UpdateByID", ctx, market).Return(market, nil)
+
+		sut.UpdateByID(ctx, market)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByDistritoPaged(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByDistritoPaged", ctx, "distrito", 1, 20).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByDistritoPaged(ctx, "distrito", 1, 20)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindCreatedBetween(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		from := time.Now()
+		to := from.Add(time.Hour)
+		sut.On("FindCreatedBetween", ctx, from, to, 20, 0).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindCreatedBetween(ctx, from, to, 20, 0)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CountCreatedByPeriod(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("CountCreatedByPeriod", ctx, "day").Return([]valueObjects.MarketTimelineValueObjects{}, nil)
+
+		sut.CountCreatedByPeriod(ctx, "day")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CountByDistrito(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("CountByDistrito", ctx, "distrito").Return(int64(1), nil)
+
+		sut.CountByDistrito(ctx, "distrito")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByRegiao8(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByRegiao8", ctx, "Leste 1").Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByRegiao8(ctx, "Leste 1")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByRegiao8Paged(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByRegiao8Paged", ctx, "Leste 1", 2, 10).Return(valueObjects.MarketPage{}, nil)
+
+		sut.FindByRegiao8Paged(ctx, "Leste 1", 2, 10)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CountByRegiao8(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("CountByRegiao8", ctx).Return(map[string]int64{}, nil)
+
+		sut.CountByRegiao8(ctx)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CountByRegiao5InDistrito(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("CountByRegiao5InDistrito", ctx, "VILA FORMOSA").Return(map[string]int64{}, nil)
+
+		sut.CountByRegiao5InDistrito(ctx, "VILA FORMOSA")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CountDistinctRegistros(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("CountDistinctRegistros", ctx).Return(int64(1), nil)
+
+		sut.CountDistinctRegistros(ctx)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_Restore(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("Restore", ctx, "4041-0").Return(valueObjects.MarketValueObjects{}, nil)
+
+		sut.Restore(ctx, "4041-0")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_Touch(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("Touch", ctx, 1).Return(nil)
+
+		sut.Touch(ctx, 1)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByCoordinates(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByCoordinates", ctx, -46.55, -23.55).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByCoordinates(ctx, -46.55, -23.55)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindSummaries(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		filter := valueObjects.MarketValueObjects{}
+		sut.On("FindSummaries", ctx, filter).Return([]valueObjects.MarketSummary{}, nil)
+
+		sut.FindSummaries(ctx, filter)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindCoordinateCollisions(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindCoordinateCollisions", ctx).Return([]valueObjects.CoordinateGroup{}, nil)
+
+		sut.FindCoordinateCollisions(ctx)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_Count(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		market := valueObjects.MarketValueObjects{}
+		sut.On("Count", ctx, market).Return(int64(1), nil)
+
+		sut.Count(ctx, market)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindAfterID(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindAfterID", ctx, 0, 10).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindAfterID(ctx, 0, 10)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindAfterUUID(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindAfterUUID", ctx, "", 10).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindAfterUUID(ctx, "", 10)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindMissingRegistro(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindMissingRegistro", ctx, 10).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindMissingRegistro(ctx, 10)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindWithNeighbors(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindWithNeighbors", ctx, 1, 500.0, 5).Return(valueObjects.MarketNeighborsValueObjects{}, nil)
+
+		sut.FindWithNeighbors(ctx, 1, 500, 5)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindNearby(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindNearby", ctx, -23550000.0, -46634000.0, 2.5, 5).Return([]valueObjects.MarketDistance{}, nil)
+
+		sut.FindNearby(ctx, -23550000, -46634000, 2.5, 5)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindSiblingsBySubpref(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindSiblingsBySubpref", ctx, 1, 5).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindSiblingsBySubpref(ctx, 1, 5)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_Upsert(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("Upsert", ctx, valueObjects.MarketValueObjects{}).Return(valueObjects.MarketValueObjects{}, true, nil)
+
+		sut.Upsert(ctx, valueObjects.MarketValueObjects{})
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_CreateMany(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		markets := []valueObjects.MarketValueObjects{{Registro: "4041-0"}}
+		sut.On("CreateMany", ctx, markets).Return(markets, nil)
+
+		sut.CreateMany(ctx, markets)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindForPublic(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindForPublic", ctx, valueObjects.MarketValueObjects{}).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindForPublic(ctx, valueObjects.MarketValueObjects{})
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByID(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByID", ctx, 1).Return(valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByID(ctx, 1)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByUUID(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByUUID", ctx, "4b0e8ac4-2a0a-4c9f-9a4e-58d0c1e0b6f1").Return(valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByUUID(ctx, "4b0e8ac4-2a0a-4c9f-9a4e-58d0c1e0b6f1")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByRegistro(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByRegistro", ctx, "4041-0").Return(valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByRegistro(ctx, "4041-0")
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindPage(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		pagination := valueObjects.Pagination{Limit: 10, Offset: 20}
+		sut.On("FindPage", ctx, valueObjects.MarketValueObjects{}, pagination).Return(valueObjects.MarketPage{}, nil)
+
+		sut.FindPage(ctx, valueObjects.MarketValueObjects{}, pagination)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_DedupeByProximity(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("DedupeByProximity", ctx, 10.0).Return(int64(2), nil)
+
+		sut.DedupeByProximity(ctx, 10)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_WithTx(t *testing.T) {
+	t.Run("should run fn with the spy", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("WithTx", ctx).Return(nil)
+
+		called := false
+		err := sut.WithTx(ctx, func(repo interfaces.IMarketRepository) error {
+			called = true
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, called)
+		sut.AssertExpectations(t)
+	})
+
+	t.Run("should return the configured error without running fn", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("WithTx", ctx).Return(fmt.Errorf("error in begin transaction"))
+
+		err := sut.WithTx(ctx, func(repo interfaces.IMarketRepository) error {
+			t.Fatal("fn should not run")
+			return nil
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func Test_FindNearestToPoints(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		points := []valueObjects.Coordinate{{Long: -46550164, Lat: -23558733}}
+		sut.On("FindNearestToPoints", ctx, points, 5).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindNearestToPoints(ctx, points, 5)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_FindByBairro(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketRepositorySpy()
+
+		ctx := context.Background()
+		sut.On("FindByBairro", ctx, "bairro", true).Return([]valueObjects.MarketValueObjects{}, nil)
+
+		sut.FindByBairro(ctx, "bairro", true)
+
+		sut.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+type IStatement interface {
+	QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row
+}
+
+// conn is what *sql.DB and *sql.Tx have in common, so the same queries run on the pool or inside WithTx.
+type conn interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// directStatement runs the query straight on the pool, saving the extra round-trip an explicit
+// Prepare costs for queries executed only once.
+type directStatement struct {
+	db    conn
+	query string
+}
+
+func (pst directStatement) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
+	return pst.db.QueryContext(ctx, pst.query, args...)
+}
+
+func (pst directStatement) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
+	return pst.db.QueryRowContext(ctx, pst.query, args...)
+}
+
+// prepare is used by one-off queries. Statements executed repeatedly (e.g. Create during imports)
+// keep calling PrepareContext directly.
+func (pst marketRepository) prepare(ctx context.Context, query string) (IStatement, error) {
+	if !pst.prepared {
+		return directStatement{pst.conn(), query}, nil
+	}
+
+	stmt, err := pst.conn().PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (pst marketRepository) conn() conn {
+	if pst.tx != nil {
+		return pst.tx
+	}
+
+	return pst.db
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarketRepo_StatementMode(t *testing.T) {
+	t.Run("should prepare the statement before querying in prepared mode", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+
+		prepare := sut.sqlMock.ExpectPrepare("SELECT (.+) FROM feiras")
+		prepare.ExpectQuery().WithArgs("distrito").WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should query without preparing in direct mode", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sut.repo = sut.directRepo()
+
+		sut.sqlMock.ExpectQuery("SELECT (.+) FROM feiras").WithArgs("distrito").WillReturnRows(sut.marketRows(2))
+
+		result, err := sut.repo.Find(context.Background(), valueObjects.MarketValueObjects{Distrito: "distrito"})
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should count without preparing in direct mode", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sut.repo = sut.directRepo()
+
+		sut.sqlMock.ExpectQuery("SELECT COUNT").WithArgs("distrito").WillReturnRows(sut.sqlMock.NewRows([]string{"count"}).AddRow(3))
+
+		count, err := sut.repo.CountByDistrito(context.Background(), "distrito")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should keep preparing Create in direct mode", func(t *testing.T) {
+		sut := makeMarketRepositorySut()
+		sut.repo = sut.directRepo()
+
+		sut.sqlMockForCreateSuccessfully()
+
+		result, err := sut.repo.Create(context.Background(), sut.marketMocked)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sut.marketMocked, result)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_NewMarketRepository(t *testing.T) {
+	t.Run("should use prepared statements by default", func(t *testing.T) {
+		os.Unsetenv("DB_PREPARED_STATEMENTS")
+
+		repo := NewMarketRepository(nil, nil).(marketRepository)
+
+		assert.True(t, repo.prepared)
+	})
+
+	t.Run("should use direct execution when DB_PREPARED_STATEMENTS is false", func(t *testing.T) {
+		os.Setenv("DB_PREPARED_STATEMENTS", "false")
+		defer os.Unsetenv("DB_PREPARED_STATEMENTS")
+
+		repo := NewMarketRepository(nil, nil).(marketRepository)
+
+		assert.False(t, repo.prepared)
+	})
+}
+
+func (pst marketRepositorySutRtn) directRepo() marketRepository {
+	repo := pst.repo.(marketRepository)
+	repo.prepared = false
+
+	return repo
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+const (
+	defaultMaxConcurrentTx  = 10
+	defaultTxAcquireTimeout = 5 * time.Second
+)
+
+// txLimiter caps the transactions the repository holds open at once. The slots channel is shared by the
+// copies of the repository, and a nil one leaves the transactions uncapped.
+type txLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// acquire waits up to the timeout for a free slot and returns the function giving it back.
+func (pst txLimiter) acquire(ctx context.Context) (func(), error) {
+	if pst.slots == nil {
+		return func() {}, nil
+	}
+
+	timer := time.NewTimer(pst.timeout)
+	defer timer.Stop()
+
+	select {
+	case pst.slots <- struct{}{}:
+		return func() { <-pst.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, fmt.Errorf("no transaction slot freed in %s", pst.timeout)
+	}
+}
+
+// txLimiterFromEnv reads DB_MAX_CONCURRENT_TX, how many transactions may be open at once (zero removes the
+// cap), and DB_TX_ACQUIRE_TIMEOUT, how long a caller waits for one to finish before giving up.
+func txLimiterFromEnv(logger interfaces.ILogger) txLimiter {
+	max, timeout := defaultMaxConcurrentTx, defaultTxAcquireTimeout
+
+	if raw := os.Getenv("DB_MAX_CONCURRENT_TX"); raw != "" {
+		if value, err := strconv.Atoi(raw); err != nil || value < 0 {
+			logger.Warn(fmt.Sprintf("[MarketRepository] - invalid DB_MAX_CONCURRENT_TX: %s", raw))
+		} else {
+			max = value
+		}
+	}
+
+	if raw := os.Getenv("DB_TX_ACQUIRE_TIMEOUT"); raw != "" {
+		if value, err := time.ParseDuration(raw); err != nil || value <= 0 {
+			logger.Warn(fmt.Sprintf("[MarketRepository] - invalid DB_TX_ACQUIRE_TIMEOUT: %s", raw))
+		} else {
+			timeout = value
+		}
+	}
+
+	if max == 0 {
+		return txLimiter{timeout: timeout}
+	}
+
+	return txLimiter{slots: make(chan struct{}, max), timeout: timeout}
+}
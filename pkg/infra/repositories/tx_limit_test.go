@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_MarketRepo_TxLimit(t *testing.T) {
+	t.Run("should not open more than DB_MAX_CONCURRENT_TX transactions at once", func(t *testing.T) {
+		os.Setenv("DB_MAX_CONCURRENT_TX", "2")
+		defer os.Unsetenv("DB_MAX_CONCURRENT_TX")
+		sut := makeMarketRepositorySut()
+		sut.sqlMock.MatchExpectationsInOrder(false)
+		for i := 0; i < 6; i++ {
+			sut.sqlMock.ExpectBegin()
+			sut.sqlMock.ExpectCommit()
+		}
+
+		var open, peak int32
+		wg := sync.WaitGroup{}
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+					current := atomic.AddInt32(&open, 1)
+					for {
+						previous := atomic.LoadInt32(&peak)
+						if current <= previous || atomic.CompareAndSwapInt32(&peak, previous, current) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					atomic.AddInt32(&open, -1)
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(2), peak)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should refuse the transaction when no slot frees in time", func(t *testing.T) {
+		os.Setenv("DB_MAX_CONCURRENT_TX", "1")
+		os.Setenv("DB_TX_ACQUIRE_TIMEOUT", "10ms")
+		defer os.Unsetenv("DB_MAX_CONCURRENT_TX")
+		defer os.Unsetenv("DB_TX_ACQUIRE_TIMEOUT")
+		sut := makeMarketRepositorySut()
+		sut.repo.(marketRepository).txLimit.slots <- struct{}{}
+		sut.logger.On("Warn", "[MarketRepository::WithTx] - too many concurrent transactions: no transaction slot freed in 10ms", []zapcore.Field(nil))
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error { return nil })
+
+		assert.Equal(t, errors.NewBackpressureError("too many concurrent transactions", 10*time.Millisecond), err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should give the slot back when the transaction fails", func(t *testing.T) {
+		os.Setenv("DB_MAX_CONCURRENT_TX", "1")
+		defer os.Unsetenv("DB_MAX_CONCURRENT_TX")
+		sut := makeMarketRepositorySut()
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectRollback()
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectCommit()
+
+		first := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error { return errors.ErrMarketNotFound })
+		second := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error { return nil })
+
+		assert.Equal(t, errors.ErrMarketNotFound, first)
+		assert.NoError(t, second)
+		assert.Empty(t, sut.repo.(marketRepository).txLimit.slots)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("should join the open transaction without taking another slot", func(t *testing.T) {
+		os.Setenv("DB_MAX_CONCURRENT_TX", "1")
+		defer os.Unsetenv("DB_MAX_CONCURRENT_TX")
+		sut := makeMarketRepositorySut()
+		sut.sqlMock.ExpectBegin()
+		sut.sqlMock.ExpectCommit()
+
+		err := sut.repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error {
+			return repo.WithTx(context.Background(), func(repo interfaces.IMarketRepository) error { return nil })
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sut.sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_TxLimiterFromEnv(t *testing.T) {
+	t.Run("should use the defaults", func(t *testing.T) {
+		limiter := txLimiterFromEnv(logger.NewLoggerSpy())
+
+		assert.Equal(t, 10, cap(limiter.slots))
+		assert.Equal(t, 5*time.Second, limiter.timeout)
+	})
+
+	t.Run("should remove the cap with zero", func(t *testing.T) {
+		os.Setenv("DB_MAX_CONCURRENT_TX", "0")
+		defer os.Unsetenv("DB_MAX_CONCURRENT_TX")
+
+		limiter := txLimiterFromEnv(logger.NewLoggerSpy())
+		release, err := limiter.acquire(context.Background())
+
+		assert.Nil(t, limiter.slots)
+		assert.NoError(t, err)
+		release()
+	})
+
+	t.Run("should fall back to the defaults when the env is invalid", func(t *testing.T) {
+		log := logger.NewLoggerSpy()
+		os.Setenv("DB_MAX_CONCURRENT_TX", "-1")
+		os.Setenv("DB_TX_ACQUIRE_TIMEOUT", "soon")
+		defer os.Unsetenv("DB_MAX_CONCURRENT_TX")
+		defer os.Unsetenv("DB_TX_ACQUIRE_TIMEOUT")
+		log.On("Warn", "[MarketRepository] - invalid DB_MAX_CONCURRENT_TX: -1", []zapcore.Field(nil))
+		log.On("Warn", "[MarketRepository] - invalid DB_TX_ACQUIRE_TIMEOUT: soon", []zapcore.Field(nil))
+
+		limiter := txLimiterFromEnv(log)
+
+		assert.Equal(t, 10, cap(limiter.slots))
+		assert.Equal(t, 5*time.Second, limiter.timeout)
+		log.AssertExpectations(t)
+	})
+}
+
+func Test_TxLimiter_Acquire(t *testing.T) {
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		limiter := txLimiter{slots: make(chan struct{}, 1), timeout: time.Minute}
+		limiter.slots <- struct{}{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := limiter.acquire(ctx)
+
+		assert.Equal(t, context.Canceled, err)
+	})
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context)
+}
+
+type scheduler struct {
+	logger  interfaces.ILogger
+	mutex   *sync.Mutex
+	wg      *sync.WaitGroup
+	jobs    []job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running bool
+}
+
+func (pst *scheduler) Register(name string, interval time.Duration, run func(ctx context.Context)) error {
+	if interval <= 0 {
+		return fmt.Errorf("job %s interval must be positive", name)
+	}
+
+	pst.mutex.Lock()
+	defer pst.mutex.Unlock()
+
+	j := job{name, interval, run}
+	pst.jobs = append(pst.jobs, j)
+	if pst.running {
+		pst.spawn(j)
+	}
+
+	return nil
+}
+
+func (pst *scheduler) Start() {
+	pst.mutex.Lock()
+	defer pst.mutex.Unlock()
+
+	if pst.running {
+		return
+	}
+
+	pst.ctx, pst.cancel = context.WithCancel(context.Background())
+	pst.running = true
+	for _, j := range pst.jobs {
+		pst.spawn(j)
+	}
+}
+
+func (pst *scheduler) Stop() {
+	pst.mutex.Lock()
+	if !pst.running {
+		pst.mutex.Unlock()
+		return
+	}
+	pst.running = false
+	pst.cancel()
+	pst.mutex.Unlock()
+
+	pst.wg.Wait()
+}
+
+func (pst *scheduler) spawn(j job) {
+	pst.wg.Add(1)
+	go pst.loop(pst.ctx, j)
+}
+
+func (pst *scheduler) loop(ctx context.Context, j job) {
+	defer pst.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pst.execute(ctx, j)
+		}
+	}
+}
+
+func (pst *scheduler) execute(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			pst.logger.Error(fmt.Sprintf("[Scheduler] - job %s panicked: %v", j.name, r))
+		}
+	}()
+
+	j.run(ctx)
+}
+
+func NewScheduler(logger interfaces.ILogger) interfaces.IScheduler {
+	return &scheduler{
+		logger: logger,
+		mutex:  &sync.Mutex{},
+		wg:     &sync.WaitGroup{},
+	}
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Scheduler_Register(t *testing.T) {
+	t.Run("should reject a non positive interval", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		err := sut.scheduler.Register("job", 0, func(ctx context.Context) {})
+
+		assert.EqualError(t, err, "job job interval must be positive")
+	})
+}
+
+func Test_Scheduler_Start(t *testing.T) {
+	t.Run("should run the job on its interval", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		var runs int32
+		sut.scheduler.Register("job", 10*time.Millisecond, func(ctx context.Context) {
+			atomic.AddInt32(&runs, 1)
+		})
+
+		sut.scheduler.Start()
+		defer sut.scheduler.Stop()
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 3 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should not run the job before the first interval", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		var runs int32
+		sut.scheduler.Register("job", time.Hour, func(ctx context.Context) {
+			atomic.AddInt32(&runs, 1)
+		})
+
+		sut.scheduler.Start()
+		time.Sleep(20 * time.Millisecond)
+		sut.scheduler.Stop()
+
+		assert.Equal(t, int32(0), atomic.LoadInt32(&runs))
+	})
+
+	t.Run("should run jobs registered after start", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		sut.scheduler.Start()
+		defer sut.scheduler.Stop()
+
+		done := make(chan struct{})
+		var once int32
+		sut.scheduler.Register("job", 10*time.Millisecond, func(ctx context.Context) {
+			if atomic.CompareAndSwapInt32(&once, 0, 1) {
+				close(done)
+			}
+		})
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("job registered after start never ran")
+		}
+	})
+
+	t.Run("should recover from a job panic and keep running", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		var runs int32
+		sut.logger.On("Error", "[Scheduler] - job failing panicked: boom", mock.Anything)
+		sut.scheduler.Register("failing", 10*time.Millisecond, func(ctx context.Context) {
+			atomic.AddInt32(&runs, 1)
+			panic("boom")
+		})
+
+		sut.scheduler.Start()
+		defer sut.scheduler.Stop()
+
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 2 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+func Test_Scheduler_Stop(t *testing.T) {
+	t.Run("should stop running the jobs and wait for the running one", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		var runs int32
+		var cancelled int32
+		sut.scheduler.Register("job", 10*time.Millisecond, func(ctx context.Context) {
+			atomic.AddInt32(&runs, 1)
+			<-ctx.Done()
+			atomic.AddInt32(&cancelled, 1)
+		})
+
+		sut.scheduler.Start()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) == 1 }, time.Second, 5*time.Millisecond)
+		sut.scheduler.Stop()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&cancelled))
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	})
+
+	t.Run("should be safe to stop twice or without starting", func(t *testing.T) {
+		sut := makeSchedulerSut()
+
+		sut.scheduler.Stop()
+		sut.scheduler.Start()
+		sut.scheduler.Stop()
+		sut.scheduler.Stop()
+	})
+}
+
+type schedulerSutRtn struct {
+	logger    *logger.LoggerSpy
+	scheduler interfaces.IScheduler
+}
+
+func makeSchedulerSut() schedulerSutRtn {
+	logger := logger.NewLoggerSpy()
+
+	scheduler := NewScheduler(logger)
+
+	return schedulerSutRtn{logger, scheduler}
+}
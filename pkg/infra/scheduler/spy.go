@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SchedulerSpy struct {
+	mock.Mock
+}
+
+func (pst SchedulerSpy) Register(name string, interval time.Duration, job func(ctx context.Context)) error {
+	args := pst.Called(name, interval)
+
+	return args.Error(0)
+}
+
+func (pst SchedulerSpy) Start() {
+	pst.Called()
+}
+
+func (pst SchedulerSpy) Stop() {
+	pst.Called()
+}
+
+func NewSchedulerSpy() *SchedulerSpy {
+	return new(SchedulerSpy)
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_SchedulerSpy_Register(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewSchedulerSpy()
+
+		sut.On("Register", "job", time.Second).Return(nil)
+
+		sut.Register("job", time.Second, func(ctx context.Context) {})
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_SchedulerSpy_Start(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewSchedulerSpy()
+
+		sut.On("Start")
+
+		sut.Start()
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_SchedulerSpy_Stop(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewSchedulerSpy()
+
+		sut.On("Stop")
+
+		sut.Stop()
+
+		sut.AssertExpectations(t)
+	})
+}
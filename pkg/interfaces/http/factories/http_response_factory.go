@@ -1,9 +1,13 @@
 package factories
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ralvescosta/base/pkg/app/errors"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
 	httpserver "github.com/ralvescosta/base/pkg/infra/http_server"
 	vm "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
 )
@@ -34,68 +38,76 @@ func (HttpResponseFactory) NoContent(headers http.Header) httpserver.HttpRespons
 }
 
 func (HttpResponseFactory) BadRequest(msg string, headers http.Header) httpserver.HttpResponse {
-	return httpserver.HttpResponse{
-		StatusCode: 400,
-		Body: vm.ErrorMessage{
-			StatusCode: 400,
-			Message:    msg,
-		},
-		Headers: headers,
-	}
+	return errorResponse(400, "BAD_REQUEST", msg, headers)
 }
 
 func (HttpResponseFactory) Unauthorized(msg string, headers http.Header) httpserver.HttpResponse {
-	return httpserver.HttpResponse{
-		StatusCode: 401,
-		Body: vm.ErrorMessage{
-			StatusCode: 401,
-			Message:    msg,
-		},
-		Headers: headers,
-	}
+	return errorResponse(401, "UNAUTHORIZED", msg, headers)
 }
 
 func (HttpResponseFactory) Forbidden(msg string, headers http.Header) httpserver.HttpResponse {
-	return httpserver.HttpResponse{
-		StatusCode: 403,
-		Body: vm.ErrorMessage{
-			StatusCode: 403,
-			Message:    msg,
-		},
-		Headers: headers,
-	}
+	return errorResponse(403, "FORBIDDEN", msg, headers)
 }
 
 func (HttpResponseFactory) NotFound(msg string, headers http.Header) httpserver.HttpResponse {
-	return httpserver.HttpResponse{
-		StatusCode: 404,
-		Body: vm.ErrorMessage{
-			StatusCode: 404,
-			Message:    msg,
-		},
-		Headers: headers,
-	}
+	return errorResponse(404, "NOT_FOUND", msg, headers)
+}
+
+func (HttpResponseFactory) MethodNotAllowed(msg string, headers http.Header) httpserver.HttpResponse {
+	return errorResponse(405, "METHOD_NOT_ALLOWED", msg, headers)
 }
 
 func (HttpResponseFactory) Conflict(msg string, headers http.Header) httpserver.HttpResponse {
-	return httpserver.HttpResponse{
-		StatusCode: 409,
-		Body: vm.ErrorMessage{
-			StatusCode: 409,
-			Message:    msg,
-		},
-		Headers: headers,
-	}
+	return errorResponse(409, "CONFLICT", msg, headers)
+}
+
+func (HttpResponseFactory) UnprocessableEntity(msg string, headers http.Header) httpserver.HttpResponse {
+	return errorResponse(422, "VALIDATION_FAILED", msg, headers)
+}
+
+func (HttpResponseFactory) TooManyRequests(msg string, headers http.Header) httpserver.HttpResponse {
+	return errorResponse(429, "TOO_MANY_REQUESTS", msg, headers)
 }
 
 func (HttpResponseFactory) InternalServerError(msg string, headers http.Header) httpserver.HttpResponse {
+	return errorResponse(500, "INTERNAL_ERROR", msg, headers)
+}
+
+func (HttpResponseFactory) ServiceUnavailable(msg string, headers http.Header) httpserver.HttpResponse {
+	return errorResponse(503, "SERVICE_UNAVAILABLE", msg, headers)
+}
+
+// RetryLater answers a backpressure error with 429, or 503 when the whole service is unavailable, and a
+// Retry-After header in whole seconds, never less than one.
+func (pst HttpResponseFactory) RetryLater(err errors.BackpressureError, headers http.Header) httpserver.HttpResponse {
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set("Retry-After", strconv.Itoa(retryAfterSeconds(err.RetryAfter)))
+
+	if err.Unavailable {
+		return pst.ServiceUnavailable(err.Error(), headers)
+	}
+
+	return pst.TooManyRequests(err.Error(), headers)
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+
+	return seconds
+}
+
+// errorResponse wraps msg in the error envelope shared by every failure, where code names the kind of error
+// so clients need not parse the message.
+func errorResponse(statusCode int, code, msg string, headers http.Header) httpserver.HttpResponse {
 	return httpserver.HttpResponse{
-		StatusCode: 500,
-		Body: vm.ErrorMessage{
-			StatusCode: 500,
-			Message:    msg,
-		},
-		Headers: headers,
+		StatusCode: statusCode,
+		Body:       vm.NewErrorMessage(code, msg),
+		Headers:    headers,
 	}
 }
 
@@ -107,12 +119,20 @@ func (HttpResponseFactory) GenericResponse(statusCode int, body interface{}, hea
 	}
 }
 
+// ErrorResponseMapper answers the application errors with their status: 404 not found, 409 conflict,
+// 422 validation, 405 read only, 429/503 backpressure and 500 for anything else.
 func (pst HttpResponseFactory) ErrorResponseMapper(err error, headers http.Header) httpserver.HttpResponse {
 	switch err.(type) {
 	case errors.NotFoundError:
 		return pst.NotFound(err.Error(), headers)
 	case errors.ConflictError:
 		return pst.Conflict(err.Error(), headers)
+	case errors.ValidationError, valueObjects.MarketValidationError:
+		return pst.UnprocessableEntity(err.Error(), headers)
+	case errors.ReadOnlyError:
+		return pst.MethodNotAllowed(err.Error(), headers)
+	case errors.BackpressureError:
+		return pst.RetryLater(err.(errors.BackpressureError), headers)
 	default:
 		return pst.InternalServerError(err.Error(), headers)
 	}
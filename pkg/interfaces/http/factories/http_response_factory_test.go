@@ -1,13 +1,17 @@
 package factories
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	mErrors "github.com/ralvescosta/base/pkg/app/errors"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	vm "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
 )
 
 func Test_New(t *testing.T) {
@@ -74,6 +78,14 @@ func Test_NotFound(t *testing.T) {
 	})
 }
 
+func Test_MethodNotAllowed(t *testing.T) {
+	t.Run("should return httpStatus 405", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		assert.Equal(t, sut.MethodNotAllowed("", nil).StatusCode, http.StatusMethodNotAllowed)
+	})
+}
+
 func Test_Conflict(t *testing.T) {
 	t.Run("should return httpStatus 409", func(t *testing.T) {
 		sut := HttpResponseFactory{}
@@ -90,39 +102,122 @@ func Test_InternalServerError(t *testing.T) {
 	})
 }
 
-func Test_GenericResponse(t *testing.T) {
-	t.Run("should return httpStatus 200", func(t *testing.T) {
+func Test_ServiceUnavailable(t *testing.T) {
+	t.Run("should return httpStatus 503", func(t *testing.T) {
 		sut := HttpResponseFactory{}
 
-		assert.Equal(t, sut.GenericResponse(http.StatusOK, "", nil).StatusCode, http.StatusOK)
+		assert.Equal(t, sut.ServiceUnavailable("", nil).StatusCode, http.StatusServiceUnavailable)
 	})
 }
 
-func Test_ErrorResponseMapper(t *testing.T) {
-	t.Run("should map notFoundError to NotFound response", func(t *testing.T) {
-		err := mErrors.NewNotFoundError("some error")
+func Test_UnprocessableEntity(t *testing.T) {
+	t.Run("should return httpStatus 422", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		assert.Equal(t, sut.UnprocessableEntity("", nil).StatusCode, http.StatusUnprocessableEntity)
+	})
+}
+
+func Test_TooManyRequests(t *testing.T) {
+	t.Run("should return httpStatus 429", func(t *testing.T) {
 		sut := HttpResponseFactory{}
 
-		result := sut.ErrorResponseMapper(err, nil)
+		assert.Equal(t, sut.TooManyRequests("", nil).StatusCode, http.StatusTooManyRequests)
+	})
+}
 
-		assert.Equal(t, result.StatusCode, http.StatusNotFound)
+func Test_RetryLater(t *testing.T) {
+	t.Run("should return httpStatus 429 with Retry-After when a queue is full", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		result := sut.RetryLater(mErrors.NewBackpressureError("import queue is full", 30*time.Second), nil)
+
+		assert.Equal(t, http.StatusTooManyRequests, result.StatusCode)
+		assert.Equal(t, "30", result.Headers.Get("Retry-After"))
+		assert.Equal(t, "import queue is full", result.Body.(vm.ErrorMessage).Error.Message)
 	})
 
-	t.Run("should map conflictError to Conflict response", func(t *testing.T) {
-		err := mErrors.NewConflictError("some error")
+	t.Run("should return httpStatus 503 with Retry-After when the service is unavailable", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		result := sut.RetryLater(mErrors.NewUnavailableError("overloaded", 2*time.Minute), nil)
+
+		assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+		assert.Equal(t, "120", result.Headers.Get("Retry-After"))
+	})
+
+	t.Run("should round Retry-After up to whole seconds", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		assert.Equal(t, "2", sut.RetryLater(mErrors.NewBackpressureError("", 1500*time.Millisecond), nil).Headers.Get("Retry-After"))
+		assert.Equal(t, "1", sut.RetryLater(mErrors.NewBackpressureError("", 0), nil).Headers.Get("Retry-After"))
+	})
+
+	t.Run("should keep the given headers", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		result := sut.RetryLater(mErrors.NewBackpressureError("", time.Second), http.Header{"X-Request-Id": {"id"}})
+
+		assert.Equal(t, "id", result.Headers.Get("X-Request-Id"))
+		assert.Equal(t, "1", result.Headers.Get("Retry-After"))
+	})
+}
+
+func Test_GenericResponse(t *testing.T) {
+	t.Run("should return httpStatus 200", func(t *testing.T) {
+		sut := HttpResponseFactory{}
+
+		assert.Equal(t, sut.GenericResponse(http.StatusOK, "", nil).StatusCode, http.StatusOK)
+	})
+}
+
+func Test_ErrorResponseMapper(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		statusCode int
+		body       vm.ErrorMessage
+	}{
+		{"notFoundError", mErrors.NewNotFoundError("not found"), http.StatusNotFound, vm.NewErrorMessage("NOT_FOUND", "not found")},
+		{"conflictError", mErrors.ErrMarketAlreadyExists, http.StatusConflict, vm.NewErrorMessage("CONFLICT", "market already exists")},
+		{"validationError", mErrors.NewValidationError("lat is invalid"), http.StatusUnprocessableEntity, vm.NewErrorMessage("VALIDATION_FAILED", "lat is invalid")},
+		{
+			"marketValidationError",
+			valueObjects.MarketValidationError{Fields: []valueObjects.ValidateResult{{Field: "Lat", Message: "Lat is invalid"}, {Field: "Registro", Message: "Registro is required"}}},
+			http.StatusUnprocessableEntity,
+			vm.NewErrorMessage("VALIDATION_FAILED", "Lat is invalid; Registro is required"),
+		},
+		{"readOnlyError", mErrors.ErrReadOnly, http.StatusMethodNotAllowed, vm.NewErrorMessage("METHOD_NOT_ALLOWED", mErrors.ErrReadOnly.Error())},
+		{"backpressureError", mErrors.NewBackpressureError("import queue is full", time.Second), http.StatusTooManyRequests, vm.NewErrorMessage("TOO_MANY_REQUESTS", "import queue is full")},
+		{"unavailableError", mErrors.NewUnavailableError("overloaded", time.Second), http.StatusServiceUnavailable, vm.NewErrorMessage("SERVICE_UNAVAILABLE", "overloaded")},
+		{"unmapped error", errors.New("some error"), http.StatusInternalServerError, vm.NewErrorMessage("INTERNAL_ERROR", "some error")},
+	}
+
+	for _, c := range cases {
+		t.Run("should map "+c.name, func(t *testing.T) {
+			sut := HttpResponseFactory{}
+
+			result := sut.ErrorResponseMapper(c.err, nil)
+
+			assert.Equal(t, c.statusCode, result.StatusCode)
+			assert.Equal(t, c.body, result.Body)
+		})
+	}
+
+	t.Run("should send Retry-After with backpressureError", func(t *testing.T) {
 		sut := HttpResponseFactory{}
 
-		result := sut.ErrorResponseMapper(err, nil)
+		result := sut.ErrorResponseMapper(mErrors.NewBackpressureError("import queue is full", 5*time.Second), nil)
 
-		assert.Equal(t, result.StatusCode, http.StatusConflict)
+		assert.Equal(t, "5", result.Headers.Get("Retry-After"))
 	})
 
-	t.Run("should map unmapped error to InternalServerError response", func(t *testing.T) {
-		err := errors.New("some error")
+	t.Run("should serialize the error envelope", func(t *testing.T) {
 		sut := HttpResponseFactory{}
 
-		result := sut.ErrorResponseMapper(err, nil)
+		body, err := json.Marshal(sut.ErrorResponseMapper(mErrors.NewNotFoundError("Market with the ID: 1 was not found"), nil).Body)
 
-		assert.Equal(t, result.StatusCode, http.StatusInternalServerError)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"error":{"code":"NOT_FOUND","message":"Market with the ID: 1 was not found"}}`, string(body))
 	})
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/interfaces/http/factories"
+	viewmodels "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
+)
+
+type IAdminHandlers interface {
+	Reload(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+}
+
+type adminHandlers struct {
+	httpResFactory factories.HttpResponseFactory
+	reloader       interfaces.IConfigReloader
+	token          string
+}
+
+func (pst adminHandlers) Reload(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	if !pst.authorized(httpRequest) {
+		return pst.httpResFactory.Unauthorized("missing or invalid admin token", nil)
+	}
+
+	result, err := pst.reloader.Reload()
+	if err != nil {
+		return pst.httpResFactory.ErrorResponseMapper(err, nil)
+	}
+
+	return pst.httpResFactory.Ok(viewmodels.NewConfigReloadViewModel(result), nil)
+}
+
+// authorized expects "Authorization: Bearer <ADMIN_TOKEN>". Without ADMIN_TOKEN every request is refused.
+func (pst adminHandlers) authorized(httpRequest httpServer.HttpRequest) bool {
+	header := httpRequest.Headers.Get("Authorization")
+	if pst.token == "" || !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+
+	token := strings.TrimPrefix(header, "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(pst.token)) == 1
+}
+
+func NewAdminHandlers(httpResFactory factories.HttpResponseFactory, reloader interfaces.IConfigReloader) IAdminHandlers {
+	return adminHandlers{httpResFactory, reloader, os.Getenv("ADMIN_TOKEN")}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/environments"
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/interfaces/http/factories"
+	viewmodels "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Admin_Reload(t *testing.T) {
+	t.Run("should return the applied and ignored values", func(t *testing.T) {
+		sut := makeAdminHandlersSut(t)
+
+		sut.reloader.On("Reload").Return(interfaces.ReloadResult{Applied: map[string]string{"LOG_LEVEL": "warn"}, Ignored: []string{"DB_HOST"}}, nil)
+
+		res := sut.handler.Reload(sut.request("Bearer secret"))
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, viewmodels.NewConfigReloadViewModel(interfaces.ReloadResult{Applied: map[string]string{"LOG_LEVEL": "warn"}, Ignored: []string{"DB_HOST"}}), res.Body)
+		sut.reloader.AssertExpectations(t)
+	})
+
+	t.Run("should return unprocessableEntity when a value is invalid", func(t *testing.T) {
+		sut := makeAdminHandlersSut(t)
+
+		sut.reloader.On("Reload").Return(interfaces.ReloadResult{}, errors.NewValidationError(`invalid LOG_LEVEL: unknown log level "verbose"`))
+
+		res := sut.handler.Reload(sut.request("Bearer secret"))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("should return unauthorized without the admin token", func(t *testing.T) {
+		sut := makeAdminHandlersSut(t)
+
+		for _, header := range []string{"", "Bearer wrong", "secret", "Basic secret"} {
+			res := sut.handler.Reload(sut.request(header))
+
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		}
+		sut.reloader.AssertNotCalled(t, "Reload")
+	})
+
+	t.Run("should return unauthorized when ADMIN_TOKEN is not configured", func(t *testing.T) {
+		reloader := environments.NewConfigReloaderSpy()
+		handler := NewAdminHandlers(factories.NewHttpResponseFactory(), reloader)
+
+		res := handler.Reload(httpServer.HttpRequest{Headers: http.Header{"Authorization": []string{"Bearer "}}})
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		reloader.AssertNotCalled(t, "Reload")
+	})
+}
+
+func Test_Admin_ReloadLogLevel(t *testing.T) {
+	t.Run("should change the log level after reload", func(t *testing.T) {
+		sut := makeAdminHandlersSut(t)
+		os.Setenv("LOG_LEVEL", "debug")
+		defer os.Unsetenv("LOG_LEVEL")
+		logger.SetLevel("debug")
+		env := environments.NewEnvironmentsSpy()
+		env.On("Configure").Run(func(mock.Arguments) { os.Setenv("LOG_LEVEL", "warn") }).Return(nil)
+		log := logger.NewLoggerSpy()
+		log.On("Info", mock.Anything, mock.Anything)
+		reloader := environments.NewConfigReloader(log, env,
+			environments.Reloadable{Key: "LOG_LEVEL", Validate: logger.ValidateLevel, Apply: logger.SetLevel})
+		handler := NewAdminHandlers(factories.NewHttpResponseFactory(), reloader)
+
+		res := handler.Reload(sut.request("Bearer secret"))
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "warn", logger.Level())
+	})
+}
+
+type adminHandlersSutRtn struct {
+	reloader *environments.ConfigReloaderSpy
+	handler  IAdminHandlers
+}
+
+func (pst adminHandlersSutRtn) request(authorization string) httpServer.HttpRequest {
+	return httpServer.HttpRequest{Headers: http.Header{"Authorization": []string{authorization}}}
+}
+
+func makeAdminHandlersSut(t *testing.T) adminHandlersSutRtn {
+	os.Setenv("ADMIN_TOKEN", "secret")
+	t.Cleanup(func() { os.Unsetenv("ADMIN_TOKEN") })
+
+	reloader := environments.NewConfigReloaderSpy()
+	handler := NewAdminHandlers(factories.NewHttpResponseFactory(), reloader)
+
+	return adminHandlersSutRtn{reloader, handler}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/interfaces/http/factories"
+)
+
+type IHealthHandlers interface {
+	Readyz(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	Live(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+}
+
+type healthHandlers struct {
+	httpResFactory factories.HttpResponseFactory
+	readiness      interfaces.IReadinessChecker
+}
+
+func (pst healthHandlers) Readyz(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	if err := pst.readiness.Ready(httpRequest.Ctx); err != nil {
+		return pst.httpResFactory.ServiceUnavailable(err.Error(), nil)
+	}
+
+	return pst.httpResFactory.Ok(map[string]string{"status": http.StatusText(http.StatusOK)}, nil)
+}
+
+// Live only tells the process is serving requests, so a database outage does not get the pod restarted.
+func (pst healthHandlers) Live(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	return pst.httpResFactory.Ok(map[string]string{"status": http.StatusText(http.StatusOK)}, nil)
+}
+
+func NewHealthHandlers(httpResFactory factories.HttpResponseFactory, readiness interfaces.IReadinessChecker) IHealthHandlers {
+	return healthHandlers{httpResFactory, readiness}
+}
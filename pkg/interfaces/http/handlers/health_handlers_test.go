@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/infra/database"
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/interfaces/http/factories"
+	viewmodels "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Health_Readyz(t *testing.T) {
+	t.Run("should return ok when every migration is applied", func(t *testing.T) {
+		sut := makeHealthHandlersSut()
+
+		sut.readiness.On("Ready", sut.request.Ctx).Return(nil)
+
+		res := sut.handler.Readyz(sut.request)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		sut.readiness.AssertExpectations(t)
+	})
+
+	t.Run("should return serviceUnavailable while migrations are pending", func(t *testing.T) {
+		sut := makeHealthHandlersSut()
+
+		sut.readiness.On("Ready", sut.request.Ctx).Return(errors.NewInternalError("pending migrations: registro_unique_up.sql"))
+
+		res := sut.handler.Readyz(sut.request)
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, viewmodels.NewErrorMessage("SERVICE_UNAVAILABLE", "pending migrations: registro_unique_up.sql"), res.Body)
+	})
+}
+
+func Test_Health_ReadyzWithDatabase(t *testing.T) {
+	t.Run("should return serviceUnavailable with the detail when the ping fails", func(t *testing.T) {
+		db, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock.ExpectPing().WillReturnError(fmt.Errorf("dial tcp 127.0.0.1:5432: connect: connection refused"))
+		log := logger.NewLoggerSpy()
+		log.On("Error", "[Database::Ready] - database unreachable: dial tcp 127.0.0.1:5432: connect: connection refused", mock.Anything)
+		handler := NewHealthHandlers(factories.NewHttpResponseFactory(), database.NewReadinessChecker(log, db))
+
+		res := handler.Readyz(httpServer.HttpRequest{Ctx: context.Background()})
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, viewmodels.NewErrorMessage("SERVICE_UNAVAILABLE", "database unreachable: dial tcp 127.0.0.1:5432: connect: connection refused"), res.Body)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func Test_Health_Live(t *testing.T) {
+	t.Run("should return ok without touching the database", func(t *testing.T) {
+		sut := makeHealthHandlersSut()
+
+		res := sut.handler.Live(sut.request)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, map[string]string{"status": "OK"}, res.Body)
+		sut.readiness.AssertExpectations(t)
+	})
+}
+
+type healthHandlersSutRtn struct {
+	readiness *database.ReadinessCheckerSpy
+	handler   IHealthHandlers
+	request   httpServer.HttpRequest
+}
+
+func makeHealthHandlersSut() healthHandlersSutRtn {
+	readiness := database.NewReadinessCheckerSpy()
+	handler := NewHealthHandlers(factories.NewHttpResponseFactory(), readiness)
+
+	return healthHandlersSutRtn{readiness, handler, httpServer.HttpRequest{Ctx: context.Background()}}
+}
@@ -1,24 +1,38 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
 
+	"github.com/ralvescosta/base/pkg/app/errors"
 	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/app/services"
 	"github.com/ralvescosta/base/pkg/domain/usecases"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
 	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
 	"github.com/ralvescosta/base/pkg/interfaces/http/factories"
 	viewmodels "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
 )
 
+const defaultListPageSize = 20
+
 type IMarketHandlers interface {
 	Create(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
 	GetByQuery(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
 	Update(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
 	Delete(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	Timeline(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	Summaries(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	Export(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	Validate(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	Schema(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
+	ImportTemplate(httpRequest httpServer.HttpRequest) httpServer.HttpResponse
 }
 
 type marketHandlers struct {
@@ -29,12 +43,17 @@ type marketHandlers struct {
 	getByQueryUseCase   usecases.IGetMarketByQueryUseCase
 	updateMarketUseCase usecases.IUpdateMarketUseCase
 	deleteUseCase       usecases.IDeleteMarketUseCase
+	timelineUseCase     usecases.IGetMarketsTimelineUseCase
+	summariesUseCase    usecases.IGetMarketSummariesUseCase
+	exportUseCase       usecases.IExportMarketsUseCase
+	pageUseCase         usecases.IGetMarketsPageUseCase
+	maxPageSize         int
 }
 
 func (pst marketHandlers) Create(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
 	vModel := viewmodels.MarketViewModel{}
 	if err := json.Unmarshal(httpRequest.Body, &vModel); err != nil {
-		return pst.httpResFactory.BadRequest("body is required", nil)
+		return pst.bodyError(err)
 	}
 
 	if validationErrs := pst.validator.ValidateStruct(vModel); validationErrs != nil {
@@ -53,12 +72,31 @@ func (pst marketHandlers) Create(httpRequest httpServer.HttpRequest) httpServer.
 	return pst.httpResFactory.Created(viewmodels.NewMarketViewModel(result), nil)
 }
 
+// GetByQuery lists the markets matching the query filters. With limit, offset, order_by or order it returns
+// that page only, with the count of every matching market in X-Total-Count.
 func (pst marketHandlers) GetByQuery(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
-	vModel, err := queryToMarketViewModel(httpRequest.Query)
+	filters, pagination, paged, err := pst.paginationFromQuery(httpRequest.Query)
+	if err != nil {
+		return pst.httpResFactory.BadRequest(err.Error(), nil)
+	}
+
+	vModel, err := queryToMarketViewModel(filters)
 	if err != nil {
 		return pst.httpResFactory.BadRequest(err.Error(), nil)
 	}
 
+	if paged {
+		page, err := pst.pageUseCase.Execute(httpRequest.Ctx, vModel.ToValueObject(), pagination)
+		if err != nil {
+			return pst.httpResFactory.ErrorResponseMapper(err, nil)
+		}
+
+		headers := http.Header{}
+		headers.Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+
+		return pst.httpResFactory.Ok(viewmodels.NewSliceOfMarketViewModel(page.Markets), headers)
+	}
+
 	result, err := pst.getByQueryUseCase.Execute(httpRequest.Ctx, vModel.ToValueObject())
 	if err != nil {
 		return pst.httpResFactory.ErrorResponseMapper(err, nil)
@@ -67,13 +105,71 @@ func (pst marketHandlers) GetByQuery(httpRequest httpServer.HttpRequest) httpSer
 	return pst.httpResFactory.Ok(viewmodels.NewSliceOfMarketViewModel(result), nil)
 }
 
+// paginationFromQuery takes limit, offset, order_by and order out of the query, leaving the filters. The limit
+// must be between 1 and MAX_PAGE_SIZE, the offset not negative and the order asc or desc. The order_by column
+// is checked by the repository.
+func (pst marketHandlers) paginationFromQuery(query map[string][]string) (map[string][]string, valueObjects.Pagination, bool, error) {
+	filters := make(map[string][]string, len(query))
+	for k, v := range query {
+		filters[k] = v
+	}
+
+	limit, hasLimit := filters["limit"]
+	offset, hasOffset := filters["offset"]
+	orderBy, hasOrderBy := filters["order_by"]
+	order, hasOrder := filters["order"]
+	delete(filters, "limit")
+	delete(filters, "offset")
+	delete(filters, "order_by")
+	delete(filters, "order")
+
+	if !hasLimit && !hasOffset && !hasOrderBy && !hasOrder {
+		return filters, valueObjects.Pagination{}, false, nil
+	}
+
+	pagination := valueObjects.Pagination{Limit: defaultListPageSize}
+	if hasLimit {
+		value, err := strconv.Atoi(limit[0])
+		if err != nil || value < 1 || value > pst.maxPageSize {
+			return nil, valueObjects.Pagination{}, false, fmt.Errorf("paramter: limit must be between 1 and %d", pst.maxPageSize)
+		}
+		pagination.Limit = value
+	}
+
+	if hasOffset {
+		value, err := strconv.Atoi(offset[0])
+		if err != nil || value < 0 {
+			return nil, valueObjects.Pagination{}, false, fmt.Errorf("paramter: offset must be a non-negative integer")
+		}
+		pagination.Offset = value
+	}
+
+	if hasOrderBy {
+		pagination.OrderBy = orderBy[0]
+	}
+
+	if hasOrder {
+		switch strings.ToLower(order[0]) {
+		case "asc":
+		case "desc":
+			pagination.Descending = true
+		default:
+			return nil, valueObjects.Pagination{}, false, fmt.Errorf("paramter: order must be asc or desc")
+		}
+	}
+
+	return filters, pagination, true, nil
+}
+
+var queryFieldNames = map[string]string{"nome_feira": "NomeFeira", "logradouro_contains": "LogradouroContains"}
+
 func queryToMarketViewModel(query map[string][]string) (viewmodels.MarketViewModel, error) {
 	vModel := viewmodels.MarketViewModel{}
 	voReflect := reflect.ValueOf(&vModel)
 	for k, v := range query {
 		var ff reflect.Value
-		if k == "nome_feira" {
-			ff = voReflect.Elem().FieldByName("NomeFeira")
+		if name, ok := queryFieldNames[k]; ok {
+			ff = voReflect.Elem().FieldByName(name)
 		} else {
 			ff = voReflect.Elem().FieldByName(strings.Title(k))
 		}
@@ -99,7 +195,7 @@ func queryToMarketViewModel(query map[string][]string) (viewmodels.MarketViewMod
 func (pst marketHandlers) Update(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
 	vModel := viewmodels.MarketViewModel{}
 	if err := json.Unmarshal(httpRequest.Body, &vModel); err != nil {
-		return pst.httpResFactory.BadRequest("body is required", nil)
+		return pst.bodyError(err)
 	}
 	if vModel.Registro != "" {
 		return pst.httpResFactory.BadRequest("the field 'registro' is not allowed", nil)
@@ -131,9 +227,121 @@ func (pst marketHandlers) Delete(httpRequest httpServer.HttpRequest) httpServer.
 	return pst.httpResFactory.Ok(struct{}{}, nil)
 }
 
+func (pst marketHandlers) Timeline(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	granularity := "day"
+	if values, ok := httpRequest.Query["granularity"]; ok && len(values) > 0 {
+		granularity = values[0]
+	}
+
+	result, err := pst.timelineUseCase.Execute(httpRequest.Ctx, granularity)
+	if err != nil {
+		return pst.httpResFactory.ErrorResponseMapper(err, nil)
+	}
+
+	return pst.httpResFactory.Ok(viewmodels.NewSliceOfMarketTimelineViewModel(result), nil)
+}
+
+func (pst marketHandlers) Summaries(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	vModel, err := queryToMarketViewModel(httpRequest.Query)
+	if err != nil {
+		return pst.httpResFactory.BadRequest(err.Error(), nil)
+	}
+
+	result, err := pst.summariesUseCase.Execute(httpRequest.Ctx, vModel.ToValueObject())
+	if err != nil {
+		return pst.httpResFactory.ErrorResponseMapper(err, nil)
+	}
+
+	return pst.httpResFactory.Ok(viewmodels.NewSliceOfMarketSummaryViewModel(result), nil)
+}
+
+func (pst marketHandlers) Export(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	result, truncated, err := pst.exportUseCase.Execute(httpRequest.Ctx)
+	if err != nil {
+		return pst.httpResFactory.ErrorResponseMapper(err, nil)
+	}
+
+	var headers http.Header
+	if truncated {
+		pst.logger.Warn(fmt.Sprintf("[MarketHandler::Export] - export truncated at %d rows", len(result)))
+		headers = http.Header{
+			"X-Export-Truncated": {"true"},
+			"Warning":            {fmt.Sprintf(`299 - "export truncated at %d rows"`, len(result))},
+		}
+	}
+
+	return pst.httpResFactory.Ok(viewmodels.NewSliceOfMarketViewModel(result), headers)
+}
+
+// Validate runs the checks Create would, the struct tags and the value object rules such as the coordinate
+// ranges, without persisting the market.
+func (pst marketHandlers) Validate(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	vModel := viewmodels.MarketViewModel{}
+	if err := json.Unmarshal(httpRequest.Body, &vModel); err != nil {
+		return pst.bodyError(err)
+	}
+
+	results := pst.validator.ValidateStruct(vModel)
+	if err, ok := vModel.ToValueObject().Validate().(valueObjects.MarketValidationError); ok {
+		results = mergeFieldErrors(results, err.Fields)
+	}
+
+	result := viewmodels.NewMarketValidationViewModel(results)
+	if !result.Valid {
+		return pst.httpResFactory.GenericResponse(http.StatusUnprocessableEntity, result, nil)
+	}
+
+	return pst.httpResFactory.Ok(result, nil)
+}
+
+func (pst marketHandlers) Schema(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	return pst.httpResFactory.Ok(viewmodels.NewMarketSchemaViewModel(), nil)
+}
+
+// ImportTemplate returns an empty CSV with the header row the importer expects.
+func (pst marketHandlers) ImportTemplate(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	template := bytes.Buffer{}
+	writer := csv.NewWriter(&template)
+	writer.Write(services.CSVHeaders())
+	writer.Flush()
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "text/csv; charset=utf-8")
+	headers.Set("Content-Disposition", `attachment; filename="import-template.csv"`)
+
+	return pst.httpResFactory.Ok(template.Bytes(), headers)
+}
+
+// mergeFieldErrors appends the errors of the fields not already reported, so a field keeps a single error.
+func mergeFieldErrors(results, more []valueObjects.ValidateResult) []valueObjects.ValidateResult {
+	reported := map[string]bool{}
+	for _, result := range results {
+		reported[result.Field] = true
+	}
+
+	for _, result := range more {
+		if !reported[result.Field] {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// bodyError surfaces the field coercion errors of the view model and hides the raw json ones.
+func (pst marketHandlers) bodyError(err error) httpServer.HttpResponse {
+	if _, ok := err.(errors.ValidationError); ok {
+		return pst.httpResFactory.ErrorResponseMapper(err, nil)
+	}
+
+	return pst.httpResFactory.BadRequest("body is required", nil)
+}
+
 func NewMarketHandlers(logger interfaces.ILogger, validator interfaces.IValidator, httpResFactory factories.HttpResponseFactory,
 	createUseCase usecases.ICreateMarketUseCase, getByQueyUseCase usecases.IGetMarketByQueryUseCase, updateMarketUseCase usecases.IUpdateMarketUseCase,
-	deleteUseCase usecases.IDeleteMarketUseCase) IMarketHandlers {
+	deleteUseCase usecases.IDeleteMarketUseCase, timelineUseCase usecases.IGetMarketsTimelineUseCase,
+	summariesUseCase usecases.IGetMarketSummariesUseCase, exportUseCase usecases.IExportMarketsUseCase,
+	pageUseCase usecases.IGetMarketsPageUseCase, maxPageSize int) IMarketHandlers {
 
 	return marketHandlers{
 		logger,
@@ -143,5 +351,10 @@ func NewMarketHandlers(logger interfaces.ILogger, validator interfaces.IValidato
 		getByQueyUseCase,
 		updateMarketUseCase,
 		deleteUseCase,
+		timelineUseCase,
+		summariesUseCase,
+		exportUseCase,
+		pageUseCase,
+		maxPageSize,
 	}
 }
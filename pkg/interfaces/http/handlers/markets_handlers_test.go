@@ -1,21 +1,31 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/ralvescosta/base/pkg/app/errors"
+	"github.com/ralvescosta/base/pkg/app/services"
 	"github.com/ralvescosta/base/pkg/app/usecases"
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+	"github.com/ralvescosta/base/pkg/infra/adapters"
 	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
 	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/infra/repositories"
 	"github.com/ralvescosta/base/pkg/infra/validator"
 	"github.com/ralvescosta/base/pkg/interfaces/http/factories"
 	viewmodels "github.com/ralvescosta/base/pkg/interfaces/http/view_models"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -41,6 +51,29 @@ func Test_Market_Create(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 	})
 
+	t.Run("should accept coddist and codsubpref sent as numeric strings", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		body := bytes.Replace(sut.createMarketHttpRequest.Body, []byte(`"coddist":10`), []byte(`"coddist":"10"`), 1)
+		body = bytes.Replace(body, []byte(`"codsubpref":10`), []byte(`"codsubpref":"10"`), 1)
+		sut.validator.On("ValidateStruct", sut.marketViewModelMocked).Return([]valueObjects.ValidateResult(nil))
+		sut.createUseCase.On("Execute", sut.createMarketHttpRequest.Ctx, sut.marketViewModelMocked.ToValueObject()).Return(valueObjects.MarketValueObjects{}, false, nil)
+
+		res := sut.handler.Create(httpServer.HttpRequest{Ctx: sut.createMarketHttpRequest.Ctx, Body: body})
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		sut.createUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should return unprocessableEntity naming the field if coddist is not numeric", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.Create(httpServer.HttpRequest{Body: []byte(`{"coddist":"abc"}`)})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, `coddist must be an integer, received: "abc"`, res.Body.(viewmodels.ErrorMessage).Error.Message)
+	})
+
 	t.Run("should return badRequest if body is unformatted", func(t *testing.T) {
 		sut := makeMarketHandlersSut()
 
@@ -66,6 +99,31 @@ func Test_Market_Create(t *testing.T) {
 		sut.createUseCase.AssertExpectations(t)
 	})
 
+	t.Run("should return tooManyRequests with Retry-After under backpressure", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.validator.On("ValidateStruct", sut.marketViewModelMocked).Return([]valueObjects.ValidateResult(nil))
+		sut.createUseCase.On("Execute", sut.createMarketHttpRequest.Ctx, sut.marketViewModelMocked.ToValueObject()).Return(valueObjects.MarketValueObjects{}, false, errors.NewBackpressureError("too many writes in flight", 10*time.Second))
+
+		res := sut.handler.Create(sut.createMarketHttpRequest)
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Equal(t, "10", res.Headers.Get("Retry-After"))
+	})
+
+	t.Run("should return conflict if the registro already exists", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.validator.On("ValidateStruct", sut.marketViewModelMocked).Return([]valueObjects.ValidateResult(nil))
+		sut.createUseCase.On("Execute", sut.createMarketHttpRequest.Ctx, sut.marketViewModelMocked.ToValueObject()).Return(valueObjects.MarketValueObjects{}, false, errors.ErrMarketAlreadyExists)
+
+		res := sut.handler.Create(sut.createMarketHttpRequest)
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, viewmodels.NewErrorMessage("CONFLICT", "market already exists"), res.Body)
+		sut.createUseCase.AssertExpectations(t)
+	})
+
 	t.Run("should return Ok if the market was already created", func(t *testing.T) {
 		sut := makeMarketHandlersSut()
 
@@ -106,6 +164,34 @@ func Test_Market_GetByQuey(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 	})
 
+	t.Run("should map logradouro_contains to the filter", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.getByQueryHTTPRequest.Query = map[string][]string{"logradouro_contains": {"MARAGOJIPE"}}
+		sut.getByQueyUseCase.On(
+			"Execute",
+			sut.getByQueryHTTPRequest.Ctx,
+			valueObjects.MarketValueObjects{LogradouroContains: "MARAGOJIPE"},
+		).Return([]valueObjects.MarketValueObjects{{}}, nil)
+
+		res := sut.handler.GetByQuery(sut.getByQueryHTTPRequest)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		sut.getByQueyUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should reject includeDeleted since deleted markets are never listed", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.getByQueryHTTPRequest.Query = map[string][]string{"includeDeleted": {"true"}}
+		sut.getByQueryHTTPRequest.Headers = map[string][]string{"Authorization": {"Bearer admin"}}
+
+		res := sut.handler.GetByQuery(sut.getByQueryHTTPRequest)
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		sut.getByQueyUseCase.AssertNotCalled(t, "Execute")
+	})
+
 	t.Run("should internalServerError if usecase return internalError", func(t *testing.T) {
 		sut := makeMarketHandlersSut()
 
@@ -122,6 +208,135 @@ func Test_Market_GetByQuey(t *testing.T) {
 	})
 }
 
+func Test_Market_GetByQuery_Paged(t *testing.T) {
+	t.Run("should return the page and the total count", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		query := map[string][]string{
+			"distrito": {"VILA FORMOSA"}, "regiao5": {"Leste"}, "nome_feira": {"VILA FORMOSA"}, "bairro": {"VL FORMOSA"},
+			"limit": {"10"}, "offset": {"20"},
+		}
+		filter := valueObjects.MarketValueObjects{Distrito: "VILA FORMOSA", Regiao5: "Leste", NomeFeira: "VILA FORMOSA", Bairro: "VL FORMOSA"}
+		sut.pageUseCase.On("Execute", ctx, filter, valueObjects.Pagination{Limit: 10, Offset: 20}).
+			Return(valueObjects.MarketPage{Markets: []valueObjects.MarketValueObjects{{ID: 21}}, Total: 21, Limit: 10, Offset: 20}, nil)
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: ctx, Query: query})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Len(t, res.Body, 1)
+		assert.Equal(t, "21", res.Headers.Get("X-Total-Count"))
+		assert.Len(t, query, 6)
+		sut.pageUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should default the limit when only the offset is sent", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.pageUseCase.On("Execute", ctx, valueObjects.MarketValueObjects{}, valueObjects.Pagination{Limit: defaultListPageSize, Offset: 40}).
+			Return(valueObjects.MarketPage{Markets: []valueObjects.MarketValueObjects{}}, nil)
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: ctx, Query: map[string][]string{"offset": {"40"}}})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		sut.pageUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should return badRequest if the limit is out of range", func(t *testing.T) {
+		for _, limit := range []string{"0", "101", "-1", "ten"} {
+			sut := makeMarketHandlersSut()
+
+			res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: context.Background(), Query: map[string][]string{"limit": {limit}}})
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, limit)
+			assert.Equal(t, "paramter: limit must be between 1 and 100", res.Body.(viewmodels.ErrorMessage).Error.Message)
+		}
+	})
+
+	t.Run("should bound the limit by MAX_PAGE_SIZE", func(t *testing.T) {
+		os.Setenv("MAX_PAGE_SIZE", "50")
+		defer os.Unsetenv("MAX_PAGE_SIZE")
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: context.Background(), Query: map[string][]string{"limit": {"51"}}})
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("should return badRequest if the offset is negative", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: context.Background(), Query: map[string][]string{"offset": {"-1"}}})
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("should page the listing ordered by order_by and order", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.pageUseCase.On("Execute", ctx, valueObjects.MarketValueObjects{Distrito: "VILA FORMOSA"},
+			valueObjects.Pagination{Limit: defaultListPageSize, OrderBy: "criado_em", Descending: true}).
+			Return(valueObjects.MarketPage{Markets: []valueObjects.MarketValueObjects{}}, nil)
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: ctx,
+			Query: map[string][]string{"distrito": {"VILA FORMOSA"}, "order_by": {"criado_em"}, "order": {"DESC"}}})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		sut.pageUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should return badRequest if the order is not asc or desc", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: context.Background(), Query: map[string][]string{"order": {"up"}}})
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, "paramter: order must be asc or desc", res.Body.(viewmodels.ErrorMessage).Error.Message)
+	})
+
+	t.Run("should return unprocessableEntity if the order_by column is not sortable", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.pageUseCase.On("Execute", ctx, valueObjects.MarketValueObjects{}, valueObjects.Pagination{Limit: defaultListPageSize, OrderBy: "registro"}).
+			Return(valueObjects.MarketPage{}, errors.NewValidationError("order_by must be one of nome_feira, distrito, bairro, criado_em"))
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: ctx, Query: map[string][]string{"order_by": {"registro"}}})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("should return internalServerError if usecase return internalError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.pageUseCase.On("Execute", ctx, valueObjects.MarketValueObjects{}, valueObjects.Pagination{Limit: 5}).
+			Return(valueObjects.MarketPage{}, errors.NewInternalError(""))
+
+		res := sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: ctx, Query: map[string][]string{"limit": {"5"}}})
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should answer an empty array over http when nothing matches", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.pageUseCase.On("Execute", mock.Anything, valueObjects.MarketValueObjects{Distrito: "NOWHERE"}, valueObjects.Pagination{Limit: 10}).
+			Return(valueObjects.MarketPage{Markets: []valueObjects.MarketValueObjects{}}, nil)
+
+		router := gin.New()
+		router.GET("/api/v1/markets", adapters.HandlerAdapt(sut.handler.GetByQuery, sut.logger))
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/markets?distrito=NOWHERE&limit=10", nil))
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "[]", res.Body.String())
+		assert.Equal(t, "0", res.Header().Get("X-Total-Count"))
+	})
+}
+
 func Test_Market_Update(t *testing.T) {
 	t.Run("should execute correctly", func(t *testing.T) {
 		sut := makeMarketHandlersSut()
@@ -177,6 +392,29 @@ func Test_Market_Update(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 		sut.updateUseCase.AssertExpectations(t)
 	})
+
+	t.Run("should return unprocessableEntity if usecase return a constraint validationError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.marketViewModelMocked.Registro = ""
+		sut.updateUseCase.On("Execute", sut.updateHTTPRequest.Ctx, "registro", sut.marketViewModelMocked.ToValueObject()).Return(valueObjects.MarketValueObjects{}, errors.NewValidationError("nome_feira is required"))
+
+		res := sut.handler.Update(sut.updateHTTPRequest)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, viewmodels.NewErrorMessage("VALIDATION_FAILED", "nome_feira is required"), res.Body)
+	})
+
+	t.Run("should return conflict if usecase return a constraint conflictError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.marketViewModelMocked.Registro = ""
+		sut.updateUseCase.On("Execute", sut.updateHTTPRequest.Ctx, "registro", sut.marketViewModelMocked.ToValueObject()).Return(valueObjects.MarketValueObjects{}, errors.NewConflictError("feiras_distrito_fkey constraint violated"))
+
+		res := sut.handler.Update(sut.updateHTTPRequest)
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
 }
 
 func Test_Market_Delete(t *testing.T) {
@@ -210,6 +448,312 @@ func Test_Market_Delete(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 	})
+
+	t.Run("should return methodNotAllowed if usecase return readOnlyError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.deleteUseCase.On("Execute", sut.deleteMarketHTTPRequest.Ctx, "registro").Return(errors.ErrReadOnly)
+
+		res := sut.handler.Delete(sut.deleteMarketHTTPRequest)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	})
+}
+
+func Test_Market_Timeline(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.timelineUseCase.On("Execute", sut.timelineHTTPRequest.Ctx, "month").Return([]valueObjects.MarketTimelineValueObjects{{Count: 1}}, nil)
+
+		res := sut.handler.Timeline(sut.timelineHTTPRequest)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		sut.timelineUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should default to day granularity", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.timelineHTTPRequest.Query = map[string][]string{}
+		sut.timelineUseCase.On("Execute", sut.timelineHTTPRequest.Ctx, "day").Return([]valueObjects.MarketTimelineValueObjects{}, nil)
+
+		res := sut.handler.Timeline(sut.timelineHTTPRequest)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		sut.timelineUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should return unprocessableEntity if usecase return validationError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.timelineHTTPRequest.Query = map[string][]string{"granularity": {"year"}}
+		sut.timelineUseCase.On("Execute", sut.timelineHTTPRequest.Ctx, "year").Return([]valueObjects.MarketTimelineValueObjects(nil), errors.NewValidationError(""))
+
+		res := sut.handler.Timeline(sut.timelineHTTPRequest)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	})
+
+	t.Run("should return internalServerError if usecase return internalError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.timelineUseCase.On("Execute", sut.timelineHTTPRequest.Ctx, "month").Return([]valueObjects.MarketTimelineValueObjects(nil), errors.NewInternalError(""))
+
+		res := sut.handler.Timeline(sut.timelineHTTPRequest)
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func Test_Market_Validate(t *testing.T) {
+	t.Run("should return ok when the payload is valid", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.validator.On("ValidateStruct", sut.marketViewModelMocked).Return([]valueObjects.ValidateResult(nil))
+
+		res := sut.handler.Validate(sut.createMarketHttpRequest)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, viewmodels.MarketValidationViewModel{Valid: true}, res.Body)
+		sut.createUseCase.AssertNotCalled(t, "Execute")
+	})
+
+	t.Run("should return unprocessableEntity with the field errors when the payload is invalid", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.validator.On("ValidateStruct", sut.marketViewModelMocked).Return([]valueObjects.ValidateResult{
+			{IsValid: false, Field: "Registro", Message: "Registro is required"},
+			{IsValid: false, Field: "Long", Message: "Long is required"},
+		})
+
+		res := sut.handler.Validate(sut.createMarketHttpRequest)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, viewmodels.MarketValidationViewModel{
+			Valid: false,
+			Errors: []viewmodels.FieldErrorViewModel{
+				{Field: "Registro", Message: "Registro is required"},
+				{Field: "Long", Message: "Long is required"},
+			},
+		}, res.Body)
+		sut.createUseCase.AssertNotCalled(t, "Execute")
+	})
+
+	t.Run("should return unprocessableEntity when a coordinate is out of range", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		vModel := sut.marketViewModelMocked
+		vModel.Lat = 91000000
+		body, _ := json.Marshal(vModel)
+		sut.validator.On("ValidateStruct", vModel).Return([]valueObjects.ValidateResult(nil))
+
+		res := sut.handler.Validate(httpServer.HttpRequest{Body: body})
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, viewmodels.MarketValidationViewModel{
+			Valid:  false,
+			Errors: []viewmodels.FieldErrorViewModel{{Field: "Lat", Message: "Lat must be between -90000000 and 90000000"}},
+		}, res.Body)
+	})
+
+	t.Run("should report a single error per field", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		vModel := sut.marketViewModelMocked
+		vModel.Registro = ""
+		body, _ := json.Marshal(vModel)
+		sut.validator.On("ValidateStruct", vModel).Return([]valueObjects.ValidateResult{
+			{IsValid: false, Field: "Registro", Message: "Registro is required"},
+		})
+
+		res := sut.handler.Validate(httpServer.HttpRequest{Body: body})
+
+		assert.Equal(t, viewmodels.MarketValidationViewModel{
+			Valid:  false,
+			Errors: []viewmodels.FieldErrorViewModel{{Field: "Registro", Message: "Registro is required"}},
+		}, res.Body)
+	})
+
+	t.Run("should return badRequest if body is no present", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.Validate(httpServer.HttpRequest{Body: []byte("")})
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func Test_Market_Schema(t *testing.T) {
+	t.Run("should return the market fields metadata", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.Schema(httpServer.HttpRequest{})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		fields := res.Body.([]viewmodels.FieldSchemaViewModel)
+		assert.Contains(t, fields, viewmodels.FieldSchemaViewModel{Name: "registro", Type: "string", Required: true})
+		assert.Contains(t, fields, viewmodels.FieldSchemaViewModel{Name: "lat", Type: "integer", Required: true})
+	})
+}
+
+func Test_Market_ImportTemplate(t *testing.T) {
+	t.Run("should return an empty csv with the importer columns", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.ImportTemplate(httpServer.HttpRequest{Ctx: context.Background()})
+
+		rows, err := csv.NewReader(bytes.NewReader(res.Body.([]byte))).ReadAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, [][]string{services.CSVHeaders()}, rows)
+		assert.Equal(t, "text/csv; charset=utf-8", res.Headers.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="import-template.csv"`, res.Headers.Get("Content-Disposition"))
+	})
+
+	t.Run("should be accepted by the importer", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		res := sut.handler.ImportTemplate(httpServer.HttpRequest{Ctx: context.Background()})
+		sut.logger.On("Info", "[ImportService::ImportCSV] - 0 imported, 0 skipped", mock.Anything)
+
+		report, err := services.NewImportService(sut.logger, repositories.NewMarketRepositorySpy()).
+			ImportCSV(context.Background(), bytes.NewReader(res.Body.([]byte)))
+
+		assert.NoError(t, err)
+		assert.Equal(t, services.ImportReport{Errors: []services.RowError{}}, report)
+	})
+}
+
+func Test_Market_Summaries(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.summariesUseCase.On(
+			"Execute",
+			sut.getByQueryHTTPRequest.Ctx,
+			viewmodels.MarketViewModel{Bairro: "bairro", NomeFeira: "nomeFeira", Coddist: 10}.ToValueObject(),
+		).Return([]valueObjects.MarketSummary{{ID: 1, NomeFeira: "nomeFeira", Long: -100, Lat: -200}}, nil)
+
+		res := sut.handler.Summaries(sut.getByQueryHTTPRequest)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []viewmodels.MarketSummaryViewModel{{ID: 1, NomeFeira: "nomeFeira", Long: -100, Lat: -200}}, res.Body)
+		sut.summariesUseCase.AssertExpectations(t)
+	})
+
+	t.Run("should return badRequest if received a invalid query parameter", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.getByQueryHTTPRequest.Query = map[string][]string{"wrong": {"wrong"}}
+
+		res := sut.handler.Summaries(sut.getByQueryHTTPRequest)
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("should return internalServerError if usecase return internalError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.summariesUseCase.On(
+			"Execute",
+			sut.getByQueryHTTPRequest.Ctx,
+			viewmodels.MarketViewModel{Bairro: "bairro", NomeFeira: "nomeFeira", Coddist: 10}.ToValueObject(),
+		).Return([]valueObjects.MarketSummary(nil), errors.NewInternalError(""))
+
+		res := sut.handler.Summaries(sut.getByQueryHTTPRequest)
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		sut.summariesUseCase.AssertExpectations(t)
+	})
+}
+
+func Test_Market_Export(t *testing.T) {
+	t.Run("should export without the truncation headers when the cap is not hit", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.exportUseCase.On("Execute", ctx).Return([]valueObjects.MarketValueObjects{{ID: 1}}, false, nil)
+
+		res := sut.handler.Export(httpServer.HttpRequest{Ctx: ctx})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Len(t, res.Body, 1)
+		assert.Empty(t, res.Headers.Get("X-Export-Truncated"))
+		assert.Empty(t, res.Headers.Get("Warning"))
+	})
+
+	t.Run("should set the truncation headers when the cap is hit", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.exportUseCase.On("Execute", ctx).Return([]valueObjects.MarketValueObjects{{ID: 1}, {ID: 2}}, true, nil)
+		sut.logger.On("Warn", "[MarketHandler::Export] - export truncated at 2 rows", []zapcore.Field(nil))
+
+		res := sut.handler.Export(httpServer.HttpRequest{Ctx: ctx})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Len(t, res.Body, 2)
+		assert.Equal(t, "true", res.Headers.Get("X-Export-Truncated"))
+		assert.Equal(t, `299 - "export truncated at 2 rows"`, res.Headers.Get("Warning"))
+		sut.logger.AssertExpectations(t)
+	})
+
+	t.Run("should return internalServerError if usecase return internalError", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		ctx := context.Background()
+		sut.exportUseCase.On("Execute", ctx).Return([]valueObjects.MarketValueObjects(nil), false, errors.NewInternalError(""))
+
+		res := sut.handler.Export(httpServer.HttpRequest{Ctx: ctx})
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func Test_Market_EmptyLists(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should serialize an empty search as []", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.getByQueyUseCase.On("Execute", ctx, valueObjects.MarketValueObjects{}).Return([]valueObjects.MarketValueObjects(nil), nil)
+
+		assertEmptyArray(t, sut.handler.GetByQuery(httpServer.HttpRequest{Ctx: ctx}))
+	})
+
+	t.Run("should serialize an empty timeline as []", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.timelineUseCase.On("Execute", ctx, "day").Return([]valueObjects.MarketTimelineValueObjects(nil), nil)
+
+		assertEmptyArray(t, sut.handler.Timeline(httpServer.HttpRequest{Ctx: ctx}))
+	})
+
+	t.Run("should serialize empty summaries as []", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.summariesUseCase.On("Execute", ctx, valueObjects.MarketValueObjects{}).Return([]valueObjects.MarketSummary(nil), nil)
+
+		assertEmptyArray(t, sut.handler.Summaries(httpServer.HttpRequest{Ctx: ctx}))
+	})
+
+	t.Run("should serialize an empty export as []", func(t *testing.T) {
+		sut := makeMarketHandlersSut()
+
+		sut.exportUseCase.On("Execute", ctx).Return([]valueObjects.MarketValueObjects(nil), false, nil)
+
+		assertEmptyArray(t, sut.handler.Export(httpServer.HttpRequest{Ctx: ctx}))
+	})
+}
+
+func assertEmptyArray(t *testing.T, res httpServer.HttpResponse) {
+	body, err := json.Marshal(res.Body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "[]", string(body))
 }
 
 type marketHandlersSutRtn struct {
@@ -220,12 +764,17 @@ type marketHandlersSutRtn struct {
 	getByQueyUseCase        *usecases.GetMarketByQueryUseCaseSpy
 	updateUseCase           *usecases.UpdateMarketUseCaseSpy
 	deleteUseCase           *usecases.DeleteMarketUseCaseSpy
+	timelineUseCase         *usecases.GetMarketsTimelineUseCaseSpy
+	summariesUseCase        *usecases.GetMarketSummariesUseCaseSpy
+	exportUseCase           *usecases.ExportMarketsUseCaseSpy
+	pageUseCase             *usecases.GetMarketsPageUseCaseSpy
 	handler                 IMarketHandlers
 	marketViewModelMocked   viewmodels.MarketViewModel
 	createMarketHttpRequest httpServer.HttpRequest
 	getByQueryHTTPRequest   httpServer.HttpRequest
 	updateHTTPRequest       httpServer.HttpRequest
 	deleteMarketHTTPRequest httpServer.HttpRequest
+	timelineHTTPRequest     httpServer.HttpRequest
 }
 
 func makeMarketHandlersSut() marketHandlersSutRtn {
@@ -236,8 +785,13 @@ func makeMarketHandlersSut() marketHandlersSutRtn {
 	getByQueryUseCase := usecases.NewGetMarketByQueryUseCaseSpy()
 	updateUseCase := usecases.NewUpdateMarketUseCaseSpy()
 	deleteUseCase := usecases.NewDeleteMarketUseCaseSpy()
+	timelineUseCase := usecases.NewGetMarketsTimelineUseCaseSpy()
+	summariesUseCase := usecases.NewGetMarketSummariesUseCaseSpy()
+	exportUseCase := usecases.NewExportMarketsUseCaseSpy()
+	pageUseCase := usecases.NewGetMarketsPageUseCaseSpy()
 
-	handler := NewMarketHandlers(logger, validator, httpResFactor, createUseCase, getByQueryUseCase, updateUseCase, deleteUseCase)
+	handler := NewMarketHandlers(logger, validator, httpResFactor, createUseCase, getByQueryUseCase, updateUseCase, deleteUseCase, timelineUseCase,
+		summariesUseCase, exportUseCase, pageUseCase, repositories.MaxPageSizeFromEnv(logger))
 
 	marketViewModelMocked := viewmodels.MarketViewModel{
 		Long:       -100,
@@ -283,6 +837,11 @@ func makeMarketHandlersSut() marketHandlersSutRtn {
 		Params: map[string]string{"registerCode": "registro"},
 	}
 
+	timelineHTTPRequest := httpServer.HttpRequest{
+		Ctx:   context.Background(),
+		Query: map[string][]string{"granularity": {"month"}},
+	}
+
 	return marketHandlersSutRtn{
 		logger,
 		validator,
@@ -291,11 +850,16 @@ func makeMarketHandlersSut() marketHandlersSutRtn {
 		getByQueryUseCase,
 		updateUseCase,
 		deleteUseCase,
+		timelineUseCase,
+		summariesUseCase,
+		exportUseCase,
+		pageUseCase,
 		handler,
 		marketViewModelMocked,
 		createMarketHTTPRequest,
 		getByQueryHTTPRequest,
 		updateHTTPRequest,
 		deleteMarketHTTPRequest,
+		timelineHTTPRequest,
 	}
 }
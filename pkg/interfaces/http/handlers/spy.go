@@ -30,7 +30,72 @@ func (pst MarketsHandlersSpy) Delete(httpRequest httpServer.HttpRequest) httpSer
 
 	return args.Get(0).(httpServer.HttpResponse)
 }
+func (pst MarketsHandlersSpy) Timeline(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+func (pst MarketsHandlersSpy) Summaries(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+func (pst MarketsHandlersSpy) Export(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+func (pst MarketsHandlersSpy) Validate(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+func (pst MarketsHandlersSpy) Schema(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+
+func (pst MarketsHandlersSpy) ImportTemplate(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
 
 func NewMarketsHandlersSpy() *MarketsHandlersSpy {
 	return new(MarketsHandlersSpy)
 }
+
+type HealthHandlersSpy struct {
+	mock.Mock
+}
+
+func (pst HealthHandlersSpy) Readyz(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+
+func (pst HealthHandlersSpy) Live(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+
+func NewHealthHandlersSpy() *HealthHandlersSpy {
+	return new(HealthHandlersSpy)
+}
+
+type AdminHandlersSpy struct {
+	mock.Mock
+}
+
+func (pst AdminHandlersSpy) Reload(httpRequest httpServer.HttpRequest) httpServer.HttpResponse {
+	args := pst.Called(httpRequest)
+
+	return args.Get(0).(httpServer.HttpResponse)
+}
+
+func NewAdminHandlersSpy() *AdminHandlersSpy {
+	return new(AdminHandlersSpy)
+}
@@ -61,3 +61,129 @@ func Test_MarketHandlerSpy_Delete(t *testing.T) {
 		sut.AssertExpectations(t)
 	})
 }
+
+func Test_MarketHandlerSpy_Timeline(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketsHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Timeline", req).Return(httpServer.HttpResponse{})
+
+		sut.Timeline(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_MarketHandlerSpy_Validate(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketsHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Validate", req).Return(httpServer.HttpResponse{})
+
+		sut.Validate(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_HealthHandlerSpy_Readyz(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewHealthHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Readyz", req).Return(httpServer.HttpResponse{})
+
+		sut.Readyz(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_HealthHandlerSpy_Live(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewHealthHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Live", req).Return(httpServer.HttpResponse{})
+
+		sut.Live(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_MarketHandlerSpy_Summaries(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketsHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Summaries", req).Return(httpServer.HttpResponse{})
+
+		sut.Summaries(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_MarketHandlerSpy_Export(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketsHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Export", req).Return(httpServer.HttpResponse{})
+
+		sut.Export(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_MarketHandlerSpy_Schema(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketsHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Schema", req).Return(httpServer.HttpResponse{})
+
+		sut.Schema(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_MarketHandlerSpy_ImportTemplate(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewMarketsHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("ImportTemplate", req).Return(httpServer.HttpResponse{})
+
+		sut.ImportTemplate(req)
+
+		sut.AssertExpectations(t)
+	})
+}
+
+func Test_AdminHandlerSpy_Reload(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		sut := NewAdminHandlersSpy()
+
+		req := httpServer.HttpRequest{}
+
+		sut.On("Reload", req).Return(httpServer.HttpResponse{})
+
+		sut.Reload(req)
+
+		sut.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,28 @@
+package presenters
+
+import (
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/adapters"
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/interfaces/http/handlers"
+)
+
+type adminRoutes struct {
+	logger   interfaces.ILogger
+	handlers handlers.IAdminHandlers
+	groups   RouteGroups
+}
+
+func (pst adminRoutes) Register(httpServer httpServer.IHTTPServer) {
+	if pst.groups.Enabled(AdminRouteGroup) {
+		httpServer.RegisterRoute("POST", "/admin/reload", adapters.HandlerAdapt(pst.handlers.Reload, pst.logger))
+	}
+}
+
+func NewAdminRoutes(logger interfaces.ILogger, handlers handlers.IAdminHandlers) IRoutes {
+	return adminRoutes{
+		logger,
+		handlers,
+		RouteGroupsFromEnv(),
+	}
+}
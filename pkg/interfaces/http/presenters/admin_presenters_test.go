@@ -0,0 +1,34 @@
+package presenters
+
+import (
+	"os"
+	"testing"
+
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/interfaces/http/handlers"
+)
+
+func Test_Admin_Register(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		server := httpServer.NewHTTPServerSpy()
+		routes := NewAdminRoutes(logger.NewLoggerSpy(), handlers.NewAdminHandlersSpy())
+
+		server.On("RegisterRoute", "POST", "/admin/reload").Return(nil)
+
+		routes.Register(server)
+
+		server.AssertExpectations(t)
+	})
+
+	t.Run("should not register the admin routes when the admin group is disabled", func(t *testing.T) {
+		os.Setenv("ROUTES_ADMIN_ENABLED", "false")
+		defer os.Unsetenv("ROUTES_ADMIN_ENABLED")
+		server := httpServer.NewHTTPServerSpy()
+		routes := NewAdminRoutes(logger.NewLoggerSpy(), handlers.NewAdminHandlersSpy())
+
+		routes.Register(server)
+
+		server.AssertNotCalled(t, "RegisterRoute", "POST", "/admin/reload")
+	})
+}
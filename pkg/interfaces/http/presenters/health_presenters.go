@@ -0,0 +1,26 @@
+package presenters
+
+import (
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+	"github.com/ralvescosta/base/pkg/infra/adapters"
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/interfaces/http/handlers"
+)
+
+type healthRoutes struct {
+	logger   interfaces.ILogger
+	handlers handlers.IHealthHandlers
+}
+
+func (pst healthRoutes) Register(httpServer httpServer.IHTTPServer) {
+	httpServer.RegisterRoute("GET", "/health/live", adapters.HandlerAdapt(pst.handlers.Live, pst.logger))
+	httpServer.RegisterRoute("GET", "/health/ready", adapters.HandlerAdapt(pst.handlers.Readyz, pst.logger))
+	httpServer.RegisterRoute("GET", "/readyz", adapters.HandlerAdapt(pst.handlers.Readyz, pst.logger))
+}
+
+func NewHealthRoutes(logger interfaces.ILogger, handlers handlers.IHealthHandlers) IRoutes {
+	return healthRoutes{
+		logger,
+		handlers,
+	}
+}
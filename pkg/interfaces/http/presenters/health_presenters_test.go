@@ -0,0 +1,24 @@
+package presenters
+
+import (
+	"testing"
+
+	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
+	"github.com/ralvescosta/base/pkg/infra/logger"
+	"github.com/ralvescosta/base/pkg/interfaces/http/handlers"
+)
+
+func Test_Health_Register(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		server := httpServer.NewHTTPServerSpy()
+		routes := NewHealthRoutes(logger.NewLoggerSpy(), handlers.NewHealthHandlersSpy())
+
+		server.On("RegisterRoute", "GET", "/health/live").Return(nil)
+		server.On("RegisterRoute", "GET", "/health/ready").Return(nil)
+		server.On("RegisterRoute", "GET", "/readyz").Return(nil)
+
+		routes.Register(server)
+
+		server.AssertExpectations(t)
+	})
+}
@@ -10,18 +10,32 @@ import (
 type marketRoutes struct {
 	logger   interfaces.ILogger
 	handlers handlers.IMarketHandlers
+	groups   RouteGroups
 }
 
 func (pst marketRoutes) Register(httpServer httpServer.IHTTPServer) {
-	httpServer.RegisterRoute("POST", "/api/v1/markets", adapters.HandlerAdapt(pst.handlers.Create, pst.logger))
 	httpServer.RegisterRoute("GET", "/api/v1/markets", adapters.HandlerAdapt(pst.handlers.GetByQuery, pst.logger))
-	httpServer.RegisterRoute("PATCH", "/api/v1/markets/:registerCode", adapters.HandlerAdapt(pst.handlers.Update, pst.logger))
-	httpServer.RegisterRoute("DELETE", "/api/v1/markets/:registerCode", adapters.HandlerAdapt(pst.handlers.Delete, pst.logger))
+	httpServer.RegisterRoute("GET", "/api/v1/markets/schema", adapters.HandlerAdapt(pst.handlers.Schema, pst.logger))
+	httpServer.RegisterRoute("GET", "/api/v1/markets/import-template.csv", adapters.HandlerAdapt(pst.handlers.ImportTemplate, pst.logger))
+	httpServer.RegisterRoute("POST", "/api/v1/markets/validate", adapters.HandlerAdapt(pst.handlers.Validate, pst.logger))
+	httpServer.RegisterRoute("GET", "/api/v1/markets/stats/timeline", adapters.HandlerAdapt(pst.handlers.Timeline, pst.logger))
+	httpServer.RegisterRoute("GET", "/api/v1/markets/summaries", adapters.HandlerAdapt(pst.handlers.Summaries, pst.logger))
+
+	if pst.groups.Enabled(WritesRouteGroup) {
+		httpServer.RegisterRoute("POST", "/api/v1/markets", adapters.HandlerAdapt(pst.handlers.Create, pst.logger))
+		httpServer.RegisterRoute("PATCH", "/api/v1/markets/:registerCode", adapters.HandlerAdapt(pst.handlers.Update, pst.logger))
+		httpServer.RegisterRoute("DELETE", "/api/v1/markets/:registerCode", adapters.HandlerAdapt(pst.handlers.Delete, pst.logger))
+	}
+
+	if pst.groups.Enabled(ExportRouteGroup) {
+		httpServer.RegisterRoute("GET", "/api/v1/markets/export", adapters.HandlerAdapt(pst.handlers.Export, pst.logger))
+	}
 }
 
 func NewMarketRoutes(logger interfaces.ILogger, handlers handlers.IMarketHandlers) IRoutes {
 	return marketRoutes{
 		logger,
 		handlers,
+		RouteGroupsFromEnv(),
 	}
 }
@@ -1,11 +1,14 @@
 package presenters
 
 import (
+	"os"
 	"testing"
 
 	httpServer "github.com/ralvescosta/base/pkg/infra/http_server"
 	"github.com/ralvescosta/base/pkg/infra/logger"
 	"github.com/ralvescosta/base/pkg/interfaces/http/handlers"
+
+	"github.com/stretchr/testify/mock"
 )
 
 func Test_Market_Register(t *testing.T) {
@@ -16,15 +19,60 @@ func Test_Market_Register(t *testing.T) {
 		sut.handlers.On("GetByQuery").Return(httpServer.HttpResponse{})
 		sut.handlers.On("Update").Return(httpServer.HttpResponse{})
 		sut.handlers.On("Delete").Return(httpServer.HttpResponse{})
+		sut.handlers.On("Timeline").Return(httpServer.HttpResponse{})
+		sut.handlers.On("Validate").Return(httpServer.HttpResponse{})
+		sut.handlers.On("Schema").Return(httpServer.HttpResponse{})
+		sut.handlers.On("ImportTemplate").Return(httpServer.HttpResponse{})
+		sut.handlers.On("Summaries").Return(httpServer.HttpResponse{})
+		sut.handlers.On("Export").Return(httpServer.HttpResponse{})
 		sut.server.On("RegisterRoute", "POST", "/api/v1/markets").Return(nil)
 		sut.server.On("RegisterRoute", "GET", "/api/v1/markets").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/schema").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/import-template.csv").Return(nil)
+		sut.server.On("RegisterRoute", "POST", "/api/v1/markets/validate").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/stats/timeline").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/summaries").Return(nil)
 		sut.server.On("RegisterRoute", "PATCH", "/api/v1/markets/:registerCode").Return(nil)
 		sut.server.On("RegisterRoute", "DELETE", "/api/v1/markets/:registerCode").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/export").Return(nil)
 
 		sut.routes.Register(sut.server)
 
 		sut.server.AssertExpectations(t)
 	})
+
+	t.Run("should not register the write routes when the writes group is disabled", func(t *testing.T) {
+		os.Setenv("ROUTES_WRITES_ENABLED", "false")
+		defer os.Unsetenv("ROUTES_WRITES_ENABLED")
+		sut := makeMarketsPresentersSut()
+
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/schema").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/import-template.csv").Return(nil)
+		sut.server.On("RegisterRoute", "POST", "/api/v1/markets/validate").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/stats/timeline").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/summaries").Return(nil)
+		sut.server.On("RegisterRoute", "GET", "/api/v1/markets/export").Return(nil)
+
+		sut.routes.Register(sut.server)
+
+		sut.server.AssertExpectations(t)
+		sut.server.AssertNotCalled(t, "RegisterRoute", "POST", "/api/v1/markets")
+		sut.server.AssertNotCalled(t, "RegisterRoute", "PATCH", "/api/v1/markets/:registerCode")
+		sut.server.AssertNotCalled(t, "RegisterRoute", "DELETE", "/api/v1/markets/:registerCode")
+	})
+
+	t.Run("should not register the export route when the export group is disabled", func(t *testing.T) {
+		os.Setenv("ROUTES_EXPORT_ENABLED", "false")
+		defer os.Unsetenv("ROUTES_EXPORT_ENABLED")
+		sut := makeMarketsPresentersSut()
+
+		sut.server.On("RegisterRoute", mock.Anything, mock.Anything).Return(nil)
+
+		sut.routes.Register(sut.server)
+
+		sut.server.AssertNotCalled(t, "RegisterRoute", "GET", "/api/v1/markets/export")
+	})
 }
 
 type marketsPresentersSutRtn struct {
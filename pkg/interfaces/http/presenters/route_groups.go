@@ -0,0 +1,34 @@
+package presenters
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type RouteGroup string
+
+const (
+	WritesRouteGroup RouteGroup = "writes"
+	AdminRouteGroup  RouteGroup = "admin"
+	ExportRouteGroup RouteGroup = "export"
+)
+
+// RouteGroups tells which groups of routes are registered. Each group is enabled unless
+// ROUTES_<GROUP>_ENABLED is false, so a read-only mirror can set ROUTES_WRITES_ENABLED=false.
+// Routes of a disabled group are never registered and answer 404.
+type RouteGroups map[RouteGroup]bool
+
+func (pst RouteGroups) Enabled(group RouteGroup) bool {
+	enabled, ok := pst[group]
+	return !ok || enabled
+}
+
+func RouteGroupsFromEnv() RouteGroups {
+	groups := RouteGroups{}
+	for _, group := range []RouteGroup{WritesRouteGroup, AdminRouteGroup, ExportRouteGroup} {
+		groups[group] = os.Getenv(fmt.Sprintf("ROUTES_%s_ENABLED", strings.ToUpper(string(group)))) != "false"
+	}
+
+	return groups
+}
@@ -0,0 +1,35 @@
+package presenters
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RouteGroupsFromEnv(t *testing.T) {
+	t.Run("should enable every group by default", func(t *testing.T) {
+		groups := RouteGroupsFromEnv()
+
+		assert.True(t, groups.Enabled(WritesRouteGroup))
+		assert.True(t, groups.Enabled(AdminRouteGroup))
+		assert.True(t, groups.Enabled(ExportRouteGroup))
+	})
+
+	t.Run("should disable the groups configured as false", func(t *testing.T) {
+		os.Setenv("ROUTES_WRITES_ENABLED", "false")
+		os.Setenv("ROUTES_EXPORT_ENABLED", "false")
+		defer os.Unsetenv("ROUTES_WRITES_ENABLED")
+		defer os.Unsetenv("ROUTES_EXPORT_ENABLED")
+
+		groups := RouteGroupsFromEnv()
+
+		assert.False(t, groups.Enabled(WritesRouteGroup))
+		assert.True(t, groups.Enabled(AdminRouteGroup))
+		assert.False(t, groups.Enabled(ExportRouteGroup))
+	})
+
+	t.Run("should enable a group that is not configured", func(t *testing.T) {
+		assert.True(t, RouteGroups{}.Enabled(AdminRouteGroup))
+	})
+}
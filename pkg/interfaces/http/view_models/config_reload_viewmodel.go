@@ -0,0 +1,29 @@
+package viewmodels
+
+import (
+	"fmt"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+)
+
+type ConfigReloadViewModel struct {
+	Applied map[string]string `json:"applied"`
+	Ignored []string          `json:"ignored"`
+	Notes   []string          `json:"notes"`
+}
+
+func NewConfigReloadViewModel(result interfaces.ReloadResult) ConfigReloadViewModel {
+	vm := ConfigReloadViewModel{Applied: result.Applied, Ignored: result.Ignored, Notes: []string{}}
+	if vm.Applied == nil {
+		vm.Applied = map[string]string{}
+	}
+	if vm.Ignored == nil {
+		vm.Ignored = []string{}
+	}
+
+	for _, key := range vm.Ignored {
+		vm.Notes = append(vm.Notes, fmt.Sprintf("%s is not reloadable, restart the service to apply it", key))
+	}
+
+	return vm
+}
@@ -0,0 +1,27 @@
+package viewmodels
+
+import (
+	"testing"
+
+	"github.com/ralvescosta/base/pkg/app/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewConfigReloadViewModel(t *testing.T) {
+	t.Run("should add a note for each ignored value", func(t *testing.T) {
+		result := NewConfigReloadViewModel(interfaces.ReloadResult{Applied: map[string]string{"LOG_LEVEL": "warn"}, Ignored: []string{"DB_HOST"}})
+
+		assert.Equal(t, ConfigReloadViewModel{
+			Applied: map[string]string{"LOG_LEVEL": "warn"},
+			Ignored: []string{"DB_HOST"},
+			Notes:   []string{"DB_HOST is not reloadable, restart the service to apply it"},
+		}, result)
+	})
+
+	t.Run("should return empty collections when receive an empty result", func(t *testing.T) {
+		result := NewConfigReloadViewModel(interfaces.ReloadResult{})
+
+		assert.Equal(t, ConfigReloadViewModel{Applied: map[string]string{}, Ignored: []string{}, Notes: []string{}}, result)
+	})
+}
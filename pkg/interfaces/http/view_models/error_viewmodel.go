@@ -1,12 +1,21 @@
 package viewmodels
 
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorMessage is the body of every error response: {"error":{"code":"NOT_FOUND","message":"..."}}.
 type ErrorMessage struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
+	Error ErrorDetail `json:"error"`
+}
+
+func NewErrorMessage(code, message string) ErrorMessage {
+	return ErrorMessage{Error: ErrorDetail{Code: code, Message: message}}
 }
 
 func StringToErrorResponse(message string) ErrorMessage {
 	return ErrorMessage{
-		Message: message,
+		Error: ErrorDetail{Message: message},
 	}
 }
@@ -0,0 +1,63 @@
+package viewmodels
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type FieldSchemaViewModel struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Required  bool   `json:"required"`
+	MaxLength int    `json:"max_length,omitempty"`
+}
+
+// NewMarketSchemaViewModel describes the fields accepted in a market body, reading the json and validate
+// tags of MarketViewModel so the schema follows the validation rules.
+func NewMarketSchemaViewModel() []FieldSchemaViewModel {
+	t := reflect.TypeOf(MarketViewModel{})
+
+	result := make([]FieldSchemaViewModel, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema := FieldSchemaViewModel{Name: name, Type: schemaType(field.Type)}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			switch {
+			case rule == "required":
+				schema.Required = true
+			case strings.HasPrefix(rule, "max="):
+				schema.MaxLength, _ = strconv.Atoi(strings.TrimPrefix(rule, "max="))
+			}
+		}
+
+		result = append(result, schema)
+	}
+
+	return result
+}
+
+func schemaType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64:
+		return "integer"
+	case reflect.String:
+		return "string"
+	}
+
+	if t.Name() == "Time" {
+		return "datetime"
+	}
+
+	return t.Kind().String()
+}
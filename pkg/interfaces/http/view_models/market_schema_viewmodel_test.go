@@ -0,0 +1,31 @@
+package viewmodels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewMarketSchemaViewModel(t *testing.T) {
+	t.Run("should describe the market fields from the struct tags", func(t *testing.T) {
+		result := NewMarketSchemaViewModel()
+
+		fields := map[string]FieldSchemaViewModel{}
+		for _, field := range result {
+			fields[field.Name] = field
+		}
+
+		assert.Len(t, result, 17)
+		assert.Equal(t, FieldSchemaViewModel{Name: "long", Type: "integer", Required: true}, result[0])
+		assert.Equal(t, FieldSchemaViewModel{Name: "nome_feira", Type: "string", Required: true}, fields["nome_feira"])
+		assert.Equal(t, FieldSchemaViewModel{Name: "coddist", Type: "integer", Required: true}, fields["coddist"])
+		assert.Equal(t, FieldSchemaViewModel{Name: "deletado_em", Type: "datetime", Required: false}, fields["deletado_em"])
+	})
+
+	t.Run("should skip the fields hidden from json", func(t *testing.T) {
+		for _, field := range NewMarketSchemaViewModel() {
+			assert.NotEqual(t, "-", field.Name)
+			assert.NotEqual(t, "LogradouroContains", field.Name)
+		}
+	})
+}
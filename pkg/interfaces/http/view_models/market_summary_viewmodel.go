@@ -0,0 +1,23 @@
+package viewmodels
+
+import (
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+// MarketSummaryViewModel carries the id or, with DB_ID_TYPE=uuid, the uuid of the market.
+type MarketSummaryViewModel struct {
+	ID        int    `json:"id,omitempty"`
+	UUID      string `json:"uuid,omitempty"`
+	NomeFeira string `json:"nome_feira"`
+	Long      int    `json:"long"`
+	Lat       int    `json:"lat"`
+}
+
+func NewSliceOfMarketSummaryViewModel(vo []valueObjects.MarketSummary) []MarketSummaryViewModel {
+	result := make([]MarketSummaryViewModel, 0, len(vo))
+	for _, v := range vo {
+		result = append(result, MarketSummaryViewModel{ID: v.ID, UUID: v.UUID, NomeFeira: v.NomeFeira, Long: v.Long, Lat: v.Lat})
+	}
+
+	return result
+}
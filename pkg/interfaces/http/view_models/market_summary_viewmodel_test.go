@@ -0,0 +1,33 @@
+package viewmodels
+
+import (
+	"encoding/json"
+	"testing"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSliceOfMarketSummaryViewModel(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		result := NewSliceOfMarketSummaryViewModel([]valueObjects.MarketSummary{{ID: 1, NomeFeira: "VILA FORMOSA", Long: -46550164, Lat: -23558733}})
+
+		assert.Equal(t, []MarketSummaryViewModel{{ID: 1, NomeFeira: "VILA FORMOSA", Long: -46550164, Lat: -23558733}}, result)
+	})
+
+	t.Run("should carry the uuid instead of a zero id", func(t *testing.T) {
+		result := NewSliceOfMarketSummaryViewModel([]valueObjects.MarketSummary{{UUID: "4b0e8ac4-2a0a-4c9f-9a4e-58d0c1e0b6f1", NomeFeira: "VILA FORMOSA"}})
+
+		body, _ := json.Marshal(result)
+
+		assert.JSONEq(t, `[{"uuid":"4b0e8ac4-2a0a-4c9f-9a4e-58d0c1e0b6f1","nome_feira":"VILA FORMOSA","long":0,"lat":0}]`, string(body))
+	})
+
+	t.Run("should return an empty slice when receive nil", func(t *testing.T) {
+		result := NewSliceOfMarketSummaryViewModel(nil)
+
+		assert.NotNil(t, result)
+		assert.Len(t, result, 0)
+	})
+}
@@ -0,0 +1,21 @@
+package viewmodels
+
+import (
+	"time"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type MarketTimelineViewModel struct {
+	Period time.Time `json:"period"`
+	Count  int64     `json:"count"`
+}
+
+func NewSliceOfMarketTimelineViewModel(vo []valueObjects.MarketTimelineValueObjects) []MarketTimelineViewModel {
+	result := make([]MarketTimelineViewModel, 0, len(vo))
+	for _, v := range vo {
+		result = append(result, MarketTimelineViewModel{Period: v.Period, Count: v.Count})
+	}
+
+	return result
+}
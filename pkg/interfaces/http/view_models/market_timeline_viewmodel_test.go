@@ -0,0 +1,27 @@
+package viewmodels
+
+import (
+	"testing"
+	"time"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSliceOfMarketTimelineViewModel(t *testing.T) {
+	t.Run("should execute correctly", func(t *testing.T) {
+		period := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		result := NewSliceOfMarketTimelineViewModel([]valueObjects.MarketTimelineValueObjects{{Period: period, Count: 3}})
+
+		assert.Equal(t, []MarketTimelineViewModel{{Period: period, Count: 3}}, result)
+	})
+
+	t.Run("should return an empty slice when receive nil", func(t *testing.T) {
+		result := NewSliceOfMarketTimelineViewModel(nil)
+
+		assert.NotNil(t, result)
+		assert.Len(t, result, 0)
+	})
+}
@@ -0,0 +1,28 @@
+package viewmodels
+
+import (
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
+
+type FieldErrorViewModel struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type MarketValidationViewModel struct {
+	Valid  bool                  `json:"valid"`
+	Errors []FieldErrorViewModel `json:"errors,omitempty"`
+}
+
+func NewMarketValidationViewModel(results []valueObjects.ValidateResult) MarketValidationViewModel {
+	if len(results) == 0 {
+		return MarketValidationViewModel{Valid: true}
+	}
+
+	errs := make([]FieldErrorViewModel, 0, len(results))
+	for _, result := range results {
+		errs = append(errs, FieldErrorViewModel{Field: result.Field, Message: result.Message})
+	}
+
+	return MarketValidationViewModel{Valid: false, Errors: errs}
+}
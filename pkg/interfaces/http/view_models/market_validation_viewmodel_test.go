@@ -0,0 +1,27 @@
+package viewmodels
+
+import (
+	"encoding/json"
+	"testing"
+
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewMarketValidationViewModel(t *testing.T) {
+	t.Run("should be valid when there is no validation result", func(t *testing.T) {
+		result := NewMarketValidationViewModel(nil)
+
+		body, _ := json.Marshal(result)
+
+		assert.Equal(t, `{"valid":true}`, string(body))
+	})
+
+	t.Run("should list the field errors", func(t *testing.T) {
+		result := NewMarketValidationViewModel([]valueObjects.ValidateResult{{Field: "Registro", Message: "Registro is required"}})
+
+		assert.False(t, result.Valid)
+		assert.Equal(t, []FieldErrorViewModel{{Field: "Registro", Message: "Registro is required"}}, result.Errors)
+	})
+}
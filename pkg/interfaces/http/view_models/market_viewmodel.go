@@ -1,24 +1,96 @@
 package viewmodels
 
-import valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ralvescosta/base/pkg/app/errors"
+	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
+)
 
 type MarketViewModel struct {
-	Long       int    `json:"long" validate:"required"`
-	Lat        int    `json:"lat" validate:"required"`
-	Setcens    string `json:"setcens" validate:"required"`
-	Areap      string `json:"areap" validate:"required"`
-	Coddist    int    `json:"coddist" validate:"required"`
-	Distrito   string `json:"distrito" validate:"required"`
-	Codsubpref int    `json:"codsubpref" validate:"required"`
-	Subpref    string `json:"subpref" validate:"required"`
-	Regiao5    string `json:"regiao5" validate:"required"`
-	Regiao8    string `json:"regiao8" validate:"required"`
-	NomeFeira  string `json:"nome_feira" validate:"required"`
-	Registro   string `json:"registro" validate:"required"`
-	Logradouro string `json:"logradouro" validate:"required"`
-	Numero     string `json:"numero" validate:"required"`
-	Bairro     string `json:"bairro" validate:"required"`
-	Referencia string `json:"referencia" validate:"required"`
+	Long       int        `json:"long" validate:"required"`
+	Lat        int        `json:"lat" validate:"required"`
+	Setcens    string     `json:"setcens" validate:"required"`
+	Areap      string     `json:"areap" validate:"required"`
+	Coddist    int        `json:"coddist" validate:"required"`
+	Distrito   string     `json:"distrito" validate:"required"`
+	Codsubpref int        `json:"codsubpref" validate:"required"`
+	Subpref    string     `json:"subpref" validate:"required"`
+	Regiao5    string     `json:"regiao5" validate:"required"`
+	Regiao8    string     `json:"regiao8" validate:"required"`
+	NomeFeira  string     `json:"nome_feira" validate:"required"`
+	Registro   string     `json:"registro" validate:"required"`
+	Logradouro string     `json:"logradouro" validate:"required"`
+	Numero     string     `json:"numero" validate:"required"`
+	Bairro     string     `json:"bairro" validate:"required"`
+	Referencia string     `json:"referencia" validate:"required"`
+	DeletadoEm *time.Time `json:"deletado_em,omitempty"`
+
+	LogradouroContains string `json:"-"`
+}
+
+// MarshalJSON drops a zero deletado_em, so only deleted markets carry the field.
+func (pst MarketViewModel) MarshalJSON() ([]byte, error) {
+	type marketViewModel MarketViewModel
+
+	if pst.DeletadoEm != nil && pst.DeletadoEm.IsZero() {
+		pst.DeletadoEm = nil
+	}
+
+	return json.Marshal(marketViewModel(pst))
+}
+
+// UnmarshalJSON accepts coddist and codsubpref either as numbers or as numeric strings, since CSV-fed
+// clients send them quoted. Anything else is rejected with a ValidationError naming the field.
+func (pst *MarketViewModel) UnmarshalJSON(data []byte) error {
+	type marketViewModel MarketViewModel
+
+	aux := struct {
+		*marketViewModel
+		Coddist    json.RawMessage `json:"coddist"`
+		Codsubpref json.RawMessage `json:"codsubpref"`
+	}{marketViewModel: (*marketViewModel)(pst)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if pst.Coddist, err = coerceInt("coddist", aux.Coddist); err != nil {
+		return err
+	}
+	if pst.Codsubpref, err = coerceInt("codsubpref", aux.Codsubpref); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func coerceInt(field string, raw json.RawMessage) (int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var number int
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return 0, errors.NewValidationError(fmt.Sprintf("%s must be an integer", field))
+	}
+
+	number, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.NewValidationError(fmt.Sprintf("%s must be an integer, received: %q", field, text))
+	}
+
+	return number, nil
 }
 
 func (pst MarketViewModel) ToValueObject() valueObjects.MarketValueObjects {
@@ -39,15 +111,13 @@ func (pst MarketViewModel) ToValueObject() valueObjects.MarketValueObjects {
 		Numero:     pst.Numero,
 		Bairro:     pst.Bairro,
 		Referencia: pst.Referencia,
+
+		LogradouroContains: pst.LogradouroContains,
 	}
 }
 
 func NewSliceOfMarketViewModel(vo []valueObjects.MarketValueObjects) []MarketViewModel {
-	if len(vo) == 0 {
-		return []MarketViewModel{}
-	}
-
-	var result []MarketViewModel
+	result := make([]MarketViewModel, 0, len(vo))
 	for _, v := range vo {
 		result = append(result, NewMarketViewModel(v))
 	}
@@ -73,5 +143,6 @@ func NewMarketViewModel(vo valueObjects.MarketValueObjects) MarketViewModel {
 		Numero:     vo.Numero,
 		Bairro:     vo.Bairro,
 		Referencia: vo.Referencia,
+		DeletadoEm: vo.DeletadoEm,
 	}
 }
@@ -1,8 +1,11 @@
 package viewmodels
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/ralvescosta/base/pkg/app/errors"
 	valueObjects "github.com/ralvescosta/base/pkg/domain/value_objects"
 
 	"github.com/stretchr/testify/assert"
@@ -61,3 +64,90 @@ func Test_NewSliceOfMarketViewModel(t *testing.T) {
 		assert.Equal(t, len(sut), 0)
 	})
 }
+
+func Test_MarketViewModel_MarshalJSON(t *testing.T) {
+	t.Run("should omit deletado_em for active markets", func(t *testing.T) {
+		body, err := json.Marshal(NewMarketViewModel(valueObjects.MarketValueObjects{Registro: "registro"}))
+
+		assert.NoError(t, err)
+		assert.NotContains(t, string(body), "deletado_em")
+	})
+
+	t.Run("should omit a zero deletado_em", func(t *testing.T) {
+		zero := time.Time{}
+
+		body, err := json.Marshal(MarketViewModel{Registro: "registro", DeletadoEm: &zero})
+
+		assert.NoError(t, err)
+		assert.NotContains(t, string(body), "deletado_em")
+	})
+
+	t.Run("should emit deletado_em for deleted markets", func(t *testing.T) {
+		deletedAt := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+
+		body, err := json.Marshal(NewMarketViewModel(valueObjects.MarketValueObjects{Registro: "registro", DeletadoEm: &deletedAt}))
+
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"deletado_em":"2022-03-10T12:00:00Z"`)
+		assert.Contains(t, string(body), `"registro":"registro"`)
+	})
+}
+
+func Test_MarketViewModel_UnmarshalJSON(t *testing.T) {
+	t.Run("should keep numeric coddist and codsubpref", func(t *testing.T) {
+		sut := MarketViewModel{}
+
+		err := json.Unmarshal([]byte(`{"coddist":87,"codsubpref":26,"distrito":"VILA FORMOSA"}`), &sut)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 87, sut.Coddist)
+		assert.Equal(t, 26, sut.Codsubpref)
+		assert.Equal(t, "VILA FORMOSA", sut.Distrito)
+	})
+
+	t.Run("should parse numeric strings into ints", func(t *testing.T) {
+		sut := MarketViewModel{}
+
+		err := json.Unmarshal([]byte(`{"coddist":"87","codsubpref":" 26 ","long":-46550164}`), &sut)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 87, sut.Coddist)
+		assert.Equal(t, 26, sut.Codsubpref)
+		assert.Equal(t, -46550164, sut.Long)
+	})
+
+	t.Run("should leave missing and null fields as zero", func(t *testing.T) {
+		sut := MarketViewModel{}
+
+		err := json.Unmarshal([]byte(`{"coddist":null}`), &sut)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sut.Coddist)
+		assert.Equal(t, 0, sut.Codsubpref)
+	})
+
+	t.Run("should return a validationError on non-numeric strings", func(t *testing.T) {
+		sut := MarketViewModel{}
+
+		err := json.Unmarshal([]byte(`{"coddist":"87a"}`), &sut)
+
+		assert.Equal(t, errors.NewValidationError(`coddist must be an integer, received: "87a"`), err)
+	})
+
+	t.Run("should return a validationError on non-numeric values", func(t *testing.T) {
+		sut := MarketViewModel{}
+
+		err := json.Unmarshal([]byte(`{"codsubpref":true}`), &sut)
+
+		assert.Equal(t, errors.NewValidationError("codsubpref must be an integer"), err)
+	})
+
+	t.Run("should still fail on malformed json", func(t *testing.T) {
+		sut := MarketViewModel{}
+
+		err := json.Unmarshal([]byte(`{"coddist":`), &sut)
+
+		assert.Error(t, err)
+		assert.IsType(t, &json.SyntaxError{}, err)
+	})
+}